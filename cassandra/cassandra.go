@@ -0,0 +1,414 @@
+package cassandra
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+
+	"github.com/philippgille/gokv"
+	"github.com/philippgille/gokv/util"
+)
+
+var defaultTimeout = 5 * time.Second
+
+// Client is a gokv.Store implementation for Cassandra.
+// It also implements gokv.CompareAndSwapper.
+type Client struct {
+	s                 *gocql.Session
+	table             string
+	readConsistency   gocql.Consistency
+	writeConsistency  gocql.Consistency
+	serialConsistency gocql.SerialConsistency
+	marshalFormat     MarshalFormat
+}
+
+// Set stores the given value for the given key.
+// Values are automatically marshalled to JSON or gob (depending on the configuration).
+// The key must not be "" and the value must not be nil.
+func (c Client) Set(k string, v interface{}) error {
+	if err := util.CheckKeyAndValue(k, v); err != nil {
+		return err
+	}
+
+	data, err := c.marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return c.s.Query("INSERT INTO "+c.table+" (k, v) VALUES (?, ?)", k, data).
+		Consistency(c.writeConsistency).
+		Exec()
+}
+
+// SetIfNotExists stores the given value for the given key, but only if no value is stored for the key yet.
+// It uses a lightweight transaction.
+// If a value already exists, it returns (false, nil).
+// Values are automatically marshalled to JSON or gob (depending on the configuration).
+// The key must not be "" and the value must not be nil.
+func (c Client) SetIfNotExists(k string, v interface{}) (created bool, err error) {
+	if err := util.CheckKeyAndValue(k, v); err != nil {
+		return false, err
+	}
+
+	data, err := c.marshal(v)
+	if err != nil {
+		return false, err
+	}
+
+	// If the row already exists, Cassandra returns its columns in addition to the "applied" column.
+	return c.s.Query("INSERT INTO "+c.table+" (k, v) VALUES (?, ?) IF NOT EXISTS", k, data).
+		Consistency(c.writeConsistency).
+		SerialConsistency(c.serialConsistency).
+		ScanCAS(new(string), new([]byte))
+}
+
+// Get retrieves the stored value for the given key.
+// You need to pass a pointer to the value, so in case of a struct
+// the automatic unmarshalling can populate the fields of the object
+// that v points to with the values of the retrieved object's values.
+// If no value is found it returns (false, nil).
+// The key must not be "" and the pointer must not be nil.
+func (c Client) Get(k string, v interface{}) (found bool, err error) {
+	if err := util.CheckKeyAndValue(k, v); err != nil {
+		return false, err
+	}
+
+	var data []byte
+	err = c.s.Query("SELECT v FROM "+c.table+" WHERE k = ?", k).
+		Consistency(c.readConsistency).
+		Scan(&data)
+	// If no value was found return false
+	if err == gocql.ErrNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, c.unmarshal(data, v)
+}
+
+// Delete deletes the stored value for the given key.
+// Deleting a non-existing key-value pair does NOT lead to an error.
+// The key must not be "".
+func (c Client) Delete(k string) error {
+	if err := util.CheckKey(k); err != nil {
+		return err
+	}
+
+	return c.s.Query("DELETE FROM "+c.table+" WHERE k = ?", k).
+		Consistency(c.writeConsistency).
+		Exec()
+}
+
+// CompareAndSwap stores newV for the given key, but only if the currently stored value equals oldV.
+// It uses a lightweight transaction.
+// Both values are marshalled with the configured marshal format and compared in their marshalled form.
+// If no value is stored for the key or the stored value differs, it returns (false, nil).
+// The key must not be "" and the values must not be nil.
+func (c Client) CompareAndSwap(k string, oldV, newV interface{}) (swapped bool, err error) {
+	if err := util.CheckKeyAndValue(k, oldV); err != nil {
+		return false, err
+	}
+	if err := util.CheckVal(newV); err != nil {
+		return false, err
+	}
+
+	oldData, err := c.marshal(oldV)
+	if err != nil {
+		return false, err
+	}
+	newData, err := c.marshal(newV)
+	if err != nil {
+		return false, err
+	}
+
+	// If the value differs, Cassandra returns the current value in addition to the "applied" column.
+	// If the row doesn't exist, it only returns the "applied" column.
+	return c.s.Query("UPDATE "+c.table+" SET v = ? WHERE k = ? IF v = ?", newData, k, oldData).
+		Consistency(c.writeConsistency).
+		SerialConsistency(c.serialConsistency).
+		ScanCAS(new([]byte))
+}
+
+// Close closes the client.
+// It must be called to close all connections to the cluster.
+func (c Client) Close() error {
+	c.s.Close()
+	return nil
+}
+
+// marshal marshals the given value according to the configured marshal format.
+func (c Client) marshal(v interface{}) ([]byte, error) {
+	switch c.marshalFormat {
+	case JSON:
+		return util.ToJSON(v)
+	case Gob:
+		return util.ToGob(v)
+	default:
+		return nil, errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	}
+}
+
+// unmarshal unmarshals the given data according to the configured marshal format.
+func (c Client) unmarshal(data []byte, v interface{}) error {
+	switch c.marshalFormat {
+	case JSON:
+		return util.FromJSON(data, v)
+	case Gob:
+		return util.FromGob(data, v)
+	default:
+		return errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	}
+}
+
+// MarshalFormat is an enum for the available (un-)marshal formats of this gokv.Store implementation.
+type MarshalFormat int
+
+const (
+	// JSON is the MarshalFormat for (un-)marshalling to/from JSON
+	JSON MarshalFormat = iota
+	// Gob is the MarshalFormat for (un-)marshalling to/from gob
+	Gob
+)
+
+// Options are the options for the Cassandra client.
+type Options struct {
+	// Addresses of the Cassandra nodes to connect to initially, with or without port.
+	// The other nodes of the cluster are discovered automatically.
+	// Optional ([]string{"127.0.0.1"} by default).
+	Hosts []string
+	// Name of the keyspace in which the table is created.
+	// If the keyspace doesn't exist yet, gokv creates it with the configured replication,
+	// unless DisableAutoProvisioning is set.
+	// Optional ("gokv" by default).
+	Keyspace string
+	// Name of the table in which the key-value pairs are stored.
+	// If the table doesn't exist yet, gokv creates it, unless DisableAutoProvisioning is set.
+	// Optional ("Item" by default).
+	TableName string
+	// Replication factor for the keyspace when it's created with the SimpleStrategy.
+	// Only used when the keyspace doesn't exist yet and DataCenterReplication is empty.
+	// Optional (1 by default).
+	ReplicationFactor int
+	// Replication factor per data center, for example map[string]int{"dc1": 3, "dc2": 3}.
+	// If set, the keyspace is created with the NetworkTopologyStrategy, which is recommended for production.
+	// Only used when the keyspace doesn't exist yet.
+	// Optional (nil by default).
+	DataCenterReplication map[string]int
+	// Consistency level for reads.
+	// gocql.Any can't be used, because it's the zero value and leads to the default value.
+	// Optional (gocql.LocalQuorum by default).
+	ReadConsistency gocql.Consistency
+	// Consistency level for writes.
+	// gocql.Any can't be used, because it's the zero value and leads to the default value.
+	// Optional (gocql.LocalQuorum by default).
+	WriteConsistency gocql.Consistency
+	// Consistency level for the consensus phase of lightweight transactions.
+	// Optional (gocql.LocalSerial by default).
+	SerialConsistency gocql.SerialConsistency
+	// Username for the PasswordAuthenticator.
+	// Optional ("" by default, which means no authentication).
+	Username string
+	// Password for the PasswordAuthenticator.
+	// Optional ("" by default).
+	Password string
+	// Timeout for connecting to the nodes and for queries.
+	// Optional (5 * time.Second by default).
+	Timeout *time.Duration
+	// (Un-)marshal format.
+	// Optional (JSON by default).
+	MarshalFormat MarshalFormat
+	// Prevents NewClient() from creating the keyspace and table.
+	// Instead, it returns a *gokv.ResourceNotFoundError if the keyspace or table doesn't exist.
+	// The keyspace and table can then be created separately with Provision().
+	// Optional (false by default).
+	DisableAutoProvisioning bool
+}
+
+// DefaultOptions is an Options object with default values.
+// Hosts: []string{"127.0.0.1"}, Keyspace: "gokv", TableName: "Item", ReplicationFactor: 1, DataCenterReplication: nil,
+// ReadConsistency: gocql.LocalQuorum, WriteConsistency: gocql.LocalQuorum, SerialConsistency: gocql.LocalSerial,
+// Username: "", Password: "", Timeout: 5 * time.Second, MarshalFormat: JSON, DisableAutoProvisioning: false
+var DefaultOptions = Options{
+	Hosts:             []string{"127.0.0.1"},
+	Keyspace:          "gokv",
+	TableName:         "Item",
+	ReplicationFactor: 1,
+	ReadConsistency:   gocql.LocalQuorum,
+	WriteConsistency:  gocql.LocalQuorum,
+	SerialConsistency: gocql.LocalSerial,
+	Timeout:           &defaultTimeout,
+	// No need to set DataCenterReplication, Username, Password or MarshalFormat because their zero values are fine.
+}
+
+// NewClient creates a new Cassandra client.
+// Unless DisableAutoProvisioning is set, the keyspace and table are created if they don't exist yet.
+//
+// Note: The keyspace and table name are used in CQL statements without escaping,
+// so you must make sure that they don't contain CQL injections.
+func NewClient(options Options) (Client, error) {
+	result := Client{}
+
+	// Set default values
+	options = setDefaults(options)
+	session, err := newSession(options)
+	if err != nil {
+		return result, err
+	}
+
+	table := options.Keyspace + "." + options.TableName
+	if options.DisableAutoProvisioning {
+		// Don't create anything, only make sure the keyspace and table exist.
+		err = checkSchema(session, options)
+	} else {
+		// Create keyspace and table if they don't exist yet.
+		// Schema changes are always executed with consistency level ALL by Cassandra,
+		// and gocql waits for the schema agreement of all nodes.
+		err = session.Query("CREATE KEYSPACE IF NOT EXISTS " + options.Keyspace + " WITH replication = " + replication(options)).Exec()
+		if err == nil {
+			err = session.Query("CREATE TABLE IF NOT EXISTS " + table + " (k text PRIMARY KEY, v blob)").Exec()
+		}
+	}
+	if err != nil {
+		session.Close()
+		return result, err
+	}
+
+	result.s = session
+	result.table = table
+	result.readConsistency = options.ReadConsistency
+	result.writeConsistency = options.WriteConsistency
+	result.serialConsistency = options.SerialConsistency
+	result.marshalFormat = options.MarshalFormat
+
+	return result, nil
+}
+
+// Provision creates the keyspace and table for the given options if they don't exist yet,
+// the same way NewClient() does.
+// It's meant to be called once in a separate setup step,
+// for example with a user that has more permissions than the one that's used by the application.
+// DisableAutoProvisioning is ignored.
+func Provision(options Options) error {
+	options.DisableAutoProvisioning = false
+	client, err := NewClient(options)
+	if err != nil {
+		return err
+	}
+	return client.Close()
+}
+
+// Drop deletes the table for the given options, including all stored key-value pairs.
+// The keyspace isn't deleted, because it might contain other tables.
+// Dropping a non-existing table or a table in a non-existing keyspace does NOT lead to an error.
+func Drop(options Options) error {
+	options = setDefaults(options)
+	session, err := newSession(options)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	found, err := exists(session, "SELECT keyspace_name FROM system_schema.keyspaces WHERE keyspace_name = ?", strings.ToLower(options.Keyspace))
+	if err != nil || !found {
+		return err
+	}
+	return session.Query("DROP TABLE IF EXISTS " + options.Keyspace + "." + options.TableName).Exec()
+}
+
+// checkSchema returns a *gokv.ResourceNotFoundError if the keyspace or table for the given options doesn't exist.
+// Unquoted names are case-insensitive in CQL and stored in lower case.
+func checkSchema(session *gocql.Session, options Options) error {
+	keyspace := strings.ToLower(options.Keyspace)
+	found, err := exists(session, "SELECT keyspace_name FROM system_schema.keyspaces WHERE keyspace_name = ?", keyspace)
+	if err != nil {
+		return err
+	} else if !found {
+		return &gokv.ResourceNotFoundError{ResourceType: "keyspace", Name: options.Keyspace}
+	}
+	found, err = exists(session, "SELECT table_name FROM system_schema.tables WHERE keyspace_name = ? AND table_name = ?", keyspace, strings.ToLower(options.TableName))
+	if err != nil {
+		return err
+	} else if !found {
+		return &gokv.ResourceNotFoundError{ResourceType: "table", Name: options.Keyspace + "." + options.TableName}
+	}
+	return nil
+}
+
+// exists returns true if the given query, which must select a single column, returns a row.
+func exists(session *gocql.Session, stmt string, values ...interface{}) (bool, error) {
+	var name string
+	err := session.Query(stmt, values...).Scan(&name)
+	if err == gocql.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// setDefaults sets the default values of all options that aren't set.
+func setDefaults(options Options) Options {
+	if len(options.Hosts) == 0 {
+		options.Hosts = DefaultOptions.Hosts
+	}
+	if options.Keyspace == "" {
+		options.Keyspace = DefaultOptions.Keyspace
+	}
+	if options.TableName == "" {
+		options.TableName = DefaultOptions.TableName
+	}
+	if options.ReplicationFactor == 0 {
+		options.ReplicationFactor = DefaultOptions.ReplicationFactor
+	}
+	if options.ReadConsistency == 0 {
+		options.ReadConsistency = DefaultOptions.ReadConsistency
+	}
+	if options.WriteConsistency == 0 {
+		options.WriteConsistency = DefaultOptions.WriteConsistency
+	}
+	if options.SerialConsistency == 0 {
+		options.SerialConsistency = DefaultOptions.SerialConsistency
+	}
+	if options.Timeout == nil {
+		options.Timeout = DefaultOptions.Timeout
+	}
+	return options
+}
+
+// newSession creates a session for the cluster that's configured in the options.
+// The options must already contain the default values.
+func newSession(options Options) (*gocql.Session, error) {
+	cluster := gocql.NewCluster(options.Hosts...)
+	cluster.Timeout = *options.Timeout
+	cluster.ConnectTimeout = *options.Timeout
+	if options.Username != "" {
+		cluster.Authenticator = gocql.PasswordAuthenticator{
+			Username: options.Username,
+			Password: options.Password,
+		}
+	}
+	// Don't set the keyspace in the cluster config, because it might not exist yet.
+	// All statements use the fully qualified table name instead.
+	return cluster.CreateSession()
+}
+
+// replication returns the replication map for creating the keyspace.
+func replication(options Options) string {
+	if len(options.DataCenterReplication) == 0 {
+		return "{'class': 'SimpleStrategy', 'replication_factor': " + strconv.Itoa(options.ReplicationFactor) + "}"
+	}
+	// Sort the data centers for a deterministic statement
+	dataCenters := make([]string, 0, len(options.DataCenterReplication))
+	for dataCenter := range options.DataCenterReplication {
+		dataCenters = append(dataCenters, dataCenter)
+	}
+	sort.Strings(dataCenters)
+	elems := []string{"'class': 'NetworkTopologyStrategy'"}
+	for _, dataCenter := range dataCenters {
+		elems = append(elems, "'"+dataCenter+"': "+strconv.Itoa(options.DataCenterReplication[dataCenter]))
+	}
+	return "{" + strings.Join(elems, ", ") + "}"
+}
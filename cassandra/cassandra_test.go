@@ -0,0 +1,345 @@
+package cassandra_test
+
+import (
+	"log"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+
+	"github.com/philippgille/gokv"
+	"github.com/philippgille/gokv/cassandra"
+	"github.com/philippgille/gokv/test"
+)
+
+// TestClient tests if reading from, writing to and deleting from the store works properly.
+// A struct is used as value. See TestTypes() for a test that is simpler but tests all types.
+//
+// Note: This test is only executed if the initial connection to Cassandra works.
+func TestClient(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Cassandra could be established. Probably not running in a proper test environment.")
+	}
+
+	// Test with JSON
+	t.Run("JSON", func(t *testing.T) {
+		client := createClient(t, cassandra.JSON)
+		test.TestStore(client, t)
+	})
+
+	// Test with gob
+	t.Run("gob", func(t *testing.T) {
+		client := createClient(t, cassandra.Gob)
+		test.TestStore(client, t)
+	})
+}
+
+// TestTypes tests if setting and getting values works with all Go types.
+//
+// Note: This test is only executed if the initial connection to Cassandra works.
+func TestTypes(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Cassandra could be established. Probably not running in a proper test environment.")
+	}
+
+	// Test with JSON
+	t.Run("JSON", func(t *testing.T) {
+		client := createClient(t, cassandra.JSON)
+		test.TestTypes(client, t)
+	})
+
+	// Test with gob
+	t.Run("gob", func(t *testing.T) {
+		client := createClient(t, cassandra.Gob)
+		test.TestTypes(client, t)
+	})
+}
+
+// TestClientConcurrent launches a bunch of goroutines that concurrently work with the Cassandra client.
+//
+// Note: This test is only executed if the initial connection to Cassandra works.
+func TestClientConcurrent(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Cassandra could be established. Probably not running in a proper test environment.")
+	}
+
+	client := createClient(t, cassandra.JSON)
+
+	goroutineCount := 1000
+
+	test.TestConcurrentInteractions(t, goroutineCount, client)
+}
+
+// TestSetIfNotExists tests if values are only stored when no value exists yet.
+//
+// Note: This test is only executed if the initial connection to Cassandra works.
+func TestSetIfNotExists(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Cassandra could be established. Probably not running in a proper test environment.")
+	}
+
+	client := createClient(t, cassandra.JSON)
+	k := "setifnotexists" + time.Now().Format(time.RFC3339Nano)
+
+	created, err := client.SetIfNotExists(k, test.Foo{Bar: "baz"})
+	if err != nil {
+		t.Error(err)
+	}
+	if !created {
+		t.Error("The value wasn't created, but should have been")
+	}
+	created, err = client.SetIfNotExists(k, test.Foo{Bar: "qux"})
+	if err != nil {
+		t.Error(err)
+	}
+	if created {
+		t.Error("The value was created, but shouldn't have been")
+	}
+
+	actualPtr := new(test.Foo)
+	found, err := client.Get(k, actualPtr)
+	if err != nil {
+		t.Error(err)
+	}
+	if !found {
+		t.Error("No value was found, but should have been")
+	}
+	if actualPtr.Bar != "baz" {
+		t.Errorf("Expected: %v, but was: %v", "baz", actualPtr.Bar)
+	}
+}
+
+// TestCompareAndSwap tests if values are only replaced when the stored value matches.
+//
+// Note: This test is only executed if the initial connection to Cassandra works.
+func TestCompareAndSwap(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Cassandra could be established. Probably not running in a proper test environment.")
+	}
+
+	client := createClient(t, cassandra.JSON)
+	k := "compareandswap" + time.Now().Format(time.RFC3339Nano)
+
+	// No value exists yet
+	swapped, err := client.CompareAndSwap(k, test.Foo{Bar: "baz"}, test.Foo{Bar: "qux"})
+	if err != nil {
+		t.Error(err)
+	}
+	if swapped {
+		t.Error("The value was swapped, but shouldn't have been")
+	}
+
+	_, err = client.SetIfNotExists(k, test.Foo{Bar: "baz"})
+	if err != nil {
+		t.Error(err)
+	}
+	swapped, err = client.CompareAndSwap(k, test.Foo{Bar: "qux"}, test.Foo{Bar: "quux"})
+	if err != nil {
+		t.Error(err)
+	}
+	if swapped {
+		t.Error("The value was swapped, but shouldn't have been")
+	}
+	swapped, err = client.CompareAndSwap(k, test.Foo{Bar: "baz"}, test.Foo{Bar: "qux"})
+	if err != nil {
+		t.Error(err)
+	}
+	if !swapped {
+		t.Error("The value wasn't swapped, but should have been")
+	}
+}
+
+// TestErrors tests some error cases.
+//
+// Note: This test is only executed if the initial connection to Cassandra works.
+func TestErrors(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Cassandra could be established. Probably not running in a proper test environment.")
+	}
+
+	// Test with a bad MarshalFormat enum value
+
+	client := createClient(t, cassandra.MarshalFormat(19))
+	err := client.Set("foo", "bar")
+	if err == nil {
+		t.Error("An error should have occurred, but didn't")
+	}
+
+	// Test empty key
+	err = client.Set("", "bar")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	_, err = client.Get("", new(string))
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	err = client.Delete("")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+// TestNil tests the behaviour when passing nil or pointers to nil values to some methods.
+//
+// Note: This test is only executed if the initial connection to Cassandra works.
+func TestNil(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Cassandra could be established. Probably not running in a proper test environment.")
+	}
+
+	// Test setting nil
+
+	t.Run("set nil with JSON marshalling", func(t *testing.T) {
+		client := createClient(t, cassandra.JSON)
+		err := client.Set("foo", nil)
+		if err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	t.Run("set nil with Gob marshalling", func(t *testing.T) {
+		client := createClient(t, cassandra.Gob)
+		err := client.Set("foo", nil)
+		if err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	// Test passing nil or pointer to nil value for retrieval
+
+	createTest := func(mf cassandra.MarshalFormat) func(t *testing.T) {
+		return func(t *testing.T) {
+			client := createClient(t, mf)
+
+			// Prep
+			err := client.Set("foo", test.Foo{Bar: "baz"})
+			if err != nil {
+				t.Error(err)
+			}
+
+			_, err = client.Get("foo", nil) // actually nil
+			if err == nil {
+				t.Error("An error was expected")
+			}
+
+			var i interface{} // actually nil
+			_, err = client.Get("foo", i)
+			if err == nil {
+				t.Error("An error was expected")
+			}
+
+			var valPtr *test.Foo // nil value
+			_, err = client.Get("foo", valPtr)
+			if err == nil {
+				t.Error("An error was expected")
+			}
+		}
+	}
+	t.Run("get with nil / nil value parameter", createTest(cassandra.JSON))
+	t.Run("get with nil / nil value parameter", createTest(cassandra.Gob))
+}
+
+// TestClose tests if the close method returns any errors.
+//
+// Note: This test is only executed if the initial connection to Cassandra works.
+func TestClose(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Cassandra could be established. Probably not running in a proper test environment.")
+	}
+
+	client := createClient(t, cassandra.JSON)
+	err := client.Close()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// checkConnection returns true if a connection could be made, false otherwise.
+// TestProvisioning tests if NewClient() returns a *gokv.ResourceNotFoundError when automatic provisioning is disabled
+// and if Provision() and Drop() create and delete the table.
+//
+// Note: This test is only executed if the initial connection to Cassandra works.
+func TestProvisioning(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Cassandra could be established. Probably not running in a proper test environment.")
+	}
+
+	// Non-existing keyspace
+	options := cassandra.Options{
+		Keyspace:                "gokv_nonexistent",
+		DisableAutoProvisioning: true,
+	}
+	_, err := cassandra.NewClient(options)
+	if notFoundErr, ok := err.(*gokv.ResourceNotFoundError); !ok {
+		t.Errorf("Expected a *gokv.ResourceNotFoundError, but was: %v", err)
+	} else if notFoundErr.ResourceType != "keyspace" {
+		t.Errorf("Expected %v, but was %v", "keyspace", notFoundErr.ResourceType)
+	}
+	// Dropping a table in a non-existing keyspace must work
+	err = cassandra.Drop(options)
+	if err != nil {
+		t.Error(err)
+	}
+
+	// Non-existing table
+	options = cassandra.Options{
+		TableName:               "ItemProvisioning",
+		DisableAutoProvisioning: true,
+	}
+	err = cassandra.Drop(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = cassandra.NewClient(options)
+	if notFoundErr, ok := err.(*gokv.ResourceNotFoundError); !ok {
+		t.Errorf("Expected a *gokv.ResourceNotFoundError, but was: %v", err)
+	} else if notFoundErr.ResourceType != "table" {
+		t.Errorf("Expected %v, but was %v", "table", notFoundErr.ResourceType)
+	}
+
+	// Provisioned table
+	err = cassandra.Provision(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := cassandra.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.TestStore(client, t)
+	client.Close()
+
+	// Dropped table
+	err = cassandra.Drop(options)
+	if err != nil {
+		t.Error(err)
+	}
+	_, err = cassandra.NewClient(options)
+	if _, ok := err.(*gokv.ResourceNotFoundError); !ok {
+		t.Errorf("Expected a *gokv.ResourceNotFoundError, but was: %v", err)
+	}
+}
+
+func checkConnection() bool {
+	cluster := gocql.NewCluster("127.0.0.1")
+	cluster.ConnectTimeout = 2 * time.Second
+	session, err := cluster.CreateSession()
+	if err != nil {
+		log.Printf("An error occurred during testing the connection to the server: %v\n", err)
+		return false
+	}
+	session.Close()
+	return true
+}
+
+func createClient(t *testing.T, mf cassandra.MarshalFormat) cassandra.Client {
+	options := cassandra.Options{
+		MarshalFormat: mf,
+	}
+	client, err := cassandra.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
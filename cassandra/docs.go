@@ -0,0 +1,10 @@
+/*
+Package cassandra contains an implementation of the `gokv.Store` interface for Apache Cassandra and ScyllaDB.
+
+The consistency levels for reads and writes are configurable.
+Create-only writes (SetIfNotExists) and CompareAndSwap use lightweight transactions,
+which are much slower than regular writes because they require a consensus between the replicas.
+Cassandra doesn't guarantee correct results when lightweight transactions and regular writes are mixed for the same key,
+so for keys that are modified with lightweight transactions you shouldn't use Set.
+*/
+package cassandra
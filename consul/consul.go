@@ -9,8 +9,10 @@ import (
 )
 
 // Client is a gokv.Store implementation for Consul.
+// It also implements gokv.Locker.
 type Client struct {
 	c             *api.KV
+	client        *api.Client
 	folder        string
 	marshalFormat MarshalFormat
 }
@@ -130,17 +132,42 @@ type Options struct {
 	// The Consul UI calls this "folder".
 	// Optional (none by default).
 	Folder string
+	// ACL token for the requests.
+	// Optional ("" by default, which means that the agent's default token is used).
+	Token string
+	// Datacenter in which the key-value pairs are stored.
+	// Optional ("" by default, which means the datacenter of the agent).
+	Datacenter string
+	// Namespace in which the key-value pairs are stored.
+	// Namespaces are only supported by Consul Enterprise.
+	// Optional ("" by default, which means the namespace that's inferred from the token or "default").
+	Namespace string
+	// Admin partition in which the key-value pairs are stored.
+	// Admin partitions are only supported by Consul Enterprise.
+	// Optional ("" by default, which means the partition that's inferred from the token or "default").
+	Partition string
+	// Path to a PEM encoded file with the CA certificate(s) to verify the server certificate with.
+	// If any of the TLS files is set and Scheme isn't set, "https" is used as scheme.
+	// Optional ("" by default, which means that the system's CA certificates are used).
+	CAFile string
+	// Path to a PEM encoded file with the client certificate for TLS client authentication.
+	// Optional ("" by default).
+	CertFile string
+	// Path to a PEM encoded file with the private key of the client certificate.
+	// Required if CertFile is set.
+	KeyFile string
 	// (Un-)marshal format.
 	// Optional (JSON by default).
 	MarshalFormat MarshalFormat
 }
 
 // DefaultOptions is an Options object with default values.
-// Scheme: "http", Address: "127.0.0.1:8500", Folder: none, MarshalFormat: JSON
+// Scheme: "http" ("https" if TLS files are set), Address: "127.0.0.1:8500", Folder: none,
+// Token: "", Datacenter: "", Namespace: "", Partition: "", CAFile: "", CertFile: "", KeyFile: "", MarshalFormat: JSON
 var DefaultOptions = Options{
 	Scheme:  "http",
 	Address: "127.0.0.1:8500",
-	// No need to define Folder because its zero value is fine
+	// No need to define Folder, Token, Datacenter, Namespace, Partition or the TLS files because their zero values are fine.
 	// No need to set MarshalFormat to JSON because its zero value is fine.
 }
 
@@ -149,8 +176,13 @@ func NewClient(options Options) (Client, error) {
 	result := Client{}
 
 	// Set default values
+	useTLS := options.CAFile != "" || options.CertFile != "" || options.KeyFile != ""
 	if options.Scheme == "" {
-		options.Scheme = DefaultOptions.Scheme
+		if useTLS {
+			options.Scheme = "https"
+		} else {
+			options.Scheme = DefaultOptions.Scheme
+		}
 	}
 	if options.Address == "" {
 		options.Address = DefaultOptions.Address
@@ -159,6 +191,29 @@ func NewClient(options Options) (Client, error) {
 	config := api.DefaultConfig()
 	config.Scheme = options.Scheme
 	config.Address = options.Address
+	// Only overwrite the values that DefaultConfig() reads from the environment (like CONSUL_HTTP_TOKEN) if they're set.
+	if options.Token != "" {
+		config.Token = options.Token
+	}
+	if options.Datacenter != "" {
+		config.Datacenter = options.Datacenter
+	}
+	if options.Namespace != "" {
+		config.Namespace = options.Namespace
+	}
+	if options.Partition != "" {
+		config.Partition = options.Partition
+	}
+	// The Consul client reads the TLS files when it's created
+	if options.CAFile != "" {
+		config.TLSConfig.CAFile = options.CAFile
+	}
+	if options.CertFile != "" {
+		config.TLSConfig.CertFile = options.CertFile
+	}
+	if options.KeyFile != "" {
+		config.TLSConfig.KeyFile = options.KeyFile
+	}
 	client, err := api.NewClient(config)
 	if err != nil {
 		return result, err
@@ -166,6 +221,7 @@ func NewClient(options Options) (Client, error) {
 
 	result = Client{
 		c:             client.KV(),
+		client:        client,
 		folder:        options.Folder,
 		marshalFormat: options.MarshalFormat,
 	}
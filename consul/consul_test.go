@@ -1,7 +1,10 @@
 package consul_test
 
 import (
+	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
 	"time"
@@ -70,6 +73,18 @@ func TestClientConcurrent(t *testing.T) {
 	test.TestConcurrentInteractions(t, goroutineCount, client)
 }
 
+// TestLock tests if the locks provide mutual exclusion.
+//
+// Note: This test is only executed if the initial connection to Consul works.
+func TestLock(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Consul could be established. Probably not running in a proper test environment.")
+	}
+
+	client := createClient(t, consul.JSON)
+	test.TestLocker(client, t)
+}
+
 // TestErrors tests some error cases.
 //
 // Note: This test is only executed if the initial connection to Consul works.
@@ -182,6 +197,163 @@ func TestClose(t *testing.T) {
 	}
 }
 
+// TestDatacenter tests if the configured datacenter is used.
+//
+// Note: This test is only executed if the initial connection to Consul works.
+func TestDatacenter(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Consul could be established. Probably not running in a proper test environment.")
+	}
+
+	// "dc1" is the default datacenter of a Consul agent
+	options := consul.Options{
+		Folder:     "test_" + strconv.FormatInt(time.Now().Unix(), 10),
+		Datacenter: "dc1",
+	}
+	client, err := consul.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.TestStore(client, t)
+
+	options.Datacenter = "non-existing"
+	client, err = consul.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Set("foo", "bar")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+// TestToken tests if the configured ACL token is used.
+// The Consul agent must have ACLs enabled, with a token that can read and write the "test_" key prefix
+// in the environment variable "CONSUL_TEST_TOKEN".
+//
+// Note: This test is only executed if the environment variable is set.
+func TestToken(t *testing.T) {
+	token := os.Getenv("CONSUL_TEST_TOKEN")
+	if token == "" {
+		t.Skip("No ACL token is configured. Probably not running in a proper test environment.")
+	}
+
+	options := consul.Options{
+		Folder: "test_" + strconv.FormatInt(time.Now().Unix(), 10),
+		Token:  token,
+	}
+	client, err := consul.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.TestStore(client, t)
+
+	options.Token = "00000000-0000-0000-0000-000000000000"
+	client, err = consul.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Set("foo", "bar")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+// TestNamespaceAndPartition tests if the configured namespace and admin partition are used.
+// Both are only supported by Consul Enterprise, so the namespace and partition must exist
+// and be configured in the environment variables "CONSUL_TEST_NAMESPACE" and "CONSUL_TEST_PARTITION".
+//
+// Note: This test is only executed if the environment variables are set.
+func TestNamespaceAndPartition(t *testing.T) {
+	namespace := os.Getenv("CONSUL_TEST_NAMESPACE")
+	partition := os.Getenv("CONSUL_TEST_PARTITION")
+	if namespace == "" || partition == "" {
+		t.Skip("No namespace and partition are configured. Probably not running in a proper test environment.")
+	}
+
+	options := consul.Options{
+		Folder:    "test_" + strconv.FormatInt(time.Now().Unix(), 10),
+		Namespace: namespace,
+		Partition: partition,
+	}
+	client, err := consul.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.TestStore(client, t)
+
+	// The key-value pairs must not be visible in the default namespace
+	err = client.Set("foo", "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Delete("foo")
+	options.Namespace = ""
+	options.Partition = ""
+	defaultClient, err := consul.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := defaultClient.Get("foo", new(string))
+	if err != nil {
+		t.Error(err)
+	}
+	if found {
+		t.Error("A value was found, but no value was expected")
+	}
+}
+
+// TestTLS tests if the client can connect to a Consul agent that requires TLS client certificates.
+// The agent and the files must be configured with the environment variables
+// "CONSUL_TLS_ADDRESS", "CONSUL_CA_FILE", "CONSUL_CERT_FILE" and "CONSUL_KEY_FILE".
+//
+// Note: This test is only executed if the environment variables are set.
+func TestTLS(t *testing.T) {
+	address := os.Getenv("CONSUL_TLS_ADDRESS")
+	if address == "" {
+		t.Skip("No Consul agent with TLS is configured. Probably not running in a proper test environment.")
+	}
+
+	options := consul.Options{
+		Address:  address,
+		Folder:   "test_" + strconv.FormatInt(time.Now().Unix(), 10),
+		CAFile:   os.Getenv("CONSUL_CA_FILE"),
+		CertFile: os.Getenv("CONSUL_CERT_FILE"),
+		KeyFile:  os.Getenv("CONSUL_KEY_FILE"),
+	}
+	client, err := consul.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.TestStore(client, t)
+}
+
+// TestTLSFiles tests if invalid TLS files lead to an error.
+// The files are read when the client is created, so this test doesn't require a running Consul agent.
+func TestTLSFiles(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gokv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	invalidFile := filepath.Join(tmpDir, "invalid.pem")
+	err = ioutil.WriteFile(invalidFile, []byte("foo"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	badOptions := []consul.Options{
+		{CAFile: filepath.Join(tmpDir, "non-existing.pem")},
+		{CertFile: invalidFile, KeyFile: invalidFile},
+	}
+	for _, options := range badOptions {
+		_, err := consul.NewClient(options)
+		if err == nil {
+			t.Errorf("Expected an error for options %+v", options)
+		}
+	}
+}
+
 // checkConnection returns true if a connection could be made, false otherwise.
 func checkConnection() bool {
 	client, err := api.NewClient(api.DefaultConfig())
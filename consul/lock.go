@@ -0,0 +1,106 @@
+package consul
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+
+	"github.com/philippgille/gokv"
+)
+
+// lockPrefix is the prefix of the keys in which locks are stored,
+// so that lock names don't collide with the keys of key-value pairs.
+const lockPrefix = "gokv-lock/"
+
+// Lock acquires the lock with the given name.
+// It uses a Consul session with a TTL of gokv.DefaultLockTTL, which is renewed in the background while the lock is held.
+// The lock is stored in the key "gokv-lock/" + name in the configured folder.
+// It blocks until the lock is acquired or the context is done.
+func (c Client) Lock(ctx context.Context, name string) (gokv.Lock, error) {
+	key := lockPrefix + name
+	if c.folder != "" {
+		key = c.folder + "/" + key
+	}
+	lock, err := c.client.LockOpts(&api.LockOptions{
+		Key:         key,
+		SessionName: "gokv",
+		SessionTTL:  gokv.DefaultLockTTL.String(),
+		// Consul's default of 15 seconds is way too long for a context that might have a short timeout
+		LockWaitTime: time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The lock can only be aborted with a channel
+	stopChan := make(chan struct{})
+	acquired := make(chan struct{})
+	defer close(acquired)
+	go func() {
+		select {
+		case <-ctx.Done():
+			close(stopChan)
+		case <-acquired:
+		}
+	}()
+	lostChan, err := lock.Lock(stopChan)
+	if err != nil {
+		return nil, err
+	}
+	// A nil channel without error means that the lock attempt was aborted
+	if lostChan == nil {
+		return nil, ctx.Err()
+	}
+
+	l := &consulLock{
+		l:        lock,
+		lost:     make(chan struct{}),
+		unlocked: make(chan struct{}),
+	}
+	go l.monitor(lostChan)
+	return l, nil
+}
+
+// consulLock is a gokv.Lock that's held with a Consul session.
+type consulLock struct {
+	l          *api.Lock
+	lost       chan struct{}
+	unlocked   chan struct{}
+	unlockOnce sync.Once
+}
+
+// monitor closes the lost channel when Consul reports that the lock was lost.
+// Consul also closes its channel on Unlock, which doesn't count as lost.
+func (l *consulLock) monitor(lostChan <-chan struct{}) {
+	select {
+	case <-lostChan:
+		select {
+		case <-l.unlocked:
+		default:
+			close(l.lost)
+		}
+	case <-l.unlocked:
+	}
+}
+
+// Unlock releases the lock and destroys the session.
+// Calling it more than once has no effect.
+func (l *consulLock) Unlock() error {
+	var err error
+	l.unlockOnce.Do(func() {
+		close(l.unlocked)
+		err = l.l.Unlock()
+		// The lock might have been lost before
+		if err == api.ErrLockNotHeld {
+			err = nil
+		}
+	})
+	return err
+}
+
+// Lost returns a channel that is closed when the lock was lost.
+func (l *consulLock) Lost() <-chan struct{} {
+	return l.lost
+}
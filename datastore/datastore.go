@@ -0,0 +1,217 @@
+package datastore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+
+	"github.com/philippgille/gokv/util"
+)
+
+var defaultTimeout = 2 * time.Second
+
+// entity is the struct that's stored in Datastore.
+// The value isn't indexed, because it's only ever retrieved via the key.
+// Unindexed properties can be up to 1 MB large, indexed ones only 1500 bytes.
+type entity struct {
+	V []byte `datastore:"v,noindex"`
+}
+
+// Client is a gokv.Store implementation for Cloud Datastore.
+// It also implements gokv.Lister.
+type Client struct {
+	c             *datastore.Client
+	kind          string
+	namespace     string
+	timeOut       time.Duration
+	marshalFormat MarshalFormat
+}
+
+// Set stores the given value for the given key.
+// Values are automatically marshalled to JSON or gob (depending on the configuration).
+// The key must not be "" and the value must not be nil.
+func (c Client) Set(k string, v interface{}) error {
+	if err := util.CheckKeyAndValue(k, v); err != nil {
+		return err
+	}
+
+	// First turn the passed object into something that Datastore can handle
+	var data []byte
+	var err error
+	switch c.marshalFormat {
+	case JSON:
+		data, err = util.ToJSON(v)
+	case Gob:
+		data, err = util.ToGob(v)
+	default:
+		err = errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	}
+	if err != nil {
+		return err
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), c.timeOut)
+	defer cancel()
+	_, err = c.c.Put(ctxWithTimeout, c.key(k), &entity{V: data})
+	return err
+}
+
+// Get retrieves the stored value for the given key.
+// You need to pass a pointer to the value, so in case of a struct
+// the automatic unmarshalling can populate the fields of the object
+// that v points to with the values of the retrieved object's values.
+// If no value is found it returns (false, nil).
+// The key must not be "" and the pointer must not be nil.
+func (c Client) Get(k string, v interface{}) (found bool, err error) {
+	if err := util.CheckKeyAndValue(k, v); err != nil {
+		return false, err
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), c.timeOut)
+	defer cancel()
+	e := entity{}
+	err = c.c.Get(ctxWithTimeout, c.key(k), &e)
+	// If no value was found return false
+	if err == datastore.ErrNoSuchEntity {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	switch c.marshalFormat {
+	case JSON:
+		return true, util.FromJSON(e.V, v)
+	case Gob:
+		return true, util.FromGob(e.V, v)
+	default:
+		return true, errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	}
+}
+
+// Delete deletes the stored value for the given key.
+// Deleting a non-existing key-value pair does NOT lead to an error.
+// The key must not be "".
+func (c Client) Delete(k string) error {
+	if err := util.CheckKey(k); err != nil {
+		return err
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), c.timeOut)
+	defer cancel()
+	return c.c.Delete(ctxWithTimeout, c.key(k))
+}
+
+// List calls fn for every key of the configured kind and namespace.
+// The keys are retrieved with a keys-only query in batches, so there can be more keys than fit into memory.
+// Queries are eventually consistent, so key-value pairs that were stored or deleted just before the call
+// may or may not be included.
+// If fn returns an error, the iteration is stopped and the error is returned.
+// The configured timeout doesn't apply to the whole iteration, which can take arbitrarily long.
+func (c Client) List(fn func(k string) error) error {
+	query := datastore.NewQuery(c.kind).Namespace(c.namespace).KeysOnly()
+	it := c.c.Run(context.Background(), query)
+	for {
+		key, err := it.Next(nil)
+		if err == iterator.Done {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := fn(key.Name); err != nil {
+			return err
+		}
+	}
+}
+
+// Close closes the client.
+// It must be called to release any open resources.
+func (c Client) Close() error {
+	return c.c.Close()
+}
+
+// key returns the Datastore key for the given key.
+func (c Client) key(k string) *datastore.Key {
+	key := datastore.NameKey(c.kind, k, nil)
+	key.Namespace = c.namespace
+	return key
+}
+
+// MarshalFormat is an enum for the available (un-)marshal formats of this gokv.Store implementation.
+type MarshalFormat int
+
+const (
+	// JSON is the MarshalFormat for (un-)marshalling to/from JSON
+	JSON MarshalFormat = iota
+	// Gob is the MarshalFormat for (un-)marshalling to/from gob
+	Gob
+)
+
+// Options are the options for the Cloud Datastore client.
+type Options struct {
+	// ID of the Google Cloud project.
+	// Optional (read from the environment variable "DATASTORE_PROJECT_ID" if not set).
+	ProjectID string
+	// Kind of the entities in which the key-value pairs are stored.
+	// Optional ("gokv" by default).
+	Kind string
+	// Namespace of the entities, which allows multiple stores with the same kind to be separated.
+	// Optional ("" by default, which is the default namespace).
+	Namespace string
+	// Path to the JSON file with the credentials of a service account.
+	// Optional (Application Default Credentials are used if not set,
+	// see https://cloud.google.com/docs/authentication/production).
+	CredentialsFile string
+	// The timeout for operations.
+	// Optional (2 * time.Second by default).
+	Timeout *time.Duration
+	// (Un-)marshal format.
+	// Optional (JSON by default).
+	MarshalFormat MarshalFormat
+}
+
+// DefaultOptions is an Options object with default values.
+// ProjectID: "" (read from environment variable), Kind: "gokv", Namespace: "",
+// CredentialsFile: "" (use Application Default Credentials), Timeout: 2 * time.Second, MarshalFormat: JSON
+var DefaultOptions = Options{
+	Kind:    "gokv",
+	Timeout: &defaultTimeout,
+	// No need to set ProjectID, Namespace, CredentialsFile or MarshalFormat because their Go zero values are fine.
+}
+
+// NewClient creates a new Cloud Datastore client.
+//
+// If the environment variable DATASTORE_EMULATOR_HOST is set,
+// the client connects to the Datastore emulator on that host instead of Google Cloud.
+func NewClient(options Options) (Client, error) {
+	result := Client{}
+
+	// Set default values
+	if options.Kind == "" {
+		options.Kind = DefaultOptions.Kind
+	}
+	if options.Timeout == nil {
+		options.Timeout = DefaultOptions.Timeout
+	}
+
+	var clientOptions []option.ClientOption
+	if options.CredentialsFile != "" {
+		clientOptions = append(clientOptions, option.WithCredentialsFile(options.CredentialsFile))
+	}
+	// The client library reads DATASTORE_PROJECT_ID and DATASTORE_EMULATOR_HOST itself.
+	client, err := datastore.NewClient(context.Background(), options.ProjectID, clientOptions...)
+	if err != nil {
+		return result, err
+	}
+
+	result.c = client
+	result.kind = options.Kind
+	result.namespace = options.Namespace
+	result.timeOut = *options.Timeout
+	result.marshalFormat = options.MarshalFormat
+
+	return result, nil
+}
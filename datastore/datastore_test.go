@@ -0,0 +1,265 @@
+package datastore_test
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/philippgille/gokv/datastore"
+	"github.com/philippgille/gokv/test"
+)
+
+// For the Datastore emulator, started with:
+// gcloud beta emulators datastore start --project=gokv --host-port=localhost:8081
+// See https://cloud.google.com/datastore/docs/tools/datastore-emulator.
+const (
+	defaultEmulatorHost = "localhost:8081"
+	projectID           = "gokv"
+)
+
+// TestClient tests if reading from, writing to and deleting from the store works properly.
+// A struct is used as value. See TestTypes() for a test that is simpler but tests all types.
+//
+// Note: This test is only executed if the initial connection to Datastore works.
+func TestClient(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Datastore could be established. Probably not running in a proper test environment.")
+	}
+
+	// Test with JSON
+	t.Run("JSON", func(t *testing.T) {
+		client := createClient(t, datastore.JSON)
+		test.TestStore(client, t)
+	})
+
+	// Test with gob
+	t.Run("gob", func(t *testing.T) {
+		client := createClient(t, datastore.Gob)
+		test.TestStore(client, t)
+	})
+}
+
+// TestTypes tests if setting and getting values works with all Go types.
+//
+// Note: This test is only executed if the initial connection to Datastore works.
+func TestTypes(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Datastore could be established. Probably not running in a proper test environment.")
+	}
+
+	// Test with JSON
+	t.Run("JSON", func(t *testing.T) {
+		client := createClient(t, datastore.JSON)
+		test.TestTypes(client, t)
+	})
+
+	// Test with gob
+	t.Run("gob", func(t *testing.T) {
+		client := createClient(t, datastore.Gob)
+		test.TestTypes(client, t)
+	})
+}
+
+// TestClientConcurrent launches a bunch of goroutines that concurrently work with the Datastore client.
+//
+// Note: This test is only executed if the initial connection to Datastore works.
+func TestClientConcurrent(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Datastore could be established. Probably not running in a proper test environment.")
+	}
+
+	client := createClient(t, datastore.JSON)
+
+	goroutineCount := 1000
+
+	test.TestConcurrentInteractions(t, goroutineCount, client)
+}
+
+// TestNamespace tests if the same key can be used in different namespaces without conflicts.
+//
+// Note: This test is only executed if the initial connection to Datastore works.
+func TestNamespace(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Datastore could be established. Probably not running in a proper test environment.")
+	}
+
+	client1 := createClientWithNamespace(t, datastore.JSON, "ns1")
+	client2 := createClientWithNamespace(t, datastore.JSON, "ns2")
+
+	err := client1.Set("foo", "bar")
+	if err != nil {
+		t.Error(err)
+	}
+	err = client2.Delete("foo")
+	if err != nil {
+		t.Error(err)
+	}
+	found, err := client1.Get("foo", new(string))
+	if err != nil {
+		t.Error(err)
+	}
+	if !found {
+		t.Error("No value was found, but should have been")
+	}
+}
+
+// TestList tests if the keys of the namespace are listed.
+//
+// Note: This test is only executed if the initial connection to Datastore works.
+func TestList(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Datastore could be established. Probably not running in a proper test environment.")
+	}
+
+	// Use a unique namespace, because other tests use the same kind
+	client := createClientWithNamespace(t, datastore.JSON, "list"+strconv.FormatInt(time.Now().UnixNano(), 10))
+	test.TestList(client, t)
+}
+
+// TestErrors tests some error cases.
+//
+// Note: This test is only executed if the initial connection to Datastore works.
+func TestErrors(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Datastore could be established. Probably not running in a proper test environment.")
+	}
+
+	// Test with a bad MarshalFormat enum value
+
+	client := createClient(t, datastore.MarshalFormat(19))
+	err := client.Set("foo", "bar")
+	if err == nil {
+		t.Error("An error should have occurred, but didn't")
+	}
+
+	// Test empty key
+	err = client.Set("", "bar")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	_, err = client.Get("", new(string))
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	err = client.Delete("")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+// TestNil tests the behaviour when passing nil or pointers to nil values to some methods.
+//
+// Note: This test is only executed if the initial connection to Datastore works.
+func TestNil(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Datastore could be established. Probably not running in a proper test environment.")
+	}
+
+	// Test setting nil
+
+	t.Run("set nil with JSON marshalling", func(t *testing.T) {
+		client := createClient(t, datastore.JSON)
+		err := client.Set("foo", nil)
+		if err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	t.Run("set nil with Gob marshalling", func(t *testing.T) {
+		client := createClient(t, datastore.Gob)
+		err := client.Set("foo", nil)
+		if err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	// Test passing nil or pointer to nil value for retrieval
+
+	createTest := func(mf datastore.MarshalFormat) func(t *testing.T) {
+		return func(t *testing.T) {
+			client := createClient(t, mf)
+
+			// Prep
+			err := client.Set("foo", test.Foo{Bar: "baz"})
+			if err != nil {
+				t.Error(err)
+			}
+
+			_, err = client.Get("foo", nil) // actually nil
+			if err == nil {
+				t.Error("An error was expected")
+			}
+
+			var i interface{} // actually nil
+			_, err = client.Get("foo", i)
+			if err == nil {
+				t.Error("An error was expected")
+			}
+
+			var valPtr *test.Foo // nil value
+			_, err = client.Get("foo", valPtr)
+			if err == nil {
+				t.Error("An error was expected")
+			}
+		}
+	}
+	t.Run("get with nil / nil value parameter", createTest(datastore.JSON))
+	t.Run("get with nil / nil value parameter", createTest(datastore.Gob))
+}
+
+// TestClose tests if the close method returns any errors.
+//
+// Note: This test is only executed if the initial connection to Datastore works.
+func TestClose(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Datastore could be established. Probably not running in a proper test environment.")
+	}
+
+	client := createClient(t, datastore.JSON)
+	err := client.Close()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// checkConnection returns true if a connection could be made, false otherwise.
+// It also sets the environment variables for the emulator if they aren't set yet.
+func checkConnection() bool {
+	if os.Getenv("DATASTORE_EMULATOR_HOST") == "" {
+		os.Setenv("DATASTORE_EMULATOR_HOST", defaultEmulatorHost)
+	}
+	if os.Getenv("DATASTORE_PROJECT_ID") == "" {
+		os.Setenv("DATASTORE_PROJECT_ID", projectID)
+	}
+
+	// The emulator responds with "Ok" on its root path
+	httpClient := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	res, err := httpClient.Get("http://" + os.Getenv("DATASTORE_EMULATOR_HOST"))
+	if err != nil {
+		log.Printf("An error occurred during testing the connection to the server: %v\n", err)
+		return false
+	}
+	res.Body.Close()
+	return true
+}
+
+func createClient(t *testing.T, mf datastore.MarshalFormat) datastore.Client {
+	return createClientWithNamespace(t, mf, "")
+}
+
+func createClientWithNamespace(t *testing.T, mf datastore.MarshalFormat, namespace string) datastore.Client {
+	options := datastore.Options{
+		Namespace:     namespace,
+		MarshalFormat: mf,
+	}
+	client, err := datastore.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
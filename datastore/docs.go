@@ -0,0 +1,9 @@
+/*
+Package datastore contains an implementation of the `gokv.Store` interface for Google Cloud Datastore and Firestore in Datastore mode.
+
+Each key-value pair is stored as an entity with the key as name and the value as unindexed blob property.
+
+To use the Datastore emulator, set the environment variable DATASTORE_EMULATOR_HOST, for example to "localhost:8081".
+The client library then connects to the emulator instead of Google Cloud and doesn't require credentials.
+*/
+package datastore
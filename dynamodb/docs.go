@@ -1,4 +1,14 @@
 /*
 Package dynamodb contains an implementation of the `gokv.Store` interface for Amazon DynamoDB.
+
+By default every value is marshalled and stored in the binary attribute "v" of an item,
+with the key in the string attribute "k".
+With the NativeAttributes option, structs are stored as native DynamoDB attributes instead,
+which makes their fields visible in the DynamoDB console, in streams and in queries.
+The conversion is done by the AWS SDK's dynamodbattribute package, so for example numbers are stored as "N",
+strings as "S", slices as "L" and nested structs and maps as "M" attributes.
+An attribute has the name of the struct field, which can be changed with a `dynamodbav:"name"` tag.
+Fields with a `dynamodbav:"-"` tag are skipped.
+The names "k" and "v" are reserved, so storing a struct with a field that uses one of them leads to an error.
 */
 package dynamodb
@@ -3,6 +3,7 @@ package dynamodb
 import (
 	"context"
 	"errors"
+	"reflect"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -11,6 +12,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
 
+	"github.com/philippgille/gokv"
 	"github.com/philippgille/gokv/util"
 )
 
@@ -21,20 +23,33 @@ var keyAttrName = "k"
 var valAttrName = "v"
 
 // Client is a gokv.Store implementation for DynamoDB.
+// It also implements gokv.Locker.
 type Client struct {
-	c             *awsdynamodb.DynamoDB
-	tableName     string
-	marshalFormat MarshalFormat
+	c                       *awsdynamodb.DynamoDB
+	tableName               string
+	consistentRead          bool
+	nativeAttributes        bool
+	consumedCapacityHandler func(ConsumedCapacity)
+	marshalFormat           MarshalFormat
 }
 
 // Set stores the given value for the given key.
 // Values are automatically marshalled to JSON or gob (depending on the configuration).
+// With the NativeAttributes option, structs are stored as native attributes instead (see the package documentation).
 // The key must not be "" and the value must not be nil.
 func (c Client) Set(k string, v interface{}) error {
 	if err := util.CheckKeyAndValue(k, v); err != nil {
 		return err
 	}
 
+	if c.nativeAttributes && isNativeStorable(reflect.TypeOf(v)) {
+		item, err := toNativeItem(k, v)
+		if err != nil {
+			return err
+		}
+		return c.putItem(item)
+	}
+
 	// First turn the passed object into something that DynamoDB can handle.
 	var data []byte
 	var err error
@@ -57,14 +72,21 @@ func (c Client) Set(k string, v interface{}) error {
 	item[valAttrName] = &awsdynamodb.AttributeValue{
 		B: data,
 	}
+	return c.putItem(item)
+}
+
+// putItem stores the given item, replacing all attributes of a previously stored item with the same key.
+func (c Client) putItem(item map[string]*awsdynamodb.AttributeValue) error {
 	putItemInput := awsdynamodb.PutItemInput{
-		TableName: &c.tableName,
-		Item:      item,
+		TableName:              &c.tableName,
+		Item:                   item,
+		ReturnConsumedCapacity: c.returnConsumedCapacity(),
 	}
-	_, err = c.c.PutItem(&putItemInput)
+	putItemOutput, err := c.c.PutItem(&putItemInput)
 	if err != nil {
 		return err
 	}
+	c.reportConsumedCapacity("Set", putItemOutput.ConsumedCapacity)
 	return nil
 }
 
@@ -72,6 +94,7 @@ func (c Client) Set(k string, v interface{}) error {
 // You need to pass a pointer to the value, so in case of a struct
 // the automatic unmarshalling can populate the fields of the object
 // that v points to with the values of the retrieved object's values.
+// With the NativeAttributes option, values that are stored as native attributes can be retrieved as well.
 // If no value is found it returns (false, nil).
 // The key must not be "" and the pointer must not be nil.
 func (c Client) Get(k string, v interface{}) (found bool, err error) {
@@ -84,18 +107,25 @@ func (c Client) Get(k string, v interface{}) (found bool, err error) {
 		S: &k,
 	}
 	getItemInput := awsdynamodb.GetItemInput{
-		TableName: &c.tableName,
-		Key:       key,
+		TableName:              &c.tableName,
+		Key:                    key,
+		ConsistentRead:         &c.consistentRead,
+		ReturnConsumedCapacity: c.returnConsumedCapacity(),
 	}
 	getItemOutput, err := c.c.GetItem(&getItemInput)
 	if err != nil {
 		return false, err
-	} else if getItemOutput.Item == nil {
+	}
+	c.reportConsumedCapacity("Get", getItemOutput.ConsumedCapacity)
+	if getItemOutput.Item == nil {
 		// Return false if the key-value pair doesn't exist
 		return false, nil
 	}
 	attributeVal := getItemOutput.Item[valAttrName]
-	if attributeVal == nil {
+	if attributeVal == nil && c.nativeAttributes {
+		// The value was stored as native attributes.
+		return true, fromNativeItem(getItemOutput.Item, v)
+	} else if attributeVal == nil {
 		// Return false if there's no value
 		// TODO: Maybe return an error? Behaviour should be consistent across all implementations.
 		return false, nil
@@ -125,11 +155,16 @@ func (c Client) Delete(k string) error {
 		S: &k,
 	}
 	deleteItemInput := awsdynamodb.DeleteItemInput{
-		TableName: &c.tableName,
-		Key:       key,
+		TableName:              &c.tableName,
+		Key:                    key,
+		ReturnConsumedCapacity: c.returnConsumedCapacity(),
 	}
-	_, err := c.c.DeleteItem(&deleteItemInput)
-	return err
+	deleteItemOutput, err := c.c.DeleteItem(&deleteItemInput)
+	if err != nil {
+		return err
+	}
+	c.reportConsumedCapacity("Delete", deleteItemOutput.ConsumedCapacity)
+	return nil
 }
 
 // Close closes the client.
@@ -138,6 +173,49 @@ func (c Client) Close() error {
 	return nil
 }
 
+// returnConsumedCapacity returns the value for the ReturnConsumedCapacity field of requests.
+// The consumed capacity is only requested if there's a handler for it.
+func (c Client) returnConsumedCapacity() *string {
+	if c.consumedCapacityHandler == nil {
+		return nil
+	}
+	return aws.String(awsdynamodb.ReturnConsumedCapacityTotal)
+}
+
+// reportConsumedCapacity passes the consumed capacity of an operation to the configured handler.
+func (c Client) reportConsumedCapacity(operation string, consumedCapacity *awsdynamodb.ConsumedCapacity) {
+	if c.consumedCapacityHandler == nil || consumedCapacity == nil {
+		return
+	}
+	c.consumedCapacityHandler(ConsumedCapacity{
+		Operation:     operation,
+		TableName:     aws.StringValue(consumedCapacity.TableName),
+		CapacityUnits: aws.Float64Value(consumedCapacity.CapacityUnits),
+	})
+}
+
+// ConsumedCapacity is the capacity that was consumed by a single operation of the client.
+type ConsumedCapacity struct {
+	// Name of the client's method that led to the operation:
+	// "Set", "Get" or "Delete", or "Lock", "RefreshLock" or "Unlock" for the operations of locks.
+	Operation string
+	// Name of the table.
+	TableName string
+	// Read or write capacity units that were consumed, depending on the operation.
+	CapacityUnits float64
+}
+
+// BillingMode is an enum for the available billing modes of tables that are created by gokv.
+type BillingMode int
+
+const (
+	// Provisioned is the BillingMode for provisioned capacity,
+	// which is configured with ReadCapacityUnits and WriteCapacityUnits.
+	Provisioned BillingMode = iota
+	// PayPerRequest is the BillingMode for on-demand capacity.
+	PayPerRequest
+)
+
 // MarshalFormat is an enum for the available (un-)marshal formats of this gokv.Store implementation.
 type MarshalFormat int
 
@@ -159,15 +237,19 @@ type Options struct {
 	// Name of the DynamoDB table.
 	// Optional ("gokv" by default).
 	TableName string
+	// Billing mode of the table.
+	// Only used when the table doesn't exist yet and is created by gokv.
+	// Optional (Provisioned by default).
+	BillingMode BillingMode
 	// ReadCapacityUnits of the table.
-	// Only required when the table doesn't exist yet and is created by gokv.
+	// Only required when the table doesn't exist yet and is created by gokv with the Provisioned BillingMode.
 	// Optional (5 by default, which is the same default value as when creating a table in the web console)
 	// 25 RCUs are included in the free tier (across all tables).
 	// For example calculations, see https://github.com/awsdocs/amazon-dynamodb-developer-guide/blob/c420420a59040c5b3dd44a6e59f7c9e55fc922ef/doc_source/HowItWorks.ProvisionedThroughput.
 	// For limits, see https://github.com/awsdocs/amazon-dynamodb-developer-guide/blob/c420420a59040c5b3dd44a6e59f7c9e55fc922ef/doc_source/Limits.md#capacity-units-and-provisioned-throughput.md#provisioned-throughput.
 	ReadCapacityUnits int64
-	// ReadCapacityUnits of the table.
-	// Only required when the table doesn't exist yet and is created by gokv.
+	// WriteCapacityUnits of the table.
+	// Only required when the table doesn't exist yet and is created by gokv with the Provisioned BillingMode.
 	// Optional (5 by default, which is the same default value as when creating a table in the web console)
 	// 25 RCUs are included in the free tier (across all tables).
 	// For example calculations, see https://github.com/awsdocs/amazon-dynamodb-developer-guide/blob/c420420a59040c5b3dd44a6e59f7c9e55fc922ef/doc_source/HowItWorks.ProvisionedThroughput.
@@ -181,6 +263,46 @@ type Options struct {
 	// because otherwise you will get ResourceNotFoundException errors.
 	// Optional (true by default).
 	WaitForTableCreation *bool
+	// Prevents NewClient() from creating the table.
+	// Instead, it returns a *gokv.ResourceNotFoundError if the table doesn't exist.
+	// This is useful when the credentials aren't allowed to create tables.
+	// The table can then be created separately with Provision().
+	// Optional (false by default).
+	DisableAutoProvisioning bool
+	// Name of the attribute that DynamoDB's Time to Live (TTL) feature uses as expiry time.
+	// TTL is enabled when gokv creates the table, and also for existing tables that don't have it enabled yet,
+	// unless DisableAutoProvisioning is set.
+	// DynamoDB deletes items whose attribute with this name contains a Unix time (in seconds) in the past.
+	// gokv doesn't write the attribute itself, but other applications that write to the same table can.
+	// gokv waits for the table creation when this is set, regardless of WaitForTableCreation,
+	// because TTL can only be enabled for active tables.
+	// A different attribute name than the one of an existing table leads to an error.
+	// Optional ("" by default, which leaves TTL disabled).
+	TTLAttributeName string
+	// Enables point-in-time recovery (continuous backups) of the table.
+	// It's enabled when gokv creates the table, and also for existing tables that don't have it enabled yet,
+	// unless DisableAutoProvisioning is set.
+	// gokv waits for the table creation when this is set, regardless of WaitForTableCreation,
+	// because point-in-time recovery can only be enabled for active tables.
+	// Optional (false by default).
+	PointInTimeRecovery bool
+	// Uses strongly consistent reads in Get(), which reflect all writes that succeeded before the read.
+	// They consume twice the read capacity of eventually consistent reads.
+	// Optional (false by default).
+	ConsistentRead bool
+	// Function that's called with the consumed capacity after each successful operation,
+	// for example to record it as metric.
+	// The function is called synchronously, so it should return quickly.
+	// It can be called concurrently, for example when the client is used by multiple goroutines
+	// or by the goroutine that refreshes a lock, so it must be safe for concurrent use.
+	// Optional (nil by default, in which case the consumed capacity isn't requested).
+	ConsumedCapacityHandler func(ConsumedCapacity)
+	// If true, structs (and pointers to structs) are stored as native DynamoDB attributes,
+	// with one attribute per exported struct field, so that the DynamoDB console, streams and queries can access them.
+	// See the package documentation for details.
+	// Other values are still marshalled and stored in a binary attribute.
+	// Optional (false by default).
+	NativeAttributes bool
 	// AWS access key ID (part of the credentials).
 	// Optional (read from shared credentials file or environment variable if not set).
 	// Environment variable: "AWS_ACCESS_KEY_ID".
@@ -204,7 +326,8 @@ type Options struct {
 // Region: "" (use shared config file or environment variable), TableName: "gokv",
 // AWSaccessKeyID: "" (use shared credentials file or environment variable),
 // AWSsecretAccessKey: "" (use shared credentials file or environment variable),
-// CustomEndpoint: "", MarshalFormat: JSON
+// CustomEndpoint: "", MarshalFormat: JSON, BillingMode: Provisioned,
+// ReadCapacityUnits: 5, WriteCapacityUnits: 5, WaitForTableCreation: true
 var DefaultOptions = Options{
 	TableName:            "gokv",
 	ReadCapacityUnits:    5,
@@ -220,10 +343,93 @@ var DefaultOptions = Options{
 // (Linux: "~/.aws/credentials", Windows: "%UserProfile%\.aws\credentials")
 // or environment variables (AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY).
 // See https://github.com/awsdocs/aws-go-developer-guide/blob/0ae5712d120d43867cf81de875cb7505f62f2d71/doc_source/configuring-sdk.rst#specifying-credentials.
+//
+// Unless DisableAutoProvisioning is set, the table is created if it doesn't exist yet.
 func NewClient(options Options) (Client, error) {
 	result := Client{}
 
-	// Set default values
+	options = setDefaults(options)
+	if options.BillingMode != Provisioned && options.BillingMode != PayPerRequest {
+		return result, errors.New("The BillingMode is invalid")
+	}
+	svc, err := newService(options)
+	if err != nil {
+		return result, err
+	}
+
+	// Create table if it doesn't exist.
+	// Also serves as connection test.
+	// Use context for timeout.
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	describeTableInput := awsdynamodb.DescribeTableInput{
+		TableName: &options.TableName,
+	}
+	_, err = svc.DescribeTableWithContext(timeoutCtx, &describeTableInput)
+	if err != nil {
+		if !isResourceNotFound(err) {
+			return result, err
+		}
+		if options.DisableAutoProvisioning {
+			return result, &gokv.ResourceNotFoundError{ResourceType: "table", Name: options.TableName}
+		}
+		err = createTable(svc, options)
+		if err != nil {
+			return result, err
+		}
+	} else if !options.DisableAutoProvisioning {
+		// A previous call might have created the table, but failed to enable TTL or point-in-time recovery.
+		err = configureTable(svc, options)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	result.c = svc
+	result.tableName = options.TableName
+	result.consistentRead = options.ConsistentRead
+	result.nativeAttributes = options.NativeAttributes
+	result.consumedCapacityHandler = options.ConsumedCapacityHandler
+	result.marshalFormat = options.MarshalFormat
+
+	return result, nil
+}
+
+// Provision creates the table for the given options if it doesn't exist yet, the same way NewClient() does.
+// For existing tables it enables TTL and point-in-time recovery if they're configured but not enabled yet.
+// It's meant to be called once in a separate setup step,
+// for example with credentials that have more permissions than the ones that are used by the application.
+// DisableAutoProvisioning is ignored.
+func Provision(options Options) error {
+	options.DisableAutoProvisioning = false
+	_, err := NewClient(options)
+	return err
+}
+
+// Drop deletes the table for the given options, including all stored key-value pairs.
+// It blocks until the table is deleted, with a timeout of 15 seconds.
+// Dropping a non-existing table does NOT lead to an error.
+func Drop(options Options) error {
+	options = setDefaults(options)
+	svc, err := newService(options)
+	if err != nil {
+		return err
+	}
+
+	deleteTableInput := awsdynamodb.DeleteTableInput{
+		TableName: &options.TableName,
+	}
+	_, err = svc.DeleteTable(&deleteTableInput)
+	if isResourceNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return waitForTable(svc, options.TableName, false)
+}
+
+// setDefaults returns a copy of the given options with default values for all unset fields.
+func setDefaults(options Options) Options {
 	if options.TableName == "" {
 		options.TableName = DefaultOptions.TableName
 	}
@@ -236,12 +442,17 @@ func NewClient(options Options) (Client, error) {
 	if options.WaitForTableCreation == nil {
 		options.WaitForTableCreation = DefaultOptions.WaitForTableCreation
 	}
+	return options
+}
+
+// newService creates a DynamoDB service client from the region, credentials and endpoint of the given options.
+func newService(options Options) (*awsdynamodb.DynamoDB, error) {
 	// Set credentials only if set in the options.
 	// If not set, the SDK uses the shared credentials file or environment variables, which is the preferred way.
 	// Return an error if only one of the values is set.
 	var creds *credentials.Credentials
 	if (options.AWSaccessKeyID != "" && options.AWSsecretAccessKey == "") || (options.AWSaccessKeyID == "" && options.AWSsecretAccessKey != "") {
-		return result, errors.New("When passing credentials via options, you need to set BOTH AWSaccessKeyID AND AWSsecretAccessKey")
+		return nil, errors.New("When passing credentials via options, you need to set BOTH AWSaccessKeyID AND AWSsecretAccessKey")
 	} else if options.AWSaccessKeyID != "" {
 		// Due to the previous check we can be sure that in this case AWSsecretAccessKey is not empty as well.
 		creds = credentials.NewStaticCredentials(options.AWSaccessKeyID, options.AWSsecretAccessKey, "")
@@ -265,74 +476,140 @@ func NewClient(options Options) (Client, error) {
 	sessionOpts.Config.MergeIn(config)
 	session, err := session.NewSessionWithOptions(sessionOpts)
 	if err != nil {
-		return result, err
+		return nil, err
 	}
-	svc := awsdynamodb.New(session)
+	return awsdynamodb.New(session), nil
+}
 
-	// Create table if it doesn't exist.
-	// Also serves as connection test.
-	// Use context for timeout.
-	timeoutCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	describeTableInput := awsdynamodb.DescribeTableInput{
+// createTable creates the table with the name, billing mode and capacity units of the given options,
+// and enables TTL and point-in-time recovery if configured.
+// If configured (true by default), it blocks until the table is created.
+func createTable(svc *awsdynamodb.DynamoDB, options Options) error {
+	keyAttrType := "S" // For "string"
+	keyType := "HASH"  // As opposed to "RANGE"
+	createTableInput := awsdynamodb.CreateTableInput{
 		TableName: &options.TableName,
+		AttributeDefinitions: []*awsdynamodb.AttributeDefinition{{
+			AttributeName: &keyAttrName,
+			AttributeType: &keyAttrType,
+		}},
+		KeySchema: []*awsdynamodb.KeySchemaElement{{
+			AttributeName: &keyAttrName,
+			KeyType:       &keyType,
+		}},
 	}
-	_, err = svc.DescribeTableWithContext(timeoutCtx, &describeTableInput)
+	if options.BillingMode == PayPerRequest {
+		createTableInput.BillingMode = aws.String(awsdynamodb.BillingModePayPerRequest)
+	} else {
+		createTableInput.ProvisionedThroughput = &awsdynamodb.ProvisionedThroughput{
+			ReadCapacityUnits:  &options.ReadCapacityUnits,
+			WriteCapacityUnits: &options.WriteCapacityUnits,
+		}
+	}
+	_, err := svc.CreateTable(&createTableInput)
 	if err != nil {
-		awsErr, ok := err.(awserr.Error)
-		if !ok {
-			return result, err
-		} else if awsErr.Code() == awsdynamodb.ErrCodeResourceNotFoundException {
-			keyAttrType := "S" // For "string"
-			keyType := "HASH"  // As opposed to "RANGE"
-			createTableInput := awsdynamodb.CreateTableInput{
+		return err
+	}
+	// Typical table creation duration is 10 seconds.
+	// TTL and point-in-time recovery can only be enabled for active tables, so configureTable() waits as well.
+	if options.TTLAttributeName != "" || options.PointInTimeRecovery {
+		return configureTable(svc, options)
+	} else if *options.WaitForTableCreation {
+		return waitForTable(svc, options.TableName, true)
+	}
+	return nil
+}
+
+// configureTable enables TTL and point-in-time recovery for the table if they're configured but not enabled yet.
+// The table must be active or in the process of being created, in which case configureTable waits for it.
+func configureTable(svc *awsdynamodb.DynamoDB, options Options) error {
+	if options.TTLAttributeName == "" && !options.PointInTimeRecovery {
+		return nil
+	}
+	err := waitForTable(svc, options.TableName, true)
+	if err != nil {
+		return err
+	}
+	if options.TTLAttributeName != "" {
+		describeTimeToLiveInput := awsdynamodb.DescribeTimeToLiveInput{
+			TableName: &options.TableName,
+		}
+		describeTimeToLiveOutput, err := svc.DescribeTimeToLive(&describeTimeToLiveInput)
+		if err != nil {
+			return err
+		}
+		ttl := describeTimeToLiveOutput.TimeToLiveDescription
+		status := aws.StringValue(ttl.TimeToLiveStatus)
+		enabled := status == awsdynamodb.TimeToLiveStatusEnabled || status == awsdynamodb.TimeToLiveStatusEnabling
+		// DynamoDB returns an error if TTL is already enabled with a different attribute name
+		if !enabled || aws.StringValue(ttl.AttributeName) != options.TTLAttributeName {
+			updateTimeToLiveInput := awsdynamodb.UpdateTimeToLiveInput{
 				TableName: &options.TableName,
-				AttributeDefinitions: []*awsdynamodb.AttributeDefinition{{
-					AttributeName: &keyAttrName,
-					AttributeType: &keyAttrType,
-				}},
-				KeySchema: []*awsdynamodb.KeySchemaElement{{
-					AttributeName: &keyAttrName,
-					KeyType:       &keyType,
-				}},
-				ProvisionedThroughput: &awsdynamodb.ProvisionedThroughput{
-					ReadCapacityUnits:  &options.ReadCapacityUnits,
-					WriteCapacityUnits: &options.WriteCapacityUnits,
+				TimeToLiveSpecification: &awsdynamodb.TimeToLiveSpecification{
+					AttributeName: &options.TTLAttributeName,
+					Enabled:       aws.Bool(true),
 				},
 			}
-			_, err := svc.CreateTable(&createTableInput)
+			_, err = svc.UpdateTimeToLive(&updateTimeToLiveInput)
 			if err != nil {
-				return result, err
+				return err
 			}
-			// If configured (true by default), block until the table is created.
-			// Typical table creation duration is 10 seconds.
-			if *options.WaitForTableCreation {
-				for try := 1; try < 16; try++ {
-					describeTableOutput, err := svc.DescribeTable(&describeTableInput)
-					if err != nil || *describeTableOutput.Table.TableStatus == "CREATING" {
-						time.Sleep(1 * time.Second)
-					} else {
-						break
-					}
-				}
-				// Last try (16th) after 15 seconds of waiting.
-				// Now handle error as such.
-				describeTableOutput, err := svc.DescribeTable(&describeTableInput)
-				if err != nil {
-					return result, errors.New("The DynamoDB table couldn't be created")
-				}
-				if *describeTableOutput.Table.TableStatus == "CREATING" {
-					return result, errors.New("The DynamoDB table took too long to be created")
-				}
+		}
+	}
+	if options.PointInTimeRecovery {
+		describeContinuousBackupsInput := awsdynamodb.DescribeContinuousBackupsInput{
+			TableName: &options.TableName,
+		}
+		describeContinuousBackupsOutput, err := svc.DescribeContinuousBackups(&describeContinuousBackupsInput)
+		if err != nil {
+			return err
+		}
+		pitr := describeContinuousBackupsOutput.ContinuousBackupsDescription.PointInTimeRecoveryDescription
+		if pitr == nil || aws.StringValue(pitr.PointInTimeRecoveryStatus) != awsdynamodb.PointInTimeRecoveryStatusEnabled {
+			updateContinuousBackupsInput := awsdynamodb.UpdateContinuousBackupsInput{
+				TableName: &options.TableName,
+				PointInTimeRecoverySpecification: &awsdynamodb.PointInTimeRecoverySpecification{
+					PointInTimeRecoveryEnabled: aws.Bool(true),
+				},
+			}
+			_, err = svc.UpdateContinuousBackups(&updateContinuousBackupsInput)
+			if err != nil {
+				return err
 			}
-		} else {
-			return result, err
 		}
 	}
+	return nil
+}
 
-	result.c = svc
-	result.tableName = options.TableName
-	result.marshalFormat = options.MarshalFormat
+// waitForTable blocks until the table is active (when exists is true) or deleted (when exists is false),
+// with a timeout of 15 seconds.
+func waitForTable(svc *awsdynamodb.DynamoDB, tableName string, exists bool) error {
+	describeTableInput := awsdynamodb.DescribeTableInput{
+		TableName: &tableName,
+	}
+	for try := 1; ; try++ {
+		describeTableOutput, err := svc.DescribeTable(&describeTableInput)
+		if exists && err == nil && *describeTableOutput.Table.TableStatus != "CREATING" {
+			return nil
+		} else if !exists && isResourceNotFound(err) {
+			return nil
+		}
+		// Last try (16th) after 15 seconds of waiting.
+		// Now handle error as such.
+		if try == 16 {
+			if exists && err != nil {
+				return errors.New("The DynamoDB table couldn't be created")
+			} else if exists {
+				return errors.New("The DynamoDB table took too long to be created")
+			}
+			return errors.New("The DynamoDB table took too long to be deleted")
+		}
+		time.Sleep(1 * time.Second)
+	}
+}
 
-	return result, nil
+// isResourceNotFound returns true if the error is DynamoDB's error for a non-existing table.
+func isResourceNotFound(err error) bool {
+	awsErr, ok := err.(awserr.Error)
+	return ok && awsErr.Code() == awsdynamodb.ErrCodeResourceNotFoundException
 }
@@ -3,7 +3,9 @@ package dynamodb_test
 import (
 	"context"
 	"log"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -12,6 +14,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
 
+	"github.com/philippgille/gokv"
 	"github.com/philippgille/gokv/dynamodb"
 	"github.com/philippgille/gokv/test"
 )
@@ -78,6 +81,18 @@ func TestClientConcurrent(t *testing.T) {
 	test.TestConcurrentInteractions(t, goroutineCount, client)
 }
 
+// TestLock tests if the locks provide mutual exclusion.
+//
+// Note: This test is only executed if the initial connection to DynamoDB works.
+func TestLock(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to DynamoDB could be established. Probably not running in a proper test environment.")
+	}
+
+	client := createClient(t, dynamodb.JSON)
+	test.TestLocker(client, t)
+}
+
 // TestErrors tests some error cases.
 //
 // Note: This test is only executed if the initial connection to DynamoDB works.
@@ -201,6 +216,272 @@ func TestNil(t *testing.T) {
 	t.Run("get with nil / nil value parameter", createTest(dynamodb.Gob))
 }
 
+// TestProvisioning tests if NewClient() returns a *gokv.ResourceNotFoundError
+// when automatic provisioning is disabled and the table doesn't exist,
+// and if Provision() and Drop() create and delete the table.
+//
+// Note: This test is only executed if the initial connection to DynamoDB works.
+func TestProvisioning(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to DynamoDB could be established. Probably not running in a proper test environment.")
+	}
+
+	options := dynamodb.Options{
+		Region:                  endpoints.EuCentral1RegionID,
+		TableName:               "gokvProvisioning",
+		CustomEndpoint:          customEndpoint,
+		DisableAutoProvisioning: true,
+	}
+	err := dynamodb.Drop(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = dynamodb.NewClient(options)
+	if notFoundErr, ok := err.(*gokv.ResourceNotFoundError); !ok {
+		t.Errorf("Expected a *gokv.ResourceNotFoundError, but was: %v", err)
+	} else if notFoundErr.Name != options.TableName {
+		t.Errorf("Expected %v, but was %v", options.TableName, notFoundErr.Name)
+	}
+
+	err = dynamodb.Provision(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := dynamodb.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.TestStore(client, t)
+
+	err = dynamodb.Drop(options)
+	if err != nil {
+		t.Error(err)
+	}
+	_, err = dynamodb.NewClient(options)
+	if _, ok := err.(*gokv.ResourceNotFoundError); !ok {
+		t.Errorf("Expected a *gokv.ResourceNotFoundError, but was: %v", err)
+	}
+}
+
+// TestTableOptions tests if a table can be created with on-demand capacity,
+// if TTL and point-in-time recovery are enabled for an existing table,
+// and if strongly consistent reads and the reporting of consumed capacity work.
+//
+// Note: This test is only executed if the initial connection to DynamoDB works.
+func TestTableOptions(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to DynamoDB could be established. Probably not running in a proper test environment.")
+	}
+
+	var operations []string
+	var capacityUnits float64
+	var lock sync.Mutex
+	options := dynamodb.Options{
+		Region:         endpoints.EuCentral1RegionID,
+		TableName:      "gokvTableOptions",
+		CustomEndpoint: customEndpoint,
+		BillingMode:    dynamodb.PayPerRequest,
+		ConsistentRead: true,
+		// The handler can be called concurrently.
+		ConsumedCapacityHandler: func(consumedCapacity dynamodb.ConsumedCapacity) {
+			lock.Lock()
+			defer lock.Unlock()
+			operations = append(operations, consumedCapacity.Operation)
+			capacityUnits += consumedCapacity.CapacityUnits
+		},
+	}
+	err := dynamodb.Drop(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Create the table without TTL and point-in-time recovery first,
+	// like a previous call that failed to enable them after creating the table.
+	_, err = dynamodb.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options.TTLAttributeName = "expiry"
+	options.PointInTimeRecovery = true
+	client, err := dynamodb.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sess, err := session.NewSession(aws.NewConfig().WithRegion(endpoints.EuCentral1RegionID).WithEndpoint(customEndpoint))
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := awsdynamodb.New(sess)
+	describeTimeToLiveOutput, err := svc.DescribeTimeToLive(&awsdynamodb.DescribeTimeToLiveInput{
+		TableName: &options.TableName,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ttl := describeTimeToLiveOutput.TimeToLiveDescription
+	if status := aws.StringValue(ttl.TimeToLiveStatus); status != awsdynamodb.TimeToLiveStatusEnabled && status != awsdynamodb.TimeToLiveStatusEnabling {
+		t.Errorf("Expected TTL to be enabled, but its status was %v", status)
+	}
+	if aws.StringValue(ttl.AttributeName) != options.TTLAttributeName {
+		t.Errorf("Expected %v, but was %v", options.TTLAttributeName, aws.StringValue(ttl.AttributeName))
+	}
+	describeContinuousBackupsOutput, err := svc.DescribeContinuousBackups(&awsdynamodb.DescribeContinuousBackupsInput{
+		TableName: &options.TableName,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pitr := describeContinuousBackupsOutput.ContinuousBackupsDescription.PointInTimeRecoveryDescription
+	if pitr == nil || aws.StringValue(pitr.PointInTimeRecoveryStatus) != awsdynamodb.PointInTimeRecoveryStatusEnabled {
+		t.Error("Expected point-in-time recovery to be enabled, but it wasn't")
+	}
+
+	err = client.Set("foo", "bar")
+	if err != nil {
+		t.Error(err)
+	}
+	vPtr := new(string)
+	found, err := client.Get("foo", vPtr)
+	if err != nil {
+		t.Error(err)
+	}
+	if !found {
+		t.Error("A value should have been found, but wasn't.")
+	}
+	if *vPtr != "bar" {
+		t.Errorf("Expected %v, but was %v", "bar", *vPtr)
+	}
+	err = client.Delete("foo")
+	if err != nil {
+		t.Error(err)
+	}
+
+	expectedOperations := []string{"Set", "Get", "Delete"}
+	if !reflect.DeepEqual(operations, expectedOperations) {
+		t.Errorf("Expected %v, but was %v", expectedOperations, operations)
+	}
+	if capacityUnits <= 0 {
+		t.Errorf("The consumed capacity units should be greater than 0, but were %v", capacityUnits)
+	}
+
+	err = dynamodb.Drop(options)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+type nativeFoo struct {
+	Name    string
+	Count   int `dynamodbav:"count"`
+	Tags    []string
+	Ignored string `dynamodbav:"-"`
+}
+
+// TestNativeAttributes tests if struct fields are stored as native attributes
+// and if they're converted back when retrieving the value.
+//
+// Note: This test is only executed if the initial connection to DynamoDB works.
+func TestNativeAttributes(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to DynamoDB could be established. Probably not running in a proper test environment.")
+	}
+
+	options := dynamodb.Options{
+		Region:           endpoints.EuCentral1RegionID,
+		CustomEndpoint:   customEndpoint,
+		NativeAttributes: true,
+	}
+	client, err := dynamodb.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.Set("foo", nativeFoo{Name: "bar", Count: 1, Tags: []string{"a"}, Ignored: "baz"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Other tools can read the attributes
+	sess, err := session.NewSession(aws.NewConfig().WithRegion(endpoints.EuCentral1RegionID).WithEndpoint(customEndpoint))
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := awsdynamodb.New(sess)
+	getItemOutput, err := svc.GetItem(&awsdynamodb.GetItemInput{
+		TableName: aws.String(dynamodb.DefaultOptions.TableName),
+		Key: map[string]*awsdynamodb.AttributeValue{
+			"k": {S: aws.String("foo")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := getItemOutput.Item
+	if aws.StringValue(item["Name"].S) != "bar" {
+		t.Errorf("Expected %v, but was %v", "bar", item["Name"])
+	}
+	if aws.StringValue(item["count"].N) != "1" {
+		t.Errorf("Expected %v, but was %v", "1", item["count"])
+	}
+	if len(item["Tags"].L) != 1 || aws.StringValue(item["Tags"].L[0].S) != "a" {
+		t.Errorf("Expected %v, but was %v", "[a]", item["Tags"])
+	}
+	if _, ok := item["Ignored"]; ok {
+		t.Error("The ignored field was stored, but shouldn't have been")
+	}
+
+	actual := nativeFoo{}
+	found, err := client.Get("foo", &actual)
+	if err != nil {
+		t.Error(err)
+	}
+	if !found {
+		t.Error("No value was found, but should have been")
+	}
+	expected := nativeFoo{Name: "bar", Count: 1, Tags: []string{"a"}}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected: %+v, but was: %+v", expected, actual)
+	}
+
+	// A value that was stored as binary attribute can still be retrieved
+	binaryClient := createClient(t, dynamodb.JSON)
+	err = binaryClient.Set("foo", expected)
+	if err != nil {
+		t.Error(err)
+	}
+	actual = nativeFoo{}
+	_, err = client.Get("foo", &actual)
+	if err != nil {
+		t.Error(err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected: %+v, but was: %+v", expected, actual)
+	}
+
+	// Reserved attribute names
+	reserved := struct {
+		K string `dynamodbav:"k"`
+	}{K: "bar"}
+	err = client.Set("foo", reserved)
+	if err == nil {
+		t.Error("An error should have occurred, but didn't")
+	}
+
+	test.TestStore(client, t)
+}
+
+// TestBadOptions tests if invalid options lead to an error.
+// It doesn't require a connection to DynamoDB, because the options are validated first.
+func TestBadOptions(t *testing.T) {
+	options := dynamodb.Options{
+		BillingMode: dynamodb.BillingMode(123),
+	}
+	_, err := dynamodb.NewClient(options)
+	if err == nil {
+		t.Error("An error should have occurred, but didn't")
+	}
+}
+
 // TestClose tests if the close method returns any errors.
 //
 // Note: This test is only executed if the initial connection to DynamoDB works.
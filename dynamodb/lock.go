@@ -0,0 +1,130 @@
+package dynamodb
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/philippgille/gokv"
+)
+
+// lockPrefix is the prefix of the keys of the items in which locks are stored,
+// so that lock names don't collide with the keys of key-value pairs.
+const lockPrefix = "gokv-lock/"
+
+// Attribute names of lock items.
+// The expiry is stored as Unix time in milliseconds.
+var (
+	lockTokenAttrName  = "lock_token"
+	lockExpiryAttrName = "lock_expiry"
+)
+
+// Lock acquires the lock with the given name.
+// The lock is stored in the item with the key "gokv-lock/" + name in the configured table.
+// It expires after gokv.DefaultLockTTL, which is extended in the background while the lock is held.
+// The expiry is compared with the local time of the clients, so their clocks must be roughly synchronized.
+// It blocks until the lock is acquired or the context is done.
+func (c Client) Lock(ctx context.Context, name string) (gokv.Lock, error) {
+	return gokv.AcquireTTLLock(ctx, lockBackend{c: c}, name, gokv.DefaultLockTTL)
+}
+
+// lockBackend implements gokv.TTLLockBackend for DynamoDB with conditional writes.
+type lockBackend struct {
+	c Client
+}
+
+func (b lockBackend) TryLock(name, token string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	putItemInput := awsdynamodb.PutItemInput{
+		TableName: &b.c.tableName,
+		Item: map[string]*awsdynamodb.AttributeValue{
+			keyAttrName:        {S: aws.String(lockPrefix + name)},
+			lockTokenAttrName:  {S: &token},
+			lockExpiryAttrName: {N: unixMilliseconds(now.Add(ttl))},
+		},
+		// The lock can be acquired if it doesn't exist or is expired
+		ConditionExpression: aws.String("attribute_not_exists(#k) OR #e < :now"),
+		ExpressionAttributeNames: map[string]*string{
+			"#k": &keyAttrName,
+			"#e": &lockExpiryAttrName,
+		},
+		ExpressionAttributeValues: map[string]*awsdynamodb.AttributeValue{
+			":now": {N: unixMilliseconds(now)},
+		},
+		ReturnConsumedCapacity: b.c.returnConsumedCapacity(),
+	}
+	putItemOutput, err := b.c.c.PutItem(&putItemInput)
+	if err == nil {
+		b.c.reportConsumedCapacity("Lock", putItemOutput.ConsumedCapacity)
+	}
+	return checkCondition(err)
+}
+
+func (b lockBackend) RefreshLock(name, token string, ttl time.Duration) (bool, error) {
+	updateItemInput := awsdynamodb.UpdateItemInput{
+		TableName: &b.c.tableName,
+		Key: map[string]*awsdynamodb.AttributeValue{
+			keyAttrName: {S: aws.String(lockPrefix + name)},
+		},
+		UpdateExpression:    aws.String("SET #e = :e"),
+		ConditionExpression: aws.String("#t = :t"),
+		ExpressionAttributeNames: map[string]*string{
+			"#e": &lockExpiryAttrName,
+			"#t": &lockTokenAttrName,
+		},
+		ExpressionAttributeValues: map[string]*awsdynamodb.AttributeValue{
+			":e": {N: unixMilliseconds(time.Now().Add(ttl))},
+			":t": {S: &token},
+		},
+		ReturnConsumedCapacity: b.c.returnConsumedCapacity(),
+	}
+	updateItemOutput, err := b.c.c.UpdateItem(&updateItemInput)
+	if err == nil {
+		b.c.reportConsumedCapacity("RefreshLock", updateItemOutput.ConsumedCapacity)
+	}
+	return checkCondition(err)
+}
+
+func (b lockBackend) ReleaseLock(name, token string) error {
+	deleteItemInput := awsdynamodb.DeleteItemInput{
+		TableName: &b.c.tableName,
+		Key: map[string]*awsdynamodb.AttributeValue{
+			keyAttrName: {S: aws.String(lockPrefix + name)},
+		},
+		ConditionExpression: aws.String("#t = :t"),
+		ExpressionAttributeNames: map[string]*string{
+			"#t": &lockTokenAttrName,
+		},
+		ExpressionAttributeValues: map[string]*awsdynamodb.AttributeValue{
+			":t": {S: &token},
+		},
+		ReturnConsumedCapacity: b.c.returnConsumedCapacity(),
+	}
+	deleteItemOutput, err := b.c.c.DeleteItem(&deleteItemInput)
+	if err == nil {
+		b.c.reportConsumedCapacity("Unlock", deleteItemOutput.ConsumedCapacity)
+	}
+	_, err = checkCondition(err)
+	return err
+}
+
+// checkCondition returns false without error if the error is due to a failed condition.
+// It returns true if there's no error.
+func checkCondition(err error) (bool, error) {
+	if err == nil {
+		return true, nil
+	}
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == awsdynamodb.ErrCodeConditionalCheckFailedException {
+		return false, nil
+	}
+	return false, err
+}
+
+// unixMilliseconds returns the time as Unix time in milliseconds, as string for a DynamoDB number.
+func unixMilliseconds(t time.Time) *string {
+	return aws.String(strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10))
+}
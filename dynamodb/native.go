@@ -0,0 +1,51 @@
+package dynamodb
+
+import (
+	"errors"
+	"reflect"
+
+	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+
+	"github.com/philippgille/gokv/util"
+)
+
+// toNativeItem converts the given struct (or pointer to a struct) to an item
+// with one native DynamoDB attribute per exported struct field, plus the key attribute.
+func toNativeItem(k string, v interface{}) (map[string]*awsdynamodb.AttributeValue, error) {
+	if val := reflect.ValueOf(v); val.Kind() == reflect.Ptr && val.IsNil() {
+		return nil, errors.New("The value must not be a nil pointer")
+	}
+	item, err := dynamodbattribute.MarshalMap(v)
+	if err != nil {
+		return nil, err
+	}
+	// The key attribute identifies the item and the value attribute identifies marshalled values,
+	// so struct fields must not use their names.
+	for _, reserved := range []string{keyAttrName, valAttrName} {
+		if _, ok := item[reserved]; ok {
+			return nil, util.ReservedFieldNameError("attribute", reserved, "dynamodbav")
+		}
+	}
+	item[keyAttrName] = &awsdynamodb.AttributeValue{
+		S: &k,
+	}
+	return item, nil
+}
+
+// fromNativeItem populates the struct that v points to with the attributes of the given item.
+// Attributes without a corresponding struct field are ignored.
+func fromNativeItem(item map[string]*awsdynamodb.AttributeValue, v interface{}) error {
+	attributes := make(map[string]*awsdynamodb.AttributeValue, len(item))
+	for name, attributeVal := range item {
+		if name != keyAttrName {
+			attributes[name] = attributeVal
+		}
+	}
+	return dynamodbattribute.UnmarshalMap(attributes, v)
+}
+
+// isNativeStorable returns true if values of the given type are stored as native attributes in the NativeAttributes mode.
+func isNativeStorable(t reflect.Type) bool {
+	return len(util.StructFields(t, "dynamodbav")) > 0
+}
@@ -2,10 +2,14 @@ package etcd
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"io/ioutil"
 	"time"
 
 	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/clientv3/namespace"
 
 	"github.com/philippgille/gokv/util"
 )
@@ -13,8 +17,12 @@ import (
 var defaultTimeout = 200 * time.Millisecond
 
 // Client is a gokv.Store implementation for etcd.
+// It also implements gokv.Locker.
 type Client struct {
-	c             *clientv3.Client
+	c *clientv3.Client
+	// kv is the KV API of c, prefixed with the namespace if one is configured
+	kv            clientv3.KV
+	namespace     string
 	timeOut       time.Duration
 	marshalFormat MarshalFormat
 }
@@ -44,7 +52,7 @@ func (c Client) Set(k string, v interface{}) error {
 
 	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), c.timeOut)
 	defer cancel()
-	_, err = c.c.Put(ctxWithTimeout, k, string(data))
+	_, err = c.kv.Put(ctxWithTimeout, k, string(data))
 	if err != nil {
 		return err
 	}
@@ -65,7 +73,7 @@ func (c Client) Get(k string, v interface{}) (found bool, err error) {
 
 	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), c.timeOut)
 	defer cancel()
-	getRes, err := c.c.Get(ctxWithTimeout, k)
+	getRes, err := c.kv.Get(ctxWithTimeout, k)
 	if err != nil {
 		return false, err
 	}
@@ -96,7 +104,7 @@ func (c Client) Delete(k string) error {
 
 	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), c.timeOut)
 	defer cancel()
-	_, err := c.c.Delete(ctxWithTimeout, k)
+	_, err := c.kv.Delete(ctxWithTimeout, k)
 	return err
 }
 
@@ -124,17 +132,38 @@ type Options struct {
 	// The timeout for operations.
 	// Optional (200 * time.Millisecond by default).
 	Timeout *time.Duration
+	// Username for etcd's authentication.
+	// Optional ("" by default, which means no authentication).
+	Username string
+	// Password for etcd's authentication.
+	// Optional ("" by default).
+	Password string
+	// Prefix for all keys, for example "myapp/".
+	// It allows multiple applications to share an etcd cluster without key collisions.
+	// Optional ("" by default).
+	Namespace string
+	// Path to a PEM encoded file with the CA certificate(s) to verify the server certificate with.
+	// If any of the TLS files is set, TLS is used and the endpoints should have the "https://" scheme.
+	// Optional ("" by default, which means that the system's CA certificates are used).
+	CAFile string
+	// Path to a PEM encoded file with the client certificate for TLS client authentication.
+	// Optional ("" by default).
+	CertFile string
+	// Path to a PEM encoded file with the private key of the client certificate.
+	// Required if CertFile is set.
+	KeyFile string
 	// (Un-)marshal format.
 	// Optional (JSON by default).
 	MarshalFormat MarshalFormat
 }
 
 // DefaultOptions is an Options object with default values.
-// Endpoints: []string{"localhost:2379"}, Timeout: 200 * time.Millisecond, MarshalFormat: JSON
+// Endpoints: []string{"localhost:2379"}, Timeout: 200 * time.Millisecond, Username: "", Password: "", Namespace: "",
+// CAFile: "", CertFile: "", KeyFile: "", MarshalFormat: JSON
 var DefaultOptions = Options{
 	Endpoints: []string{"localhost:2379"},
 	Timeout:   &defaultTimeout,
-	// No need to set MarshalFormat to JSON because its zero value is fine.
+	// No need to set the other options because their zero values are fine.
 }
 
 // NewClient creates a new etcd client.
@@ -149,6 +178,11 @@ func NewClient(options Options) (Client, error) {
 		options.Timeout = DefaultOptions.Timeout
 	}
 
+	tlsConfig, err := newTLSConfig(options)
+	if err != nil {
+		return result, err
+	}
+
 	// clientv3.New() should block when a DialTimeout is set,
 	// according to https://github.com/etcd-io/etcd/issues/9829.
 	// TODO: But it doesn't.
@@ -156,6 +190,9 @@ func NewClient(options Options) (Client, error) {
 	config := clientv3.Config{
 		Endpoints:   options.Endpoints,
 		DialTimeout: 2 * time.Second,
+		Username:    options.Username,
+		Password:    options.Password,
+		TLS:         tlsConfig,
 	}
 
 	cli, err := clientv3.New(config)
@@ -167,15 +204,53 @@ func NewClient(options Options) (Client, error) {
 	defer cancel()
 	statusRes, err := cli.Status(ctxWithTimeout, options.Endpoints[0])
 	if err != nil {
+		cli.Close()
 		return result, err
 	} else if statusRes == nil {
+		cli.Close()
 		return result, errors.New("The status response from etcd was nil")
 	}
 
+	var kv clientv3.KV = cli
+	if options.Namespace != "" {
+		kv = namespace.NewKV(cli.KV, options.Namespace)
+	}
+
 	result = Client{
 		c:             cli,
+		kv:            kv,
+		namespace:     options.Namespace,
 		timeOut:       *options.Timeout,
 		marshalFormat: options.MarshalFormat,
 	}
 	return result, nil
 }
+
+// newTLSConfig creates the TLS config from the configured files.
+// It returns nil if none of the files is configured.
+func newTLSConfig(options Options) (*tls.Config, error) {
+	if options.CAFile == "" && options.CertFile == "" && options.KeyFile == "" {
+		return nil, nil
+	}
+
+	result := &tls.Config{}
+	if options.CAFile != "" {
+		caCerts, err := ioutil.ReadFile(options.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(caCerts) {
+			return nil, errors.New("The CAFile doesn't contain any PEM encoded certificates")
+		}
+		result.RootCAs = certPool
+	}
+	if options.CertFile != "" || options.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(options.CertFile, options.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		result.Certificates = []tls.Certificate{cert}
+	}
+	return result, nil
+}
@@ -2,7 +2,11 @@ package etcd_test
 
 import (
 	"context"
+	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
+	"strconv"
 	"testing"
 	"time"
 
@@ -70,6 +74,18 @@ func TestClientConcurrent(t *testing.T) {
 	test.TestConcurrentInteractions(t, goroutineCount, client)
 }
 
+// TestLock tests if the locks provide mutual exclusion.
+//
+// Note: This test is only executed if the initial connection to etcd works.
+func TestLock(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to etcd could be established. Probably not running in a proper test environment.")
+	}
+
+	client := createClient(t, etcd.JSON)
+	test.TestLocker(client, t)
+}
+
 // TestErrors tests some error cases.
 //
 // Note: This test is only executed if the initial connection to etcd works.
@@ -215,6 +231,153 @@ func TestDefaultTimeout(t *testing.T) {
 	}
 }
 
+// TestNamespace tests if keys are prefixed with the configured namespace.
+//
+// Note: This test is only executed if the initial connection to etcd works.
+func TestNamespace(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to etcd could be established. Probably not running in a proper test environment.")
+	}
+
+	timeout := 2 * time.Second
+	options := etcd.Options{
+		Timeout:   &timeout,
+		Namespace: "gokv_test_ns/",
+	}
+	client, err := etcd.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	test.TestStore(client, t)
+
+	nsKey := "foo_" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	err = client.Set(nsKey, "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Delete(nsKey)
+
+	// The key must be stored with the prefix
+	cli, err := clientv3.New(clientv3.Config{
+		Endpoints:   []string{"localhost:2379"},
+		DialTimeout: 2 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	getRes, err := cli.Get(ctxWithTimeout, "gokv_test_ns/"+nsKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(getRes.Kvs) != 1 {
+		t.Errorf("Expected the key to be stored with the namespace as prefix")
+	}
+
+	// A client without namespace must not find the key
+	nonNsClient := createClient(t, etcd.JSON)
+	defer nonNsClient.Close()
+	found, err := nonNsClient.Get(nsKey, new(string))
+	if err != nil {
+		t.Error(err)
+	}
+	if found {
+		t.Error("A value was found, but no value was expected")
+	}
+}
+
+// TestAuth tests if the client can authenticate with username and password.
+// Enabling authentication affects all clients of a server, so the test requires a dedicated etcd server
+// with authentication enabled, configured with the environment variables "ETCD_AUTH_ENDPOINT",
+// "ETCD_AUTH_USERNAME" and "ETCD_AUTH_PASSWORD". The user must be allowed to read and write all keys.
+//
+// Note: This test is only executed if the environment variables are set.
+func TestAuth(t *testing.T) {
+	endpoint := os.Getenv("ETCD_AUTH_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("No etcd server with authentication is configured. Probably not running in a proper test environment.")
+	}
+
+	timeout := 2 * time.Second
+	options := etcd.Options{
+		Endpoints: []string{endpoint},
+		Timeout:   &timeout,
+		Username:  os.Getenv("ETCD_AUTH_USERNAME"),
+		Password:  os.Getenv("ETCD_AUTH_PASSWORD"),
+	}
+	client, err := etcd.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	test.TestStore(client, t)
+
+	// Test with a wrong password
+	options.Password += "wrong"
+	_, err = etcd.NewClient(options)
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+// TestTLS tests if the client can connect to an etcd server that requires TLS client certificates.
+// The server and the files must be configured with the environment variables
+// "ETCD_TLS_ENDPOINT", "ETCD_CA_FILE", "ETCD_CERT_FILE" and "ETCD_KEY_FILE".
+//
+// Note: This test is only executed if the environment variables are set.
+func TestTLS(t *testing.T) {
+	endpoint := os.Getenv("ETCD_TLS_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("No etcd server with TLS is configured. Probably not running in a proper test environment.")
+	}
+
+	timeout := 2 * time.Second
+	options := etcd.Options{
+		Endpoints: []string{endpoint},
+		Timeout:   &timeout,
+		CAFile:    os.Getenv("ETCD_CA_FILE"),
+		CertFile:  os.Getenv("ETCD_CERT_FILE"),
+		KeyFile:   os.Getenv("ETCD_KEY_FILE"),
+	}
+	client, err := etcd.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	test.TestStore(client, t)
+}
+
+// TestTLSFiles tests if invalid TLS files lead to an error.
+// The files are checked before connecting, so this test doesn't require a running etcd server.
+func TestTLSFiles(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gokv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	invalidFile := filepath.Join(tmpDir, "invalid.pem")
+	err = ioutil.WriteFile(invalidFile, []byte("foo"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	badOptions := []etcd.Options{
+		{CAFile: filepath.Join(tmpDir, "non-existing.pem")},
+		{CAFile: invalidFile},
+		{CertFile: invalidFile, KeyFile: invalidFile},
+		{CertFile: invalidFile},
+	}
+	for _, options := range badOptions {
+		_, err := etcd.NewClient(options)
+		if err == nil {
+			t.Errorf("Expected an error for options %+v", options)
+		}
+	}
+}
+
 // checkConnection returns true if a connection could be made, false otherwise.
 func checkConnection() bool {
 	// clientv3.New() should block when a DialTimeout is set,
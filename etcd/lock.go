@@ -0,0 +1,89 @@
+package etcd
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.etcd.io/etcd/clientv3/concurrency"
+
+	"github.com/philippgille/gokv"
+)
+
+// lockPrefix is the prefix of the keys in which locks are stored,
+// so that lock names don't collide with the keys of key-value pairs.
+const lockPrefix = "gokv-lock/"
+
+// Lock acquires the lock with the given name.
+// It uses an etcd session with a lease TTL of gokv.DefaultLockTTL, which is kept alive in the background while the lock is held.
+// The lock is stored under the key prefix "gokv-lock/" + name in the configured namespace.
+// It blocks until the lock is acquired or the context is done.
+func (c Client) Lock(ctx context.Context, name string) (gokv.Lock, error) {
+	// Don't pass ctx to the session, because the lease would only be kept alive until ctx is done
+	session, err := concurrency.NewSession(c.c, concurrency.WithTTL(int(gokv.DefaultLockTTL.Seconds())))
+	if err != nil {
+		return nil, err
+	}
+	// The mutex uses the client directly, so the namespace must be added here
+	mutex := concurrency.NewMutex(session, c.namespace+lockPrefix+name)
+	if err = mutex.Lock(ctx); err != nil {
+		session.Close()
+		return nil, err
+	}
+
+	l := &etcdLock{
+		session:  session,
+		mutex:    mutex,
+		timeOut:  c.timeOut,
+		lost:     make(chan struct{}),
+		unlocked: make(chan struct{}),
+	}
+	go l.monitor()
+	return l, nil
+}
+
+// etcdLock is a gokv.Lock that's held with an etcd session.
+type etcdLock struct {
+	session    *concurrency.Session
+	mutex      *concurrency.Mutex
+	timeOut    time.Duration
+	lost       chan struct{}
+	unlocked   chan struct{}
+	unlockOnce sync.Once
+}
+
+// monitor closes the lost channel when the lease of the session expired.
+// The session is also done when it's closed on Unlock, which doesn't count as lost.
+func (l *etcdLock) monitor() {
+	select {
+	case <-l.session.Done():
+		select {
+		case <-l.unlocked:
+		default:
+			close(l.lost)
+		}
+	case <-l.unlocked:
+	}
+}
+
+// Unlock releases the lock and revokes the lease of the session.
+// Calling it more than once has no effect.
+func (l *etcdLock) Unlock() error {
+	var err error
+	l.unlockOnce.Do(func() {
+		close(l.unlocked)
+		ctxWithTimeout, cancel := context.WithTimeout(context.Background(), l.timeOut)
+		defer cancel()
+		err = l.mutex.Unlock(ctxWithTimeout)
+		// Revoking the lease deletes the lock's key as well, so it's done even if the unlock failed
+		if closeErr := l.session.Close(); err == nil {
+			err = closeErr
+		}
+	})
+	return err
+}
+
+// Lost returns a channel that is closed when the lock was lost.
+func (l *etcdLock) Lost() <-chan struct{} {
+	return l.lost
+}
@@ -0,0 +1,12 @@
+/*
+Package file contains an implementation of the `gokv.Store` interface for the local file system.
+
+Each value is stored in its own file, so the store is easy to inspect and edit with the usual tools.
+The file names are the escaped keys with the marshal format as file extension, for example "foo%2Fbar.json" for the key "foo/bar".
+Uppercase letters are escaped as well ("%46oo.json" for the key "Foo"), so different keys never share a file on case-insensitive file systems.
+Values are written to a temporary file first, which is then renamed, so readers never see partially written values.
+
+The store doesn't use any locks or index files, so multiple processes can use the same directory.
+It's not optimized for performance though. For a single process and many key-value pairs use the bbolt or badgerdb package instead.
+*/
+package file
@@ -0,0 +1,317 @@
+package file
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/philippgille/gokv/util"
+)
+
+// Store is a gokv.Store implementation for the local file system.
+// Each value is stored in its own file.
+// It also implements gokv.Lister.
+type Store struct {
+	directory     string
+	fanOut        int
+	sync          bool
+	marshalFormat MarshalFormat
+}
+
+// Set stores the given value for the given key.
+// Values are automatically marshalled to JSON or gob (depending on the configuration).
+// The key must not be "" and the value must not be nil.
+func (s Store) Set(k string, v interface{}) error {
+	if err := util.CheckKeyAndValue(k, v); err != nil {
+		return err
+	}
+
+	// First turn the passed object into something that can be written to a file
+	var data []byte
+	var err error
+	switch s.marshalFormat {
+	case JSON:
+		data, err = util.ToJSON(v)
+	case Gob:
+		data, err = util.ToGob(v)
+	default:
+		err = errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	}
+	if err != nil {
+		return err
+	}
+
+	filePath := s.filePath(k)
+	dir := filepath.Dir(filePath)
+	if s.fanOut > 0 {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+	}
+
+	// Write to a temporary file in the same directory and rename it afterwards,
+	// so that readers never see a partially written file.
+	// The name of the temporary file starts with a dot, which escaped keys never do.
+	tmpFile, err := ioutil.TempFile(dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	_, err = tmpFile.Write(data)
+	if err == nil && s.sync {
+		err = tmpFile.Sync()
+	}
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, filePath)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if s.sync {
+		return syncDir(dir)
+	}
+	return nil
+}
+
+// Get retrieves the stored value for the given key.
+// You need to pass a pointer to the value, so in case of a struct
+// the automatic unmarshalling can populate the fields of the object
+// that v points to with the values of the retrieved object's values.
+// If no value is found it returns (false, nil).
+// The key must not be "" and the pointer must not be nil.
+func (s Store) Get(k string, v interface{}) (found bool, err error) {
+	if err := util.CheckKeyAndValue(k, v); err != nil {
+		return false, err
+	}
+
+	data, err := ioutil.ReadFile(s.filePath(k))
+	if err != nil {
+		// If no value was found return false
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	switch s.marshalFormat {
+	case JSON:
+		return true, util.FromJSON(data, v)
+	case Gob:
+		return true, util.FromGob(data, v)
+	default:
+		return true, errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	}
+}
+
+// Delete deletes the stored value for the given key.
+// Deleting a non-existing key-value pair does NOT lead to an error.
+// The key must not be "".
+func (s Store) Delete(k string) error {
+	if err := util.CheckKey(k); err != nil {
+		return err
+	}
+
+	err := os.Remove(s.filePath(k))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// List calls fn for every key in the store.
+// Only files with the file extension of the configured marshal format are taken into account.
+// If fn returns an error, the iteration is stopped and the error is returned.
+// Key-value pairs that are stored or deleted during the iteration may or may not be included.
+func (s Store) List(fn func(k string) error) error {
+	ext := s.fileExt()
+	return filepath.Walk(s.directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// The file was deleted between reading the directory and walking it
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		name := info.Name()
+		if info.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, ext) {
+			return nil
+		}
+		k, err := unescape(strings.TrimSuffix(name, ext))
+		if err != nil {
+			// Not a file of the store
+			return nil
+		}
+		return fn(k)
+	})
+}
+
+// Close closes the store.
+// When called, some resources of the store are left for garbage collection.
+// The files are not deleted.
+func (s Store) Close() error {
+	return nil
+}
+
+// filePath returns the path of the file for the given key.
+func (s Store) filePath(k string) string {
+	name := escape(k) + s.fileExt()
+	if s.fanOut == 0 {
+		return filepath.Join(s.directory, name)
+	}
+
+	// Use one byte of the key's hash per directory level.
+	hasher := fnv.New32a()
+	hasher.Write([]byte(k))
+	hash := hasher.Sum(nil)
+	elems := make([]string, 0, s.fanOut+2)
+	elems = append(elems, s.directory)
+	for i := 0; i < s.fanOut; i++ {
+		elems = append(elems, fmt.Sprintf("%02x", hash[i]))
+	}
+	elems = append(elems, name)
+	return filepath.Join(elems...)
+}
+
+// fileExt returns the file extension for the configured marshal format.
+func (s Store) fileExt() string {
+	switch s.marshalFormat {
+	case JSON:
+		return ".json"
+	case Gob:
+		return ".gob"
+	default:
+		return ""
+	}
+}
+
+// escape turns the key into a string that can be used as file name on all common file systems.
+// Lowercase letters, digits, "-" and "_" are kept, all other bytes are percent-encoded.
+// This also prevents file names like "." and "..", path separators and names that start with a dot.
+// Uppercase letters are encoded as well, so that keys that only differ in their case
+// lead to different file names on case-insensitive file systems (like the defaults of Windows and macOS).
+// Names that are reserved on Windows, like "con" or "nul", get their first letter encoded.
+func escape(k string) string {
+	buf := bytes.Buffer{}
+	for i := 0; i < len(k); i++ {
+		c := k[i]
+		if (('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c == '-' || c == '_') && !(i == 0 && isReservedName(k)) {
+			buf.WriteByte(c)
+		} else {
+			fmt.Fprintf(&buf, "%%%02X", c)
+		}
+	}
+	return buf.String()
+}
+
+// isReservedName returns true if the given key is a device name that Windows doesn't allow as file name,
+// even with a file extension.
+// Only lowercase names are checked, because escape encodes uppercase letters anyway.
+func isReservedName(k string) bool {
+	switch k {
+	case "con", "prn", "aux", "nul":
+		return true
+	}
+	return len(k) == 4 && (strings.HasPrefix(k, "com") || strings.HasPrefix(k, "lpt")) && '1' <= k[3] && k[3] <= '9'
+}
+
+// unescape is the reverse of escape.
+func unescape(name string) (string, error) {
+	return url.PathUnescape(name)
+}
+
+// syncDir flushes the directory entry of a renamed file to disk.
+// Windows doesn't support syncing directories.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = f.Sync()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// MarshalFormat is an enum for the available (un-)marshal formats of this gokv.Store implementation.
+type MarshalFormat int
+
+const (
+	// JSON is the MarshalFormat for (un-)marshalling to/from JSON
+	JSON MarshalFormat = iota
+	// Gob is the MarshalFormat for (un-)marshalling to/from gob
+	Gob
+)
+
+// Options are the options for the file store.
+type Options struct {
+	// Directory in which the files are stored.
+	// It's created if it doesn't exist yet.
+	// Optional ("gokv" by default).
+	Directory string
+	// Number of directory levels between the directory and the files.
+	// Each level consists of up to 256 directories named after one byte of the key's hash,
+	// so with many key-value pairs the files are spread over multiple directories
+	// instead of all being in one directory, which is slow on some file systems.
+	// Must be between 0 and 4.
+	// Optional (0 by default).
+	FanOut int
+	// Flush files and their directories to disk when storing values.
+	// Without it a stored value can be lost in case of a power loss or operating system crash.
+	// Optional (false by default).
+	Sync bool
+	// (Un-)marshal format.
+	// Optional (JSON by default).
+	MarshalFormat MarshalFormat
+}
+
+// DefaultOptions is an Options object with default values.
+// Directory: "gokv", FanOut: 0, Sync: false, MarshalFormat: JSON
+var DefaultOptions = Options{
+	Directory: "gokv",
+	// No need to set FanOut, Sync or MarshalFormat because their zero values are fine.
+}
+
+// NewStore creates a new file store.
+//
+// Note: Some file systems also limit the length of file names, so very long keys can lead to errors.
+func NewStore(options Options) (Store, error) {
+	result := Store{}
+
+	// Set default values
+	if options.Directory == "" {
+		options.Directory = DefaultOptions.Directory
+	}
+	if options.FanOut < 0 || options.FanOut > 4 {
+		return result, errors.New("The FanOut must be between 0 and 4")
+	}
+
+	err := os.MkdirAll(options.Directory, 0700)
+	if err != nil {
+		return result, err
+	}
+
+	result = Store{
+		directory:     options.Directory,
+		fanOut:        options.FanOut,
+		sync:          options.Sync,
+		marshalFormat: options.MarshalFormat,
+	}
+
+	return result, nil
+}
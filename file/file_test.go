@@ -0,0 +1,277 @@
+package file_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/philippgille/gokv/file"
+	"github.com/philippgille/gokv/test"
+)
+
+// TestStore tests if reading from, writing to and deleting from the store works properly.
+// A struct is used as value. See TestTypes() for a test that is simpler but tests all types.
+func TestStore(t *testing.T) {
+	// Test with JSON
+	t.Run("JSON", func(t *testing.T) {
+		store := createStore(t, file.JSON, 0)
+		test.TestStore(store, t)
+	})
+
+	// Test with gob
+	t.Run("gob", func(t *testing.T) {
+		store := createStore(t, file.Gob, 0)
+		test.TestStore(store, t)
+	})
+
+	// Test with fan-out
+	t.Run("fan-out", func(t *testing.T) {
+		store := createStore(t, file.JSON, 2)
+		test.TestStore(store, t)
+	})
+}
+
+// TestTypes tests if setting and getting values works with all Go types.
+func TestTypes(t *testing.T) {
+	// Test with JSON
+	t.Run("JSON", func(t *testing.T) {
+		store := createStore(t, file.JSON, 0)
+		test.TestTypes(store, t)
+	})
+
+	// Test with gob
+	t.Run("gob", func(t *testing.T) {
+		store := createStore(t, file.Gob, 0)
+		test.TestTypes(store, t)
+	})
+}
+
+// TestStoreConcurrent launches a bunch of goroutines that concurrently work with one store.
+// The store doesn't use any locks, so this tests if the rename-on-write prevents reading partially written files.
+func TestStoreConcurrent(t *testing.T) {
+	store := createStore(t, file.JSON, 1)
+
+	goroutineCount := 1000
+
+	test.TestConcurrentInteractions(t, goroutineCount, store)
+}
+
+// TestList tests if the keys are read from the file names.
+func TestList(t *testing.T) {
+	// Test without fan-out
+	t.Run("flat", func(t *testing.T) {
+		store := createStore(t, file.JSON, 0)
+		test.TestList(store, t)
+	})
+
+	// Test with fan-out
+	t.Run("fan-out", func(t *testing.T) {
+		store := createStore(t, file.JSON, 2)
+		test.TestList(store, t)
+	})
+}
+
+// TestEscaping tests if keys that aren't valid file names are escaped,
+// if keys that only differ in their case lead to file names that also differ on case-insensitive file systems
+// and if the files are only created in the store's directory.
+func TestEscaping(t *testing.T) {
+	dir := generateRandomTempDir(t)
+	options := file.Options{
+		Directory: filepath.Join(dir, "store"),
+	}
+	store, err := file.NewStore(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := []string{".", "..", "../foo", "foo/bar", `foo\bar`, "foo:bar", ".hidden", "foo bar", "äöü", "foo%2Fbar",
+		"Foo", "FOO", "foo", "con", "NUL", "com1", "lpt9"}
+	for _, k := range keys {
+		err := store.Set(k, k)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	for _, k := range keys {
+		actual := ""
+		found, err := store.Get(k, &actual)
+		if err != nil {
+			t.Error(err)
+		}
+		if !found {
+			t.Errorf("No value was found for key %v, but should have been", k)
+		}
+		if actual != k {
+			t.Errorf("Expected: %v, but was: %v", k, actual)
+		}
+	}
+
+	// All files must be directly in the store's directory
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the store's directory, but there were %v entries", len(entries))
+	}
+	files, err := ioutil.ReadDir(options.Directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != len(keys) {
+		t.Errorf("Expected %v files, but there were %v", len(keys), len(files))
+	}
+	names := make(map[string]bool, len(files))
+	for _, f := range files {
+		if f.IsDir() {
+			t.Errorf("Expected only files, but %v is a directory", f.Name())
+		}
+		lowerName := strings.ToLower(f.Name())
+		if names[lowerName] {
+			t.Errorf("Multiple file names are equal when ignoring their case: %v", f.Name())
+		}
+		names[lowerName] = true
+		// Windows device names are reserved, even with a file extension
+		switch strings.TrimSuffix(lowerName, ".json") {
+		case "con", "nul", "com1", "lpt9":
+			t.Errorf("The file name %v is reserved on Windows", f.Name())
+		}
+	}
+	for _, name := range []string{"foo%2Fbar.json", "%46oo.json", "%63on.json"} {
+		_, err = os.Stat(filepath.Join(options.Directory, name))
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// TestSync tests if storing values works when flushing to disk is activated.
+func TestSync(t *testing.T) {
+	options := file.Options{
+		Directory: generateRandomTempDir(t),
+		FanOut:    1,
+		Sync:      true,
+	}
+	store, err := file.NewStore(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.TestStore(store, t)
+}
+
+// TestErrors tests some error cases.
+func TestErrors(t *testing.T) {
+	// Test with a bad MarshalFormat enum value
+
+	store := createStore(t, file.MarshalFormat(19), 0)
+	err := store.Set("foo", "bar")
+	if err == nil {
+		t.Error("An error should have occurred, but didn't")
+	}
+
+	// Test with a bad FanOut value
+	_, err = file.NewStore(file.Options{Directory: generateRandomTempDir(t), FanOut: 5})
+	if err == nil {
+		t.Error("Expected an error")
+	}
+
+	// Test empty key
+	err = store.Set("", "bar")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	_, err = store.Get("", new(string))
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	err = store.Delete("")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+// TestNil tests the behaviour when passing nil or pointers to nil values to some methods.
+func TestNil(t *testing.T) {
+	// Test setting nil
+
+	t.Run("set nil with JSON marshalling", func(t *testing.T) {
+		store := createStore(t, file.JSON, 0)
+		err := store.Set("foo", nil)
+		if err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	t.Run("set nil with Gob marshalling", func(t *testing.T) {
+		store := createStore(t, file.Gob, 0)
+		err := store.Set("foo", nil)
+		if err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	// Test passing nil or pointer to nil value for retrieval
+
+	createTest := func(mf file.MarshalFormat) func(t *testing.T) {
+		return func(t *testing.T) {
+			store := createStore(t, mf, 0)
+
+			// Prep
+			err := store.Set("foo", test.Foo{Bar: "baz"})
+			if err != nil {
+				t.Error(err)
+			}
+
+			_, err = store.Get("foo", nil) // actually nil
+			if err == nil {
+				t.Error("An error was expected")
+			}
+
+			var i interface{} // actually nil
+			_, err = store.Get("foo", i)
+			if err == nil {
+				t.Error("An error was expected")
+			}
+
+			var valPtr *test.Foo // nil value
+			_, err = store.Get("foo", valPtr)
+			if err == nil {
+				t.Error("An error was expected")
+			}
+		}
+	}
+	t.Run("get with nil / nil value parameter", createTest(file.JSON))
+	t.Run("get with nil / nil value parameter", createTest(file.Gob))
+}
+
+// TestClose tests if the close method returns any errors.
+func TestClose(t *testing.T) {
+	store := createStore(t, file.JSON, 0)
+	err := store.Close()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func createStore(t *testing.T, mf file.MarshalFormat, fanOut int) file.Store {
+	options := file.Options{
+		Directory:     generateRandomTempDir(t),
+		FanOut:        fanOut,
+		MarshalFormat: mf,
+	}
+	store, err := file.NewStore(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func generateRandomTempDir(t *testing.T) string {
+	path, err := ioutil.TempDir(os.TempDir(), "gokv")
+	if err != nil {
+		t.Fatalf("Generating random directory failed: %v", err)
+	}
+	return path
+}
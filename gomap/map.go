@@ -1,9 +1,13 @@
 package gomap
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"strings"
 	"sync"
 
+	"github.com/philippgille/gokv"
 	"github.com/philippgille/gokv/util"
 )
 
@@ -11,9 +15,26 @@ import (
 type Store struct {
 	m             map[string][]byte
 	lock          *sync.RWMutex
+	watchers      *watchers
 	marshalFormat MarshalFormat
 }
 
+// watchers holds the channels of all active Watch() calls.
+type watchers struct {
+	lock  sync.RWMutex
+	chans map[chan gokv.WatchEvent]watcher
+}
+
+type watcher struct {
+	ctx context.Context
+	// Cancels ctx, which disconnects the watcher.
+	cancel context.CancelFunc
+	prefix string
+}
+
+// watchBufferSize is the number of events that are buffered for a watcher before it's disconnected.
+const watchBufferSize = 64
+
 // Set stores the given value for the given key.
 // Values are automatically marshalled to JSON or gob (depending on the configuration).
 // The key must not be "" and the value must not be nil.
@@ -22,23 +43,15 @@ func (m Store) Set(k string, v interface{}) error {
 		return err
 	}
 
-	var data []byte
-	var err error
-	switch m.marshalFormat {
-	case JSON:
-		data, err = util.ToJSON(v)
-	case Gob:
-		data, err = util.ToGob(v)
-	default:
-		err = errors.New("The store seems to be configured with a marshal format that's not implemented yet")
-	}
+	data, err := m.marshal(v)
 	if err != nil {
 		return err
 	}
 
 	m.lock.Lock()
-	defer m.lock.Unlock()
 	m.m[k] = data
+	m.lock.Unlock()
+	m.notify(gokv.WatchEvent{Key: k})
 	return nil
 }
 
@@ -81,10 +94,167 @@ func (m Store) Delete(k string) error {
 		return err
 	}
 
+	m.lock.Lock()
 	delete(m.m, k)
+	m.lock.Unlock()
+	m.notify(gokv.WatchEvent{Key: k, Deleted: true})
 	return nil
 }
 
+// List calls fn for every key in the store, in no particular order.
+// If fn returns an error, the iteration is stopped and the error is returned.
+// The keys are copied before the iteration starts,
+// so fn can safely call other methods of the store.
+func (m Store) List(fn func(k string) error) error {
+	m.lock.RLock()
+	keys := make([]string, 0, len(m.m))
+	for k := range m.m {
+		keys = append(keys, k)
+	}
+	m.lock.RUnlock()
+
+	for _, k := range keys {
+		if err := fn(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetMany stores the given values for the given keys.
+// All values are marshalled before any of them is stored,
+// so either all key-value pairs are stored or none of them.
+// The keys must not be "" and the values must not be nil.
+func (m Store) SetMany(kvs map[string]interface{}) error {
+	dataMap := make(map[string][]byte, len(kvs))
+	for k, v := range kvs {
+		if err := util.CheckKeyAndValue(k, v); err != nil {
+			return err
+		}
+		data, err := m.marshal(v)
+		if err != nil {
+			return err
+		}
+		dataMap[k] = data
+	}
+
+	m.lock.Lock()
+	for k, data := range dataMap {
+		m.m[k] = data
+	}
+	m.lock.Unlock()
+	for k := range dataMap {
+		m.notify(gokv.WatchEvent{Key: k})
+	}
+	return nil
+}
+
+// DeleteMany deletes the stored values for the given keys.
+// Deleting non-existing key-value pairs does NOT lead to an error.
+// The keys must not be "".
+func (m Store) DeleteMany(ks []string) error {
+	for _, k := range ks {
+		if err := util.CheckKey(k); err != nil {
+			return err
+		}
+	}
+
+	m.lock.Lock()
+	for _, k := range ks {
+		delete(m.m, k)
+	}
+	m.lock.Unlock()
+	for _, k := range ks {
+		m.notify(gokv.WatchEvent{Key: k, Deleted: true})
+	}
+	return nil
+}
+
+// CompareAndSwap stores newV for the given key, but only if the currently stored value equals oldV.
+// Both values are marshalled with the configured marshal format and compared byte by byte.
+// If no value is stored for the key or the stored value differs, it returns (false, nil).
+// The key must not be "" and the values must not be nil.
+func (m Store) CompareAndSwap(k string, oldV, newV interface{}) (swapped bool, err error) {
+	if err := util.CheckKeyAndValue(k, oldV); err != nil {
+		return false, err
+	}
+	if err := util.CheckVal(newV); err != nil {
+		return false, err
+	}
+
+	oldData, err := m.marshal(oldV)
+	if err != nil {
+		return false, err
+	}
+	newData, err := m.marshal(newV)
+	if err != nil {
+		return false, err
+	}
+
+	m.lock.Lock()
+	data, found := m.m[k]
+	if !found || !bytes.Equal(data, oldData) {
+		m.lock.Unlock()
+		return false, nil
+	}
+	m.m[k] = newData
+	m.lock.Unlock()
+	m.notify(gokv.WatchEvent{Key: k})
+	return true, nil
+}
+
+// Watch returns a channel that receives an event for every key-value pair
+// with a key that starts with the given prefix that is stored or deleted after the call.
+// An empty prefix matches all keys.
+// The channel is closed when the context is done.
+// Writing goroutines don't wait for receivers. Up to 64 events are buffered,
+// and when a receiver doesn't keep up and the buffer is full, the channel is closed as well.
+// If the channel is closed before the context is done, events might have been missed,
+// so the receiver should call Watch again and re-read the values it's interested in.
+func (m Store) Watch(ctx context.Context, prefix string) (<-chan gokv.WatchEvent, error) {
+	ch := make(chan gokv.WatchEvent, watchBufferSize)
+	ctx, cancel := context.WithCancel(ctx)
+	m.watchers.lock.Lock()
+	m.watchers.chans[ch] = watcher{
+		ctx:    ctx,
+		cancel: cancel,
+		prefix: prefix,
+	}
+	m.watchers.lock.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		// Acquiring the write lock guarantees that no notify() call is sending to the channel anymore.
+		m.watchers.lock.Lock()
+		delete(m.watchers.chans, ch)
+		m.watchers.lock.Unlock()
+		close(ch)
+	}()
+
+	return ch, nil
+}
+
+// notify sends the given event to all watchers with a matching prefix.
+// It doesn't block. Watchers whose buffer is full are disconnected,
+// because they would miss the event.
+// It must not be called while holding the store's lock.
+func (m Store) notify(event gokv.WatchEvent) {
+	m.watchers.lock.RLock()
+	defer m.watchers.lock.RUnlock()
+	for ch, w := range m.watchers.chans {
+		// A watcher that's being disconnected already missed an event, so it must not receive later ones
+		if !strings.HasPrefix(event.Key, w.prefix) || w.ctx.Err() != nil {
+			continue
+		}
+		select {
+		case ch <- event:
+		default:
+			// The goroutine that was started by Watch() closes the channel
+			w.cancel()
+		}
+	}
+}
+
 // Close closes the store.
 // When called, the store's pointer to the internal Go map is set to nil,
 // leading to the map being free for garbage collection.
@@ -95,6 +265,18 @@ func (m Store) Close() error {
 	return nil
 }
 
+// marshal marshals the given value according to the configured marshal format.
+func (m Store) marshal(v interface{}) ([]byte, error) {
+	switch m.marshalFormat {
+	case JSON:
+		return util.ToJSON(v)
+	case Gob:
+		return util.ToGob(v)
+	default:
+		return nil, errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	}
+}
+
 // MarshalFormat is an enum for the available (un-)marshal formats of this gokv.Store implementation.
 type MarshalFormat int
 
@@ -124,6 +306,7 @@ func NewStore(options Options) Store {
 	return Store{
 		m:             make(map[string][]byte),
 		lock:          new(sync.RWMutex),
+		watchers:      &watchers{chans: make(map[chan gokv.WatchEvent]watcher)},
 		marshalFormat: options.MarshalFormat,
 	}
 }
@@ -1,8 +1,11 @@
 package gomap_test
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	"github.com/philippgille/gokv"
 	"github.com/philippgille/gokv/gomap"
 	"github.com/philippgille/gokv/test"
 )
@@ -48,6 +51,206 @@ func TestStoreConcurrent(t *testing.T) {
 	test.TestConcurrentInteractions(t, goroutineCount, store)
 }
 
+// TestList tests if all stored keys are enumerated.
+func TestList(t *testing.T) {
+	store := createStore(t, gomap.JSON)
+	test.TestList(store, t)
+}
+
+// TestBatch tests if multiple key-value pairs can be stored and deleted at once.
+func TestBatch(t *testing.T) {
+	store := createStore(t, gomap.JSON)
+
+	kvs := map[string]interface{}{
+		"foo": test.Foo{Bar: "baz"},
+		"bar": test.Foo{Bar: "qux"},
+	}
+	err := store.SetMany(kvs)
+	if err != nil {
+		t.Error(err)
+	}
+	for k, expected := range kvs {
+		actualPtr := new(test.Foo)
+		found, err := store.Get(k, actualPtr)
+		if err != nil {
+			t.Error(err)
+		}
+		if !found {
+			t.Error("No value was found, but should have been")
+		}
+		if *actualPtr != expected {
+			t.Errorf("Expected: %v, but was: %v", expected, *actualPtr)
+		}
+	}
+
+	// An invalid key should lead to none of the values being stored
+	err = store.SetMany(map[string]interface{}{"baz": "qux", "": "qux"})
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	found, err := store.Get("baz", new(string))
+	if err != nil {
+		t.Error(err)
+	}
+	if found {
+		t.Error("A value was found, but no value was expected")
+	}
+
+	err = store.DeleteMany([]string{"foo", "bar"})
+	if err != nil {
+		t.Error(err)
+	}
+	for k := range kvs {
+		found, err := store.Get(k, new(test.Foo))
+		if err != nil {
+			t.Error(err)
+		}
+		if found {
+			t.Error("A value was found, but no value was expected")
+		}
+	}
+}
+
+// TestCompareAndSwap tests if values are only replaced when the stored value matches.
+func TestCompareAndSwap(t *testing.T) {
+	store := createStore(t, gomap.JSON)
+
+	// Non-existing key
+	swapped, err := store.CompareAndSwap("foo", test.Foo{Bar: "baz"}, test.Foo{Bar: "qux"})
+	if err != nil {
+		t.Error(err)
+	}
+	if swapped {
+		t.Error("The value was swapped, but shouldn't have been")
+	}
+
+	err = store.Set("foo", test.Foo{Bar: "baz"})
+	if err != nil {
+		t.Error(err)
+	}
+
+	// Outdated value
+	swapped, err = store.CompareAndSwap("foo", test.Foo{Bar: "qux"}, test.Foo{Bar: "quux"})
+	if err != nil {
+		t.Error(err)
+	}
+	if swapped {
+		t.Error("The value was swapped, but shouldn't have been")
+	}
+
+	// Current value
+	swapped, err = store.CompareAndSwap("foo", test.Foo{Bar: "baz"}, test.Foo{Bar: "qux"})
+	if err != nil {
+		t.Error(err)
+	}
+	if !swapped {
+		t.Error("The value wasn't swapped, but should have been")
+	}
+	actualPtr := new(test.Foo)
+	_, err = store.Get("foo", actualPtr)
+	if err != nil {
+		t.Error(err)
+	}
+	if actualPtr.Bar != "qux" {
+		t.Errorf("Expected: %v, but was: %v", "qux", actualPtr.Bar)
+	}
+}
+
+// TestWatch tests if changes are sent to watchers.
+func TestWatch(t *testing.T) {
+	store := createStore(t, gomap.JSON)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := store.Watch(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// "bar" doesn't match the prefix
+	err = store.Set("bar", "baz")
+	if err != nil {
+		t.Error(err)
+	}
+	err = store.Set("foo123", "baz")
+	if err != nil {
+		t.Error(err)
+	}
+	err = store.Delete("foo123")
+	if err != nil {
+		t.Error(err)
+	}
+
+	expected := []gokv.WatchEvent{{Key: "foo123"}, {Key: "foo123", Deleted: true}}
+	for _, expectedEvent := range expected {
+		select {
+		case event := <-events:
+			if event != expectedEvent {
+				t.Errorf("Expected: %v, but was: %v", expectedEvent, event)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("No event was received, but should have been")
+		}
+	}
+
+	// The channel must be closed after the context is canceled
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("The channel should have been closed")
+		}
+	case <-time.After(time.Second):
+		t.Error("The channel wasn't closed")
+	}
+}
+
+// TestWatchSlowReceiver tests if a watcher that doesn't read its events is disconnected
+// instead of blocking writes.
+func TestWatchSlowReceiver(t *testing.T) {
+	store := createStore(t, gomap.JSON)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := store.Watch(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 1000; i++ {
+			err := store.Set("foo", i)
+			if err != nil {
+				t.Error(err)
+			}
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Writing was blocked by the watcher")
+	}
+
+	// The buffered events can still be read, then the channel must be closed
+	count := 0
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				if count == 0 || count >= 1000 {
+					t.Errorf("Expected some, but not all events before the channel was closed, but was %v", count)
+				}
+				return
+			}
+			count++
+		case <-timeout:
+			t.Fatal("The channel wasn't closed")
+		}
+	}
+}
+
 // TestErrors tests some error cases.
 func TestErrors(t *testing.T) {
 	// Test with a bad MarshalFormat enum value
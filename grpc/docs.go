@@ -0,0 +1,8 @@
+/*
+Package grpc contains an implementation of the `gokv.Store` interface for a remote store
+that's exposed via the gRPC server in the `server/grpc` package.
+
+This allows for example to use an embedded store like bbolt or BadgerDB from multiple processes,
+by running the server in the process that opens the embedded DB and using this client in the other processes.
+*/
+package grpc
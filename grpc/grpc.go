@@ -0,0 +1,322 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/philippgille/gokv"
+	"github.com/philippgille/gokv/grpc/pb"
+	"github.com/philippgille/gokv/util"
+)
+
+var defaultTimeout = 2 * time.Second
+
+// Client is a gokv.Store implementation for a remote store that's exposed via the gRPC server in the server/grpc package.
+//
+// It also implements gokv.Lister, gokv.Batcher, gokv.CompareAndSwapper and gokv.Watcher.
+// Whether the methods of these interfaces work depends on the store that the server wraps.
+// If it doesn't implement the respective interface, an error with the code codes.Unimplemented is returned.
+type Client struct {
+	c             pb.StoreClient
+	conn          *grpc.ClientConn
+	timeOut       time.Duration
+	marshalFormat MarshalFormat
+}
+
+// Set stores the given value for the given key.
+// Values are automatically marshalled to JSON or gob (depending on the configuration).
+// The key must not be "" and the value must not be nil.
+func (c Client) Set(k string, v interface{}) error {
+	if err := util.CheckKeyAndValue(k, v); err != nil {
+		return err
+	}
+
+	// First turn the passed object into something that can be sent to the server
+	data, err := c.marshal(v)
+	if err != nil {
+		return err
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), c.timeOut)
+	defer cancel()
+	_, err = c.c.Set(ctxWithTimeout, &pb.SetRequest{
+		Key:   k,
+		Value: data,
+	})
+	return err
+}
+
+// Get retrieves the stored value for the given key.
+// You need to pass a pointer to the value, so in case of a struct
+// the automatic unmarshalling can populate the fields of the object
+// that v points to with the values of the retrieved object's values.
+// If no value is found it returns (false, nil).
+// The key must not be "" and the pointer must not be nil.
+func (c Client) Get(k string, v interface{}) (found bool, err error) {
+	if err := util.CheckKeyAndValue(k, v); err != nil {
+		return false, err
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), c.timeOut)
+	defer cancel()
+	res, err := c.c.Get(ctxWithTimeout, &pb.GetRequest{
+		Key: k,
+	})
+	if err != nil {
+		return false, err
+	}
+	// If no value was found return false
+	if !res.Found {
+		return false, nil
+	}
+
+	return true, c.unmarshal(res.Value, v)
+}
+
+// Delete deletes the stored value for the given key.
+// Deleting a non-existing key-value pair does NOT lead to an error.
+// The key must not be "".
+func (c Client) Delete(k string) error {
+	if err := util.CheckKey(k); err != nil {
+		return err
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), c.timeOut)
+	defer cancel()
+	_, err := c.c.Delete(ctxWithTimeout, &pb.DeleteRequest{
+		Key: k,
+	})
+	return err
+}
+
+// List calls fn for every key in the remote store.
+// The keys are streamed from the server, so the store can contain more keys than fit into memory.
+// If fn returns an error, the iteration is stopped and the error is returned.
+// The configured timeout doesn't apply to the whole iteration, which can take arbitrarily long.
+func (c Client) List(fn func(k string) error) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := c.c.List(ctx, &pb.ListRequest{})
+	if err != nil {
+		return err
+	}
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := fn(res.Key); err != nil {
+			return err
+		}
+	}
+}
+
+// SetMany stores the given values for the given keys.
+// Values are automatically marshalled to JSON or gob (depending on the configuration).
+// The keys must not be "" and the values must not be nil.
+func (c Client) SetMany(kvs map[string]interface{}) error {
+	dataMap := make(map[string][]byte, len(kvs))
+	for k, v := range kvs {
+		if err := util.CheckKeyAndValue(k, v); err != nil {
+			return err
+		}
+		data, err := c.marshal(v)
+		if err != nil {
+			return err
+		}
+		dataMap[k] = data
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), c.timeOut)
+	defer cancel()
+	_, err := c.c.SetMany(ctxWithTimeout, &pb.SetManyRequest{
+		Kvs: dataMap,
+	})
+	return err
+}
+
+// DeleteMany deletes the stored values for the given keys.
+// Deleting non-existing key-value pairs does NOT lead to an error.
+// The keys must not be "".
+func (c Client) DeleteMany(ks []string) error {
+	for _, k := range ks {
+		if err := util.CheckKey(k); err != nil {
+			return err
+		}
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), c.timeOut)
+	defer cancel()
+	_, err := c.c.DeleteMany(ctxWithTimeout, &pb.DeleteManyRequest{
+		Keys: ks,
+	})
+	return err
+}
+
+// CompareAndSwap stores newV for the given key, but only if the currently stored value equals oldV.
+// Both values are marshalled with the configured marshal format and compared in their marshalled form.
+// If no value is stored for the key or the stored value differs, it returns (false, nil).
+// The key must not be "" and the values must not be nil.
+func (c Client) CompareAndSwap(k string, oldV, newV interface{}) (swapped bool, err error) {
+	if err := util.CheckKeyAndValue(k, oldV); err != nil {
+		return false, err
+	}
+	if err := util.CheckVal(newV); err != nil {
+		return false, err
+	}
+
+	oldData, err := c.marshal(oldV)
+	if err != nil {
+		return false, err
+	}
+	newData, err := c.marshal(newV)
+	if err != nil {
+		return false, err
+	}
+
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), c.timeOut)
+	defer cancel()
+	res, err := c.c.CompareAndSwap(ctxWithTimeout, &pb.CompareAndSwapRequest{
+		Key:      k,
+		OldValue: oldData,
+		NewValue: newData,
+	})
+	if err != nil {
+		return false, err
+	}
+	return res.Swapped, nil
+}
+
+// Watch returns a channel that receives an event for every key-value pair
+// with a key that starts with the given prefix that is stored or deleted after the call.
+// The events are streamed from the server.
+// The channel is closed when the context is done or when the stream breaks,
+// for example because the server doesn't support watching keys or is shut down.
+func (c Client) Watch(ctx context.Context, prefix string) (<-chan gokv.WatchEvent, error) {
+	stream, err := c.c.Watch(ctx, &pb.WatchRequest{
+		Prefix: prefix,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan gokv.WatchEvent)
+	go func() {
+		defer close(events)
+		for {
+			res, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			select {
+			case events <- gokv.WatchEvent{Key: res.Key, Deleted: res.Deleted}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// Close closes the client.
+// It must be called to close the connection to the server.
+func (c Client) Close() error {
+	return c.conn.Close()
+}
+
+// marshal marshals the given value according to the configured marshal format.
+func (c Client) marshal(v interface{}) ([]byte, error) {
+	switch c.marshalFormat {
+	case JSON:
+		return util.ToJSON(v)
+	case Gob:
+		return util.ToGob(v)
+	default:
+		return nil, errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	}
+}
+
+// unmarshal unmarshals the given data according to the configured marshal format.
+func (c Client) unmarshal(data []byte, v interface{}) error {
+	switch c.marshalFormat {
+	case JSON:
+		return util.FromJSON(data, v)
+	case Gob:
+		return util.FromGob(data, v)
+	default:
+		return errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	}
+}
+
+// MarshalFormat is an enum for the available (un-)marshal formats of this gokv.Store implementation.
+type MarshalFormat int
+
+const (
+	// JSON is the MarshalFormat for (un-)marshalling to/from JSON
+	JSON MarshalFormat = iota
+	// Gob is the MarshalFormat for (un-)marshalling to/from gob
+	Gob
+)
+
+// Options are the options for the gRPC client.
+type Options struct {
+	// Address of the gRPC server, including the port.
+	// Optional ("localhost:8100" by default).
+	Address string
+	// The timeout for operations.
+	// Optional (2 * time.Second by default).
+	Timeout *time.Duration
+	// Options for dialing the server, for example grpc.WithTransportCredentials() for TLS
+	// or grpc.WithDialer() for a custom connection.
+	// Optional ([]grpc.DialOption{grpc.WithInsecure()} by default).
+	DialOptions []grpc.DialOption
+	// (Un-)marshal format.
+	// Optional (JSON by default).
+	MarshalFormat MarshalFormat
+}
+
+// DefaultOptions is an Options object with default values.
+// Address: "localhost:8100", Timeout: 2 * time.Second, DialOptions: grpc.WithInsecure(), MarshalFormat: JSON
+var DefaultOptions = Options{
+	Address:     "localhost:8100",
+	Timeout:     &defaultTimeout,
+	DialOptions: []grpc.DialOption{grpc.WithInsecure()},
+	// No need to set MarshalFormat to JSON because its zero value is fine.
+}
+
+// NewClient creates a new gRPC client.
+// The client connects to the server in the background,
+// so the returned error doesn't indicate whether the server is reachable.
+func NewClient(options Options) (Client, error) {
+	result := Client{}
+
+	// Set default values
+	if options.Address == "" {
+		options.Address = DefaultOptions.Address
+	}
+	if options.Timeout == nil {
+		options.Timeout = DefaultOptions.Timeout
+	}
+	if len(options.DialOptions) == 0 {
+		options.DialOptions = DefaultOptions.DialOptions
+	}
+
+	conn, err := grpc.Dial(options.Address, options.DialOptions...)
+	if err != nil {
+		return result, err
+	}
+
+	result = Client{
+		c:             pb.NewStoreClient(conn),
+		conn:          conn,
+		timeOut:       *options.Timeout,
+		marshalFormat: options.MarshalFormat,
+	}
+	return result, nil
+}
@@ -0,0 +1,288 @@
+package grpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/philippgille/gokv"
+	"github.com/philippgille/gokv/gomap"
+	"github.com/philippgille/gokv/grpc"
+	grpcserver "github.com/philippgille/gokv/server/grpc"
+	"github.com/philippgille/gokv/syncmap"
+	"github.com/philippgille/gokv/test"
+)
+
+// TestClient tests if reading from, writing to and deleting from the store works properly.
+// A struct is used as value. See TestTypes() for a test that is simpler but tests all types.
+func TestClient(t *testing.T) {
+	// Test with JSON
+	t.Run("JSON", func(t *testing.T) {
+		client := createClient(t, gomap.NewStore(gomap.DefaultOptions), grpc.JSON)
+		test.TestStore(client, t)
+	})
+
+	// Test with gob
+	t.Run("gob", func(t *testing.T) {
+		client := createClient(t, gomap.NewStore(gomap.DefaultOptions), grpc.Gob)
+		test.TestStore(client, t)
+	})
+}
+
+// TestTypes tests if setting and getting values works with all Go types.
+func TestTypes(t *testing.T) {
+	// Test with JSON
+	t.Run("JSON", func(t *testing.T) {
+		client := createClient(t, gomap.NewStore(gomap.DefaultOptions), grpc.JSON)
+		test.TestTypes(client, t)
+	})
+
+	// Test with gob
+	t.Run("gob", func(t *testing.T) {
+		client := createClient(t, gomap.NewStore(gomap.DefaultOptions), grpc.Gob)
+		test.TestTypes(client, t)
+	})
+}
+
+// TestClientConcurrent launches a bunch of goroutines that concurrently work with the gRPC client.
+func TestClientConcurrent(t *testing.T) {
+	client := createClient(t, gomap.NewStore(gomap.DefaultOptions), grpc.JSON)
+
+	goroutineCount := 1000
+
+	test.TestConcurrentInteractions(t, goroutineCount, client)
+}
+
+// TestList tests if the keys are streamed from the server.
+func TestList(t *testing.T) {
+	client := createClient(t, gomap.NewStore(gomap.DefaultOptions), grpc.JSON)
+	test.TestList(client, t)
+}
+
+// TestBatch tests if multiple key-value pairs can be stored and deleted at once.
+func TestBatch(t *testing.T) {
+	client := createClient(t, gomap.NewStore(gomap.DefaultOptions), grpc.JSON)
+
+	err := client.SetMany(map[string]interface{}{
+		"foo": test.Foo{Bar: "baz"},
+		"bar": test.Foo{Bar: "qux"},
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	actualPtr := new(test.Foo)
+	found, err := client.Get("bar", actualPtr)
+	if err != nil {
+		t.Error(err)
+	}
+	if !found {
+		t.Error("No value was found, but should have been")
+	}
+	if actualPtr.Bar != "qux" {
+		t.Errorf("Expected: %v, but was: %v", "qux", actualPtr.Bar)
+	}
+
+	err = client.DeleteMany([]string{"foo", "bar"})
+	if err != nil {
+		t.Error(err)
+	}
+	found, err = client.Get("bar", new(test.Foo))
+	if err != nil {
+		t.Error(err)
+	}
+	if found {
+		t.Error("A value was found, but no value was expected")
+	}
+}
+
+// TestCompareAndSwap tests if values are only replaced when the stored value matches.
+func TestCompareAndSwap(t *testing.T) {
+	client := createClient(t, gomap.NewStore(gomap.DefaultOptions), grpc.JSON)
+
+	err := client.Set("foo", test.Foo{Bar: "baz"})
+	if err != nil {
+		t.Error(err)
+	}
+	swapped, err := client.CompareAndSwap("foo", test.Foo{Bar: "qux"}, test.Foo{Bar: "quux"})
+	if err != nil {
+		t.Error(err)
+	}
+	if swapped {
+		t.Error("The value was swapped, but shouldn't have been")
+	}
+	swapped, err = client.CompareAndSwap("foo", test.Foo{Bar: "baz"}, test.Foo{Bar: "qux"})
+	if err != nil {
+		t.Error(err)
+	}
+	if !swapped {
+		t.Error("The value wasn't swapped, but should have been")
+	}
+}
+
+// TestWatch tests if changes are streamed from the server.
+func TestWatch(t *testing.T) {
+	client := createClient(t, gomap.NewStore(gomap.DefaultOptions), grpc.JSON)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := client.Watch(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The stream is established asynchronously, so wait a bit before making changes
+	time.Sleep(100 * time.Millisecond)
+
+	err = client.Set("bar", "baz")
+	if err != nil {
+		t.Error(err)
+	}
+	err = client.Set("foo123", "baz")
+	if err != nil {
+		t.Error(err)
+	}
+
+	select {
+	case event := <-events:
+		expected := gokv.WatchEvent{Key: "foo123"}
+		if event != expected {
+			t.Errorf("Expected: %v, but was: %v", expected, event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("No event was received, but should have been")
+	}
+}
+
+// TestUnimplemented tests if the optional methods return an error
+// when the store that the server wraps doesn't implement the respective interface.
+func TestUnimplemented(t *testing.T) {
+	// syncmap.Store doesn't implement gokv.Batcher
+	client := createClient(t, syncmap.NewStore(syncmap.DefaultOptions), grpc.JSON)
+
+	err := client.DeleteMany([]string{"foo"})
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("Expected an error with code %v, but was: %v", codes.Unimplemented, err)
+	}
+}
+
+// TestErrors tests some error cases.
+func TestErrors(t *testing.T) {
+	// Test with a bad MarshalFormat enum value
+
+	client := createClient(t, gomap.NewStore(gomap.DefaultOptions), grpc.MarshalFormat(19))
+	err := client.Set("foo", "bar")
+	if err == nil {
+		t.Error("An error should have occurred, but didn't")
+	}
+
+	// Test empty key
+	err = client.Set("", "bar")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	_, err = client.Get("", new(string))
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	err = client.Delete("")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+// TestNil tests the behaviour when passing nil or pointers to nil values to some methods.
+func TestNil(t *testing.T) {
+	// Test setting nil
+
+	t.Run("set nil with JSON marshalling", func(t *testing.T) {
+		client := createClient(t, gomap.NewStore(gomap.DefaultOptions), grpc.JSON)
+		err := client.Set("foo", nil)
+		if err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	t.Run("set nil with Gob marshalling", func(t *testing.T) {
+		client := createClient(t, gomap.NewStore(gomap.DefaultOptions), grpc.Gob)
+		err := client.Set("foo", nil)
+		if err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	// Test passing nil or pointer to nil value for retrieval
+
+	createTest := func(mf grpc.MarshalFormat) func(t *testing.T) {
+		return func(t *testing.T) {
+			client := createClient(t, gomap.NewStore(gomap.DefaultOptions), mf)
+
+			// Prep
+			err := client.Set("foo", test.Foo{Bar: "baz"})
+			if err != nil {
+				t.Error(err)
+			}
+
+			_, err = client.Get("foo", nil) // actually nil
+			if err == nil {
+				t.Error("An error was expected")
+			}
+
+			var i interface{} // actually nil
+			_, err = client.Get("foo", i)
+			if err == nil {
+				t.Error("An error was expected")
+			}
+
+			var valPtr *test.Foo // nil value
+			_, err = client.Get("foo", valPtr)
+			if err == nil {
+				t.Error("An error was expected")
+			}
+		}
+	}
+	t.Run("get with nil / nil value parameter", createTest(grpc.JSON))
+	t.Run("get with nil / nil value parameter", createTest(grpc.Gob))
+}
+
+// TestClose tests if the close method returns any errors.
+func TestClose(t *testing.T) {
+	client := createClient(t, gomap.NewStore(gomap.DefaultOptions), grpc.JSON)
+	err := client.Close()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// createClient starts a gRPC server for the given store that listens on an in-memory connection
+// and returns a client that's connected to it.
+func createClient(t *testing.T, store gokv.Store, mf grpc.MarshalFormat) grpc.Client {
+	server, err := grpcserver.NewServer(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := gogrpc.NewServer()
+	server.Register(grpcServer)
+	go grpcServer.Serve(listener)
+
+	options := grpc.Options{
+		Address: "bufconn",
+		DialOptions: []gogrpc.DialOption{
+			gogrpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+				return listener.Dial()
+			}),
+			gogrpc.WithInsecure(),
+		},
+		MarshalFormat: mf,
+	}
+	client, err := grpc.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
@@ -0,0 +1,11 @@
+/*
+Package pb contains the Go types for the gRPC service defined in `gokv.proto`.
+
+It's used by the `grpc` package (client) and the `server/grpc` package (server).
+The code is generated from `gokv.proto` with protoc, protoc-gen-go and protoc-gen-go-grpc.
+After changing `gokv.proto`, run `go generate ./grpc/pb` to regenerate it.
+Other clients can use code generated from `gokv.proto` to talk to the server.
+*/
+package pb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative,require_unimplemented_servers=false gokv.proto
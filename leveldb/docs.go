@@ -0,0 +1,8 @@
+/*
+Package leveldb contains an implementation of the `gokv.Store` interface for LevelDB.
+
+It uses goleveldb (https://github.com/syndtr/goleveldb), a pure Go implementation of LevelDB.
+LevelDB uses an LSM tree, which generally means that it's very fast for write operations,
+and unlike BadgerDB it doesn't require a separate garbage collection of the values.
+*/
+package leveldb
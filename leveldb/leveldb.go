@@ -0,0 +1,195 @@
+package leveldb
+
+import (
+	"errors"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	leveldbutil "github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/philippgille/gokv/util"
+)
+
+// Store is a gokv.Store implementation for LevelDB.
+// It also implements gokv.Lister.
+type Store struct {
+	db            *leveldb.DB
+	writeOptions  *opt.WriteOptions
+	marshalFormat MarshalFormat
+}
+
+// Set stores the given value for the given key.
+// Values are automatically marshalled to JSON or gob (depending on the configuration).
+// The key must not be "" and the value must not be nil.
+func (c Store) Set(k string, v interface{}) error {
+	if err := util.CheckKeyAndValue(k, v); err != nil {
+		return err
+	}
+
+	// First turn the passed object into something that LevelDB can handle
+	var data []byte
+	var err error
+	switch c.marshalFormat {
+	case JSON:
+		data, err = util.ToJSON(v)
+	case Gob:
+		data, err = util.ToGob(v)
+	default:
+		err = errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.db.Put([]byte(k), data, c.writeOptions)
+}
+
+// Get retrieves the stored value for the given key.
+// You need to pass a pointer to the value, so in case of a struct
+// the automatic unmarshalling can populate the fields of the object
+// that v points to with the values of the retrieved object's values.
+// If no value is found it returns (false, nil).
+// The key must not be "" and the pointer must not be nil.
+func (c Store) Get(k string, v interface{}) (found bool, err error) {
+	if err := util.CheckKeyAndValue(k, v); err != nil {
+		return false, err
+	}
+
+	// The returned slice is a copy, so it's safe to use it after the call.
+	data, err := c.db.Get([]byte(k), nil)
+	// If no value was found return false
+	if err == leveldb.ErrNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	switch c.marshalFormat {
+	case JSON:
+		return true, util.FromJSON(data, v)
+	case Gob:
+		return true, util.FromGob(data, v)
+	default:
+		return true, errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	}
+}
+
+// Delete deletes the stored value for the given key.
+// Deleting a non-existing key-value pair does NOT lead to an error.
+// The key must not be "".
+func (c Store) Delete(k string) error {
+	if err := util.CheckKey(k); err != nil {
+		return err
+	}
+
+	return c.db.Delete([]byte(k), c.writeOptions)
+}
+
+// List calls fn for every key in the store, in lexicographical order.
+// The iteration works on a snapshot of the DB, so key-value pairs that are stored or deleted
+// during the iteration are not included, and fn can modify the store.
+// If fn returns an error, the iteration is stopped and the error is returned.
+func (c Store) List(fn func(k string) error) error {
+	iter := c.db.NewIterator(nil, nil)
+	defer iter.Release()
+	for iter.Next() {
+		// Converting to a string copies the key, which is only valid until the next call of Next().
+		if err := fn(string(iter.Key())); err != nil {
+			return err
+		}
+	}
+	return iter.Error()
+}
+
+// Compact compacts the whole DB, which removes deleted and overwritten values from the DB files.
+// LevelDB compacts the DB automatically in the background, so this is only required
+// to reclaim disk space immediately, for example after deleting many key-value pairs.
+func (c Store) Compact() error {
+	return c.db.CompactRange(leveldbutil.Range{})
+}
+
+// Close closes the store.
+// It must be called to make sure that all pending updates make their way to disk
+// and to release the lock on the DB directory.
+func (c Store) Close() error {
+	return c.db.Close()
+}
+
+// MarshalFormat is an enum for the available (un-)marshal formats of this gokv.Store implementation.
+type MarshalFormat int
+
+const (
+	// JSON is the MarshalFormat for (un-)marshalling to/from JSON
+	JSON MarshalFormat = iota
+	// Gob is the MarshalFormat for (un-)marshalling to/from gob
+	Gob
+)
+
+// Options are the options for the LevelDB store.
+type Options struct {
+	// Directory for storing the DB files.
+	// Optional ("LevelDB" by default).
+	Dir string
+	// Size of the cache for uncompressed data blocks, in bytes.
+	// A larger cache speeds up reading frequently read values.
+	// Optional (0 by default, which leads to goleveldb's default of 8 MiB).
+	CacheSize int
+	// Size of the in-memory buffer for writes, in bytes.
+	// When it's full, its content is written to a sorted file on disk.
+	// A larger buffer speeds up bulk writes, but also leads to a longer recovery when opening the DB after a crash.
+	// Optional (0 by default, which leads to goleveldb's default of 4 MiB).
+	WriteBufferSize int
+	// Number of files in the first level of the LSM tree that trigger a compaction.
+	// Higher values reduce the write amplification, but slow down reads.
+	// Optional (0 by default, which leads to goleveldb's default of 4).
+	CompactionL0Trigger int
+	// Flush every write to disk before Set() and Delete() return.
+	// Without it a stored value can be lost in case of a power loss or operating system crash,
+	// but not when only the process crashes.
+	// Optional (false by default).
+	SyncWrites bool
+	// (Un-)marshal format.
+	// Optional (JSON by default).
+	MarshalFormat MarshalFormat
+}
+
+// DefaultOptions is an Options object with default values.
+// Dir: "LevelDB", CacheSize: 0, WriteBufferSize: 0, CompactionL0Trigger: 0 (goleveldb's defaults), SyncWrites: false, MarshalFormat: JSON
+var DefaultOptions = Options{
+	Dir: "LevelDB",
+	// No need to set the other fields because their zero values lead to goleveldb's defaults.
+}
+
+// NewStore creates a new LevelDB store.
+// Note: LevelDB uses an exclusive lock on the database directory so it cannot be shared by multiple processes.
+// So when creating multiple stores you should always use a new database directory (by setting a different Dir in the options).
+func NewStore(options Options) (Store, error) {
+	result := Store{}
+
+	// Set default values
+	if options.Dir == "" {
+		options.Dir = DefaultOptions.Dir
+	}
+
+	// Open the LevelDB database located in the options.Dir directory.
+	// It will be created if it doesn't exist.
+	opts := &opt.Options{
+		BlockCacheCapacity:  options.CacheSize,
+		WriteBuffer:         options.WriteBufferSize,
+		CompactionL0Trigger: options.CompactionL0Trigger,
+	}
+	db, err := leveldb.OpenFile(options.Dir, opts)
+	if err != nil {
+		return result, err
+	}
+
+	result = Store{
+		db: db,
+		writeOptions: &opt.WriteOptions{
+			Sync: options.SyncWrites,
+		},
+		marshalFormat: options.MarshalFormat,
+	}
+
+	return result, nil
+}
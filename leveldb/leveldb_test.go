@@ -0,0 +1,188 @@
+package leveldb_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/philippgille/gokv/leveldb"
+	"github.com/philippgille/gokv/test"
+)
+
+// TestStore tests if reading from, writing to and deleting from the store works properly.
+// A struct is used as value. See TestTypes() for a test that is simpler but tests all types.
+func TestStore(t *testing.T) {
+	// Test with JSON
+	t.Run("JSON", func(t *testing.T) {
+		store := createStore(t, leveldb.JSON)
+		test.TestStore(store, t)
+	})
+
+	// Test with gob
+	t.Run("gob", func(t *testing.T) {
+		store := createStore(t, leveldb.Gob)
+		test.TestStore(store, t)
+	})
+}
+
+// TestTypes tests if setting and getting values works with all Go types.
+func TestTypes(t *testing.T) {
+	// Test with JSON
+	t.Run("JSON", func(t *testing.T) {
+		store := createStore(t, leveldb.JSON)
+		test.TestTypes(store, t)
+	})
+
+	// Test with gob
+	t.Run("gob", func(t *testing.T) {
+		store := createStore(t, leveldb.Gob)
+		test.TestTypes(store, t)
+	})
+}
+
+// TestStoreConcurrent launches a bunch of goroutines that concurrently work with one store.
+// The locking is implemented in the goleveldb package, but test it nonetheless.
+func TestStoreConcurrent(t *testing.T) {
+	store := createStore(t, leveldb.JSON)
+
+	goroutineCount := 1000
+
+	test.TestConcurrentInteractions(t, goroutineCount, store)
+}
+
+// TestList tests if all keys are listed.
+func TestList(t *testing.T) {
+	store := createStore(t, leveldb.JSON)
+	test.TestList(store, t)
+}
+
+// TestOptions tests if the store works with tuned options and if compacting works.
+func TestOptions(t *testing.T) {
+	options := leveldb.Options{
+		Dir:                 generateRandomTempDBpath(t),
+		CacheSize:           16 * 1024 * 1024,
+		WriteBufferSize:     1024 * 1024,
+		CompactionL0Trigger: 8,
+		SyncWrites:          true,
+	}
+	store, err := leveldb.NewStore(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	test.TestStore(store, t)
+
+	err = store.Compact()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// TestErrors tests some error cases.
+func TestErrors(t *testing.T) {
+	// Test with a bad MarshalFormat enum value
+
+	store := createStore(t, leveldb.MarshalFormat(19))
+	err := store.Set("foo", "bar")
+	if err == nil {
+		t.Error("An error should have occurred, but didn't")
+	}
+
+	// Test empty key
+	err = store.Set("", "bar")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	_, err = store.Get("", new(string))
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	err = store.Delete("")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+// TestNil tests the behaviour when passing nil or pointers to nil values to some methods.
+func TestNil(t *testing.T) {
+	// Test setting nil
+
+	t.Run("set nil with JSON marshalling", func(t *testing.T) {
+		store := createStore(t, leveldb.JSON)
+		err := store.Set("foo", nil)
+		if err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	t.Run("set nil with Gob marshalling", func(t *testing.T) {
+		store := createStore(t, leveldb.Gob)
+		err := store.Set("foo", nil)
+		if err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	// Test passing nil or pointer to nil value for retrieval
+
+	createTest := func(mf leveldb.MarshalFormat) func(t *testing.T) {
+		return func(t *testing.T) {
+			store := createStore(t, mf)
+
+			// Prep
+			err := store.Set("foo", test.Foo{Bar: "baz"})
+			if err != nil {
+				t.Error(err)
+			}
+
+			_, err = store.Get("foo", nil) // actually nil
+			if err == nil {
+				t.Error("An error was expected")
+			}
+
+			var i interface{} // actually nil
+			_, err = store.Get("foo", i)
+			if err == nil {
+				t.Error("An error was expected")
+			}
+
+			var valPtr *test.Foo // nil value
+			_, err = store.Get("foo", valPtr)
+			if err == nil {
+				t.Error("An error was expected")
+			}
+		}
+	}
+	t.Run("get with nil / nil value parameter", createTest(leveldb.JSON))
+	t.Run("get with nil / nil value parameter", createTest(leveldb.Gob))
+}
+
+// TestClose tests if the close method returns any errors.
+func TestClose(t *testing.T) {
+	store := createStore(t, leveldb.JSON)
+	err := store.Close()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func createStore(t *testing.T, mf leveldb.MarshalFormat) leveldb.Store {
+	options := leveldb.Options{
+		Dir:           generateRandomTempDBpath(t),
+		MarshalFormat: mf,
+	}
+	store, err := leveldb.NewStore(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func generateRandomTempDBpath(t *testing.T) string {
+	path, err := ioutil.TempDir(os.TempDir(), "LevelDB")
+	if err != nil {
+		t.Fatalf("Generating random DB path failed: %v", err)
+	}
+	return path
+}
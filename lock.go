@@ -0,0 +1,137 @@
+package gokv
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// DefaultLockTTL is the time after which a lock expires when it's not renewed,
+// for example because the process that held it crashed.
+// Locks are renewed three times within this time.
+const DefaultLockTTL = 15 * time.Second
+
+// TTLLockBackend is the interface that stores must implement to build locks with AcquireTTLLock.
+// The methods must be atomic.
+// All clients that use the same lock must have roughly synchronized clocks if the store compares expiry times itself.
+type TTLLockBackend interface {
+	// TryLock stores the token for the lock with the given name,
+	// but only if the lock doesn't exist or is expired.
+	// The lock must expire after the given TTL.
+	TryLock(name, token string, ttl time.Duration) (acquired bool, err error)
+	// RefreshLock resets the expiry of the lock to the given TTL,
+	// but only if the lock still holds the given token.
+	RefreshLock(name, token string, ttl time.Duration) (refreshed bool, err error)
+	// ReleaseLock deletes the lock, but only if it still holds the given token.
+	// Releasing a lock that doesn't exist or holds another token does NOT lead to an error.
+	ReleaseLock(name, token string) error
+}
+
+// AcquireTTLLock acquires a lock for stores that don't have native lock support,
+// but support create-only writes with a TTL.
+// It's meant to be used by gokv.Locker implementations.
+// While the lock is held, it's refreshed in the background every third of the TTL.
+// The lock is lost if it can't be refreshed before the TTL elapses.
+// It blocks until the lock is acquired or the context is done.
+func AcquireTTLLock(ctx context.Context, backend TTLLockBackend, name string, ttl time.Duration) (Lock, error) {
+	token, err := newLockToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// Retry often enough for a quick handover, but don't flood the store
+	retryInterval := ttl / 10
+	if retryInterval > 250*time.Millisecond {
+		retryInterval = 250 * time.Millisecond
+	}
+	for {
+		acquired, err := backend.TryLock(name, token, ttl)
+		if err != nil {
+			return nil, err
+		}
+		if acquired {
+			break
+		}
+		select {
+		case <-time.After(retryInterval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	l := &ttlLock{
+		backend: backend,
+		name:    name,
+		token:   token,
+		ttl:     ttl,
+		lost:    make(chan struct{}),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go l.refresh(time.Now())
+	return l, nil
+}
+
+// ttlLock is a Lock that's acquired with AcquireTTLLock.
+type ttlLock struct {
+	backend    TTLLockBackend
+	name       string
+	token      string
+	ttl        time.Duration
+	lost       chan struct{}
+	stop       chan struct{}
+	stopped    chan struct{}
+	unlockOnce sync.Once
+}
+
+// refresh refreshes the lock until it's unlocked or lost.
+func (l *ttlLock) refresh(lastRefresh time.Time) {
+	defer close(l.stopped)
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-l.stop:
+			return
+		}
+		refreshed, err := l.backend.RefreshLock(l.name, l.token, l.ttl)
+		if err == nil && refreshed {
+			lastRefresh = time.Now()
+			continue
+		}
+		// Errors might be temporary, so the lock is only lost when it expired in the meantime
+		// or if it's held by someone else.
+		if err == nil || time.Since(lastRefresh) >= l.ttl {
+			close(l.lost)
+			return
+		}
+	}
+}
+
+// Unlock releases the lock and stops its renewal.
+func (l *ttlLock) Unlock() error {
+	var err error
+	l.unlockOnce.Do(func() {
+		close(l.stop)
+		<-l.stopped
+		err = l.backend.ReleaseLock(l.name, l.token)
+	})
+	return err
+}
+
+// Lost returns a channel that is closed when the lock was lost.
+func (l *ttlLock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// newLockToken returns a random token that identifies the holder of a lock.
+func newLockToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
@@ -0,0 +1,143 @@
+package gokv_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/philippgille/gokv"
+	"github.com/philippgille/gokv/gomap"
+	"github.com/philippgille/gokv/test"
+)
+
+// TestTTLLock tests if locks that are acquired with AcquireTTLLock provide mutual exclusion.
+func TestTTLLock(t *testing.T) {
+	store := ttlLockStore{
+		Store:   gomap.NewStore(gomap.DefaultOptions),
+		backend: newMemoryLockBackend(),
+		ttl:     300 * time.Millisecond,
+	}
+	test.TestLocker(store, t)
+}
+
+// TestTTLLockRefresh tests if a lock is refreshed while it's held, so that it doesn't expire.
+func TestTTLLockRefresh(t *testing.T) {
+	backend := newMemoryLockBackend()
+	ttl := 100 * time.Millisecond
+	lock, err := gokv.AcquireTTLLock(context.Background(), backend, "foo", ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Unlock()
+
+	time.Sleep(3 * ttl)
+	acquired, err := backend.TryLock("foo", "other", ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acquired {
+		t.Error("The lock expired, but should have been refreshed")
+	}
+	select {
+	case <-lock.Lost():
+		t.Error("The lock was lost")
+	default:
+	}
+}
+
+// TestTTLLockLost tests if the Lost channel is closed when the lock is held by someone else.
+func TestTTLLockLost(t *testing.T) {
+	backend := newMemoryLockBackend()
+	ttl := 100 * time.Millisecond
+	lock, err := gokv.AcquireTTLLock(context.Background(), backend, "foo", ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Unlock()
+
+	// Simulate an expiry and someone else acquiring the lock
+	backend.steal("foo", "other", ttl)
+
+	select {
+	case <-lock.Lost():
+	case <-time.After(2 * ttl):
+		t.Error("The lock should have been lost")
+	}
+	// Unlocking must not release the lock of the other holder
+	err = lock.Unlock()
+	if err != nil {
+		t.Error(err)
+	}
+	acquired, err := backend.TryLock("foo", "third", ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acquired {
+		t.Error("The lock of the other holder was released")
+	}
+}
+
+// ttlLockStore is a gokv.Store with a gokv.Locker implementation that uses AcquireTTLLock.
+type ttlLockStore struct {
+	gokv.Store
+	backend gokv.TTLLockBackend
+	ttl     time.Duration
+}
+
+func (s ttlLockStore) Lock(ctx context.Context, name string) (gokv.Lock, error) {
+	return gokv.AcquireTTLLock(ctx, s.backend, name, s.ttl)
+}
+
+type memoryLock struct {
+	token  string
+	expiry time.Time
+}
+
+// memoryLockBackend is a gokv.TTLLockBackend that holds the locks in memory.
+type memoryLockBackend struct {
+	lock  *sync.Mutex
+	locks map[string]memoryLock
+}
+
+func newMemoryLockBackend() memoryLockBackend {
+	return memoryLockBackend{
+		lock:  &sync.Mutex{},
+		locks: make(map[string]memoryLock),
+	}
+}
+
+func (b memoryLockBackend) TryLock(name, token string, ttl time.Duration) (bool, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if l, ok := b.locks[name]; ok && time.Now().Before(l.expiry) {
+		return false, nil
+	}
+	b.locks[name] = memoryLock{token: token, expiry: time.Now().Add(ttl)}
+	return true, nil
+}
+
+func (b memoryLockBackend) RefreshLock(name, token string, ttl time.Duration) (bool, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if l, ok := b.locks[name]; !ok || l.token != token {
+		return false, nil
+	}
+	b.locks[name] = memoryLock{token: token, expiry: time.Now().Add(ttl)}
+	return true, nil
+}
+
+func (b memoryLockBackend) ReleaseLock(name, token string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if l, ok := b.locks[name]; ok && l.token == token {
+		delete(b.locks, name)
+	}
+	return nil
+}
+
+func (b memoryLockBackend) steal(name, token string, ttl time.Duration) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.locks[name] = memoryLock{token: token, expiry: time.Now().Add(ttl)}
+}
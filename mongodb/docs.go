@@ -3,5 +3,20 @@ Package mongodb contains an implementation of the `gokv.Store` interface for Mon
 
 Note: If you use a sharded cluster, you must use "_id" as the shard key!
 You should also use hashed sharding as opposed to ranged sharding to enable more evenly distributed data no matter how your key looks like.
+
+By default every value is marshalled and stored as binary data in the field "v" of a document, with the key as "_id".
+With the NativeDocuments option, maps (with string keys) and structs are stored as embedded BSON document in "v" instead,
+so their fields can be queried and indexed.
+The conversion is done by mgo's bson package, which uses the lowercased struct field names as document field names by default.
+They can be changed with a `bson:"name"` tag. Other values, like strings and numbers, are still stored as binary data.
+This also applies to maps and structs that contain map keys which aren't valid field names,
+which are empty keys and keys that contain "." or start with "$".
+
+Client.Find() retrieves all values whose fields match a filter, for example:
+
+	var values []Foo
+	keys, err := client.Find(map[string]interface{}{"count": bson.M{"$gt": 5}}, &values)
+
+To speed up queries, you can create an index for the queried fields of the embedded document, for example "v.count".
 */
 package mongodb
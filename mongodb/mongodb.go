@@ -2,6 +2,7 @@ package mongodb
 
 import (
 	"errors"
+	"reflect"
 	"time"
 
 	"github.com/globalsign/mgo"
@@ -16,6 +17,7 @@ import (
 // Having the gokv package user's value marshalled by ourselves allows any value to be used,
 // so the MongoDB implementation works the same as any other gokv.Store implementation.
 // See https://github.com/globalsign/mgo/blob/113d3961e7311526535a1ef7042196563d442761/bson/bson.go#L538.
+// With the NativeDocuments option, maps and structs are stored as embedded documents instead, see nativeItem.
 type item struct {
 	// There are advantages and disavantages regarding the use of a string as "_id" instead of MongoDB's default ObjectId.
 	// We can't use the ObjectId because we only have the key that the gokv package user passes us as parameter.
@@ -40,29 +42,30 @@ type item struct {
 type Client struct {
 	c *mgo.Collection
 	// Only needed for closing.
-	session       *mgo.Session
-	marshalFormat MarshalFormat
+	session         *mgo.Session
+	nativeDocuments bool
+	marshalFormat   MarshalFormat
 }
 
 // Set stores the given value for the given key.
 // Values are automatically marshalled to JSON or gob (depending on the configuration).
+// With the NativeDocuments option, maps and structs are stored as embedded documents instead (see the package documentation).
 // The key must not be "" and the value must not be nil.
 func (c Client) Set(k string, v interface{}) error {
 	if err := util.CheckKeyAndValue(k, v); err != nil {
 		return err
 	}
 
-	// First turn the passed object into something that MongoDB can handle
-	var data []byte
-	var err error
-	switch c.marshalFormat {
-	case JSON:
-		data, err = util.ToJSON(v)
-	case Gob:
-		data, err = util.ToGob(v)
-	default:
-		err = errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	if c.nativeDocuments && isNativeStorable(v) {
+		if val := reflect.ValueOf(v); val.Kind() == reflect.Ptr && val.IsNil() {
+			return errors.New("The value must not be a nil pointer")
+		}
+		_, err := c.c.UpsertId(k, nativeItem{K: k, V: v})
+		return err
 	}
+
+	// First turn the passed object into something that MongoDB can handle
+	data, err := c.marshal(v)
 	if err != nil {
 		return err
 	}
@@ -85,6 +88,7 @@ func (c Client) Set(k string, v interface{}) error {
 // You need to pass a pointer to the value, so in case of a struct
 // the automatic unmarshalling can populate the fields of the object
 // that v points to with the values of the retrieved object's values.
+// Values that are stored as embedded documents are retrieved as well, independent of the NativeDocuments option.
 // If no value is found it returns (false, nil).
 // The key must not be "" and the pointer must not be nil.
 func (c Client) Get(k string, v interface{}) (found bool, err error) {
@@ -92,7 +96,7 @@ func (c Client) Get(k string, v interface{}) (found bool, err error) {
 		return false, err
 	}
 
-	item := new(item)
+	item := new(rawItem)
 	err = c.c.FindId(k).One(item)
 	// If no value was found return false
 	if err == mgo.ErrNotFound {
@@ -100,16 +104,8 @@ func (c Client) Get(k string, v interface{}) (found bool, err error) {
 	} else if err != nil {
 		return false, err
 	}
-	data := item.V
 
-	switch c.marshalFormat {
-	case JSON:
-		return true, util.FromJSON(data, v)
-	case Gob:
-		return true, util.FromGob(data, v)
-	default:
-		return true, errors.New("The store seems to be configured with a marshal format that's not implemented yet")
-	}
+	return true, c.decode(item.V, v)
 }
 
 // Delete deletes the stored value for the given key.
@@ -127,6 +123,30 @@ func (c Client) Delete(k string) error {
 	return nil
 }
 
+// marshal marshals the given value according to the configured marshal format.
+func (c Client) marshal(v interface{}) ([]byte, error) {
+	switch c.marshalFormat {
+	case JSON:
+		return util.ToJSON(v)
+	case Gob:
+		return util.ToGob(v)
+	default:
+		return nil, errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	}
+}
+
+// unmarshal unmarshals the given data according to the configured marshal format.
+func (c Client) unmarshal(data []byte, v interface{}) error {
+	switch c.marshalFormat {
+	case JSON:
+		return util.FromJSON(data, v)
+	case Gob:
+		return util.FromGob(data, v)
+	default:
+		return errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	}
+}
+
 // Close closes the client.
 // It must be called to release any open resources.
 func (c Client) Close() error {
@@ -144,6 +164,67 @@ const (
 	Gob
 )
 
+// ReadPreference is an enum for the MongoDB read preference modes, which determine the replica set members that reads are sent to.
+// Writes are always sent to the primary.
+// See https://github.com/mongodb/docs/blob/01fa14decadc116b09ecdeae049e6744f16bf97f/source/core/read-preference.txt.
+type ReadPreference int
+
+const (
+	// Primary is the ReadPreference for reading from the primary only, which guarantees to read the latest writes.
+	Primary ReadPreference = iota
+	// PrimaryPreferred is the ReadPreference for reading from the primary,
+	// or from a secondary if the primary is unavailable.
+	PrimaryPreferred
+	// Secondary is the ReadPreference for reading from secondaries only.
+	// Reads can return stale data.
+	Secondary
+	// SecondaryPreferred is the ReadPreference for reading from secondaries,
+	// or from the primary if no secondary is available.
+	// Reads can return stale data.
+	SecondaryPreferred
+	// Nearest is the ReadPreference for reading from the member with the lowest network latency,
+	// no matter if it's the primary or a secondary.
+	// Reads can return stale data.
+	Nearest
+)
+
+// mode returns the mgo session mode for the read preference.
+func (r ReadPreference) mode() (mgo.Mode, error) {
+	switch r {
+	case Primary:
+		return mgo.Primary, nil
+	case PrimaryPreferred:
+		return mgo.PrimaryPreferred, nil
+	case Secondary:
+		return mgo.Secondary, nil
+	case SecondaryPreferred:
+		return mgo.SecondaryPreferred, nil
+	case Nearest:
+		return mgo.Nearest, nil
+	default:
+		return 0, errors.New("The ReadPreference is invalid")
+	}
+}
+
+// WriteConcern determines which acknowledgement MongoDB must give before a write is considered successful.
+// See https://github.com/mongodb/docs/blob/01fa14decadc116b09ecdeae049e6744f16bf97f/source/reference/write-concern.txt.
+type WriteConcern struct {
+	// Number of replica set members that must acknowledge the write.
+	// Optional (0 by default, which means the primary only).
+	W int
+	// Mode for the acknowledgement, for example "majority" for the majority of the replica set members.
+	// Takes precedence over W.
+	// Optional ("" by default).
+	WMode string
+	// If true, the write must be written to the journal on disk before it's acknowledged.
+	// Optional (false by default).
+	Journal bool
+	// Time limit for the acknowledgement by the W or WMode members.
+	// When it's exceeded, an error is returned, but the write isn't undone.
+	// Optional (0 by default, which means no time limit).
+	Timeout time.Duration
+}
+
 // Options are the options for the MongoDB client.
 type Options struct {
 	// Seed servers for the initial connection to the MongoDB cluster.
@@ -160,18 +241,37 @@ type Options struct {
 	// The name of the collection to use.
 	// Optional ("item" by default).
 	CollectionName string
+	// Timeout for the initial connection.
+	// Optional (2 seconds by default).
+	DialTimeout time.Duration
+	// Read preference, which determines the replica set members that reads are sent to.
+	// Writes are always sent to the primary.
+	// All read preferences other than Primary can lead to reading stale data.
+	// Optional (Primary by default).
+	ReadPreference ReadPreference
+	// Write concern for all writes.
+	// Optional (nil by default, which means that writes are acknowledged by the primary).
+	WriteConcern *WriteConcern
 	// (Un-)marshal format.
 	// Optional (JSON by default).
 	MarshalFormat MarshalFormat
+	// If true, maps (with string keys) and structs (and pointers to them) are stored as embedded BSON documents,
+	// so that their fields can be queried (for example with Find()) and indexed.
+	// See the package documentation for details.
+	// Other values are still marshalled and stored as binary data.
+	// Optional (false by default).
+	NativeDocuments bool
 }
 
 // DefaultOptions is an Options object with default values.
-// ConnectionString: "localhost", DatabaseName: "gokv", CollectionName: "item", MarshalFormat: JSON
+// ConnectionString: "localhost", DatabaseName: "gokv", CollectionName: "item", DialTimeout: 2 * time.Second,
+// ReadPreference: Primary, WriteConcern: nil, MarshalFormat: JSON
 var DefaultOptions = Options{
 	ConnectionString: "localhost",
 	DatabaseName:     "gokv",
 	CollectionName:   "item",
-	// No need to set MarshalFormat to JSON because its zero value is fine.
+	DialTimeout:      2 * time.Second,
+	// No need to set ReadPreference to Primary and MarshalFormat to JSON because their zero values are fine.
 }
 
 // NewClient creates a new MongoDB client.
@@ -188,15 +288,32 @@ func NewClient(options Options) (Client, error) {
 	if options.CollectionName == "" {
 		options.CollectionName = DefaultOptions.CollectionName
 	}
+	if options.DialTimeout == 0 {
+		options.DialTimeout = DefaultOptions.DialTimeout
+	}
+	mode, err := options.ReadPreference.mode()
+	if err != nil {
+		return result, err
+	}
 
-	session, err := mgo.DialWithTimeout(options.ConnectionString, 2*time.Second)
+	session, err := mgo.DialWithTimeout(options.ConnectionString, options.DialTimeout)
 	if err != nil {
 		return result, err
 	}
+	session.SetMode(mode, true)
+	if options.WriteConcern != nil {
+		session.SetSafe(&mgo.Safe{
+			W:        options.WriteConcern.W,
+			WMode:    options.WriteConcern.WMode,
+			J:        options.WriteConcern.Journal,
+			WTimeout: int(options.WriteConcern.Timeout / time.Millisecond),
+		})
+	}
 	c := session.DB(options.DatabaseName).C(options.CollectionName)
 
 	result.c = c
 	result.session = session
+	result.nativeDocuments = options.NativeDocuments
 	result.marshalFormat = options.MarshalFormat
 
 	return result, nil
@@ -2,10 +2,14 @@ package mongodb_test
 
 import (
 	"log"
+	"reflect"
+	"sort"
 	"testing"
 	"time"
 
 	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+
 	"github.com/philippgille/gokv/mongodb"
 	"github.com/philippgille/gokv/test"
 )
@@ -174,6 +178,213 @@ func TestNil(t *testing.T) {
 	t.Run("get with nil / nil value parameter", createTest(mongodb.Gob))
 }
 
+type nativeFoo struct {
+	Name  string
+	Count int `bson:"count"`
+	Tags  []string
+}
+
+// TestNativeDocuments tests if maps and structs are stored as embedded documents,
+// if they can be found by their fields and if other values are still stored as binary data.
+//
+// Note: This test is only executed if the initial connection to MongoDB works.
+func TestNativeDocuments(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to MongoDB could be established. Probably not running in a proper test environment.")
+	}
+
+	options := mongodb.Options{
+		CollectionName:  "native",
+		NativeDocuments: true,
+	}
+	client, err := mongodb.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	session, err := mgo.DialWithTimeout("localhost", 2*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+	collection := session.DB(mongodb.DefaultOptions.DatabaseName).C("native")
+	_, err = collection.RemoveAll(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.Set("foo", nativeFoo{Name: "foo", Count: 1, Tags: []string{"a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Set("bar", &nativeFoo{Name: "bar", Count: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Set("baz", map[string]interface{}{"name": "baz", "count": 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Set("scalar", "qux")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Other tools can read the fields
+	doc := bson.M{}
+	err = collection.FindId("foo").One(&doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	embedded, ok := doc["v"].(bson.M)
+	if !ok {
+		t.Fatalf("Expected an embedded document, but was: %v", doc["v"])
+	}
+	if embedded["name"] != "foo" {
+		t.Errorf("Expected %v, but was %v", "foo", embedded["name"])
+	}
+
+	// Get
+	actual := nativeFoo{}
+	found, err := client.Get("foo", &actual)
+	if err != nil {
+		t.Error(err)
+	}
+	if !found {
+		t.Error("No value was found, but should have been")
+	}
+	expected := nativeFoo{Name: "foo", Count: 1, Tags: []string{"a"}}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected: %+v, but was: %+v", expected, actual)
+	}
+	scalar := ""
+	_, err = client.Get("scalar", &scalar)
+	if err != nil {
+		t.Error(err)
+	}
+	if scalar != "qux" {
+		t.Errorf("Expected %v, but was %v", "qux", scalar)
+	}
+
+	// Find with value
+	var values []nativeFoo
+	keys, err := client.Find(map[string]interface{}{"name": "bar"}, &values)
+	if err != nil {
+		t.Error(err)
+	}
+	if !reflect.DeepEqual(keys, []string{"bar"}) {
+		t.Errorf("Expected %v, but was %v", []string{"bar"}, keys)
+	}
+	if len(values) != 1 || values[0].Count != 2 {
+		t.Errorf("Expected %v, but was %v", []nativeFoo{{Name: "bar", Count: 2}}, values)
+	}
+
+	// Find with operator
+	values = nil
+	keys, err = client.Find(map[string]interface{}{"count": bson.M{"$gte": 2}}, &values)
+	if err != nil {
+		t.Error(err)
+	}
+	sort.Strings(keys)
+	if !reflect.DeepEqual(keys, []string{"bar", "baz"}) {
+		t.Errorf("Expected %v, but was %v", []string{"bar", "baz"}, keys)
+	}
+	if len(values) != 2 {
+		t.Errorf("Expected %v values, but was %v", 2, len(values))
+	}
+
+	// An empty filter only matches embedded documents, not the scalar value
+	values = nil
+	keys, err = client.Find(nil, &values)
+	if err != nil {
+		t.Error(err)
+	}
+	sort.Strings(keys)
+	if !reflect.DeepEqual(keys, []string{"bar", "baz", "foo"}) {
+		t.Errorf("Expected %v, but was %v", []string{"bar", "baz", "foo"}, keys)
+	}
+	values = nil
+	keys, err = client.Find(map[string]interface{}{"name": bson.M{"$exists": false}}, &values)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(keys) != 0 {
+		t.Errorf("Expected no keys, but was %v", keys)
+	}
+
+	// Maps with keys that aren't valid field names are stored as binary data
+	invalidKeys := map[string]string{"a.b": "c", "$d": "e"}
+	err = client.Set("invalid", invalidKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc = bson.M{}
+	err = collection.FindId("invalid").One(&doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := doc["v"].(bson.M); ok {
+		t.Errorf("Expected binary data, but was an embedded document: %v", doc["v"])
+	}
+	actualMap := map[string]string{}
+	_, err = client.Get("invalid", &actualMap)
+	if err != nil {
+		t.Error(err)
+	}
+	if !reflect.DeepEqual(actualMap, invalidKeys) {
+		t.Errorf("Expected %v, but was %v", invalidKeys, actualMap)
+	}
+
+	// Errors
+	_, err = client.Find(map[string]interface{}{"$or": nil}, &values)
+	if err == nil {
+		t.Error("An error should have occurred, but didn't")
+	}
+	_, err = client.Find(nil, values)
+	if err == nil {
+		t.Error("An error should have occurred, but didn't")
+	}
+}
+
+// TestReadPreferenceAndWriteConcern tests if the client works with a read preference other than Primary
+// and a write concern with journaling.
+// The test server is a single node, so SecondaryPreferred reads from the primary.
+//
+// Note: This test is only executed if the initial connection to MongoDB works.
+func TestReadPreferenceAndWriteConcern(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to MongoDB could be established. Probably not running in a proper test environment.")
+	}
+
+	options := mongodb.Options{
+		ReadPreference: mongodb.SecondaryPreferred,
+		WriteConcern: &mongodb.WriteConcern{
+			WMode:   "majority",
+			Journal: true,
+			Timeout: 5 * time.Second,
+		},
+	}
+	client, err := mongodb.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	test.TestStore(client, t)
+}
+
+// TestBadOptions tests if invalid options lead to an error.
+// It doesn't require a connection to MongoDB, because the options are validated first.
+func TestBadOptions(t *testing.T) {
+	options := mongodb.Options{
+		ReadPreference: mongodb.ReadPreference(123),
+	}
+	_, err := mongodb.NewClient(options)
+	if err == nil {
+		t.Error("An error should have occurred, but didn't")
+	}
+}
+
 // TestClose tests if the close method returns any errors.
 //
 // Note: This test is only executed if the initial connection to MongoDB works.
@@ -0,0 +1,155 @@
+package mongodb
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/philippgille/gokv/util"
+)
+
+// BSON kinds of the "v" field, see http://bsonspec.org/spec.html.
+const (
+	bsonDocument = 0x03
+	bsonBinary   = 0x05
+)
+
+// nativeItem is the document that's stored in the MongoDB collection for values that are stored as embedded document.
+// mgo marshals the value to an embedded BSON document, so its fields can be queried and indexed.
+type nativeItem struct {
+	K string      `bson:"_id"`
+	V interface{} `bson:"v"`
+}
+
+// rawItem is used for reading documents that can contain either a marshalled value or an embedded document.
+type rawItem struct {
+	K string   `bson:"_id"`
+	V bson.Raw `bson:"v"`
+}
+
+// Find retrieves the values of all key-value pairs whose values match the given filter
+// and stores them in the slice that values points to, for example a *[]Foo.
+// It returns the keys in the same order as the values.
+//
+// The filter only matches values that are stored as embedded document (see the NativeDocuments option).
+// Its keys are the names of the value's fields, which are the struct field names lowercased by default,
+// as mgo's bson package marshals structs this way. Use dot notation for the fields of nested documents.
+// The filter values are either the values to match or documents with MongoDB query operators,
+// for example bson.M{"$gt": 5}. Top-level operators like "$or" are not supported.
+// An empty filter matches all values that are stored as embedded document.
+func (c Client) Find(filter map[string]interface{}, values interface{}) ([]string, error) {
+	slicePtr := reflect.ValueOf(values)
+	if slicePtr.Kind() != reflect.Ptr || slicePtr.Elem().Kind() != reflect.Slice {
+		return nil, errors.New("The values must be a pointer to a slice")
+	}
+	slice := slicePtr.Elem()
+	elemType := slice.Type().Elem()
+
+	// The value's fields are fields of the embedded document "v".
+	// Values that are stored as binary data must never match, not even with an empty filter
+	// or an operator like "$exists": false.
+	query := bson.M{"v": bson.M{"$type": "object"}}
+	for field, condition := range filter {
+		if field == "" || strings.HasPrefix(field, "$") {
+			return nil, errors.New("The filter keys must be field names, but one was: \"" + field + "\"")
+		}
+		query["v."+field] = condition
+	}
+
+	var keys []string
+	iter := c.c.Find(query).Iter()
+	item := rawItem{}
+	for iter.Next(&item) {
+		elemPtr := reflect.New(elemType)
+		if err := c.decode(item.V, elemPtr.Interface()); err != nil {
+			iter.Close()
+			return nil, err
+		}
+		slice = reflect.Append(slice, elemPtr.Elem())
+		keys = append(keys, item.K)
+		item = rawItem{}
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	slicePtr.Elem().Set(slice)
+	return keys, nil
+}
+
+// decode populates the object that v points to with the value of the "v" field of a document,
+// which can be either an embedded document or a marshalled value.
+func (c Client) decode(raw bson.Raw, v interface{}) error {
+	switch raw.Kind {
+	case bsonDocument:
+		return raw.Unmarshal(v)
+	case bsonBinary:
+		var data []byte
+		if err := raw.Unmarshal(&data); err != nil {
+			return err
+		}
+		return c.unmarshal(data, v)
+	default:
+		return errors.New("The stored value has an unexpected BSON type")
+	}
+}
+
+// isNativeStorable returns true if the given value is stored as embedded document in the NativeDocuments mode,
+// which is the case for maps with string keys and for structs with fields that can be stored individually.
+// Values that contain maps with keys that aren't valid field names are stored as marshalled value, see isFieldName.
+func isNativeStorable(v interface{}) bool {
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	isMap := t.Kind() == reflect.Map && t.Key().Kind() == reflect.String
+	if !isMap && len(util.StructFields(t, "bson")) == 0 {
+		return false
+	}
+	return hasFieldNames(reflect.ValueOf(v))
+}
+
+// hasFieldNames returns true if the keys of all maps in the given value, including nested ones, are valid field names.
+func hasFieldNames(val reflect.Value) bool {
+	switch val.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if val.IsNil() {
+			return true
+		}
+		return hasFieldNames(val.Elem())
+	case reflect.Map:
+		for _, key := range val.MapKeys() {
+			if key.Kind() == reflect.String && !isFieldName(key.String()) {
+				return false
+			}
+			if !hasFieldNames(val.MapIndex(key)) {
+				return false
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		// Byte slices are stored as binary data
+		if val.Type().Elem().Kind() == reflect.Uint8 {
+			return true
+		}
+		for i := 0; i < val.Len(); i++ {
+			if !hasFieldNames(val.Index(i)) {
+				return false
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < val.NumField(); i++ {
+			if val.Type().Field(i).PkgPath == "" && !hasFieldNames(val.Field(i)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isFieldName returns true if the given map key can be used as field name of an embedded document.
+// MongoDB rejects field names that start with "$", and field names with "." can't be queried with Find(),
+// because the dot notation would interpret them as nested fields.
+func isFieldName(name string) bool {
+	return name != "" && !strings.HasPrefix(name, "$") && !strings.Contains(name, ".")
+}
@@ -1,4 +1,9 @@
 /*
 Package mysql contains an implementation of the `gokv.Store` interface for MySQL.
+
+The key-value pairs are stored in a table with a VARCHAR key column and a BLOB value column by default.
+The key length, the type of the value column (for example LONGBLOB for large values or native JSON),
+the character set, the collation and the storage engine can be configured via the Options.
+When a table already exists but doesn't match the configured schema, it's migrated when the client is created.
 */
 package mysql
@@ -9,11 +9,11 @@ import (
 	// but we'll use the package's ParseDNS() function so we make this an actual import.
 	gosqldriver "github.com/go-sql-driver/mysql"
 
+	"github.com/philippgille/gokv"
 	"github.com/philippgille/gokv/util"
 )
 
 const defaultDBname = "gokv"
-const keyLength = "255"
 
 // It's a code smell to work with a hard coded number,
 // but the error doesn't seem to be defined as constant or variable
@@ -22,16 +22,20 @@ const errDBnotFound = 1049
 
 // Client is a gokv.Store implementation for MySQL.
 type Client struct {
-	c             *sql.DB
+	c *sql.DB
+	// Only set when a read replica is configured.
+	// Only needed for closing, because the getStmt already belongs to it.
+	readDB        *sql.DB
 	insertStmt    *sql.Stmt
 	getStmt       *sql.Stmt
 	deleteStmt    *sql.Stmt
 	marshalFormat MarshalFormat
+	valueType     ValueType
 }
 
 // Set stores the given value for the given key.
 // Values are automatically marshalled to JSON or gob (depending on the configuration).
-// The length of the key must not exceed 255 characters.
+// The length of the key must not exceed the configured KeyLength (255 characters by default).
 // The key must not be "" and the value must not be nil.
 func (c Client) Set(k string, v interface{}) error {
 	if err := util.CheckKeyAndValue(k, v); err != nil {
@@ -53,10 +57,12 @@ func (c Client) Set(k string, v interface{}) error {
 		return err
 	}
 
-	if err != nil {
-		return err
+	// MySQL rejects JSON values that are sent as binary string.
+	var value interface{} = data
+	if c.valueType == JSONColumn {
+		value = string(data)
 	}
-	_, err = c.insertStmt.Exec(k, data)
+	_, err = c.insertStmt.Exec(k, value)
 	if err != nil {
 		return err
 	}
@@ -68,7 +74,8 @@ func (c Client) Set(k string, v interface{}) error {
 // You need to pass a pointer to the value, so in case of a struct
 // the automatic unmarshalling can populate the fields of the object
 // that v points to with the values of the retrieved object's values.
-// The length of the key must not exceed 255 characters.
+// The length of the key must not exceed the configured KeyLength (255 characters by default).
+// With a ReadReplicaDataSourceName, the value is read from the replica.
 // If no value is found it returns (false, nil).
 // The key must not be "" and the pointer must not be nil.
 func (c Client) Get(k string, v interface{}) (found bool, err error) {
@@ -99,7 +106,7 @@ func (c Client) Get(k string, v interface{}) (found bool, err error) {
 
 // Delete deletes the stored value for the given key.
 // Deleting a non-existing key-value pair does NOT lead to an error.
-// The length of the key must not exceed 255 characters.
+// The length of the key must not exceed the configured KeyLength (255 characters by default).
 // The key must not be "".
 func (c Client) Delete(k string) error {
 	if err := util.CheckKey(k); err != nil {
@@ -113,6 +120,12 @@ func (c Client) Delete(k string) error {
 // Close closes the client.
 // It must be called to return all open connections to the connection pool and to release any open resources.
 func (c Client) Close() error {
+	if c.readDB != nil {
+		if err := c.readDB.Close(); err != nil {
+			c.c.Close()
+			return err
+		}
+	}
 	return c.c.Close()
 }
 
@@ -137,6 +150,7 @@ type Options struct {
 	// If you passed a name of a database that doesn't exist yet,
 	// an attempt will be made to create it.
 	// If the user doesn't have the permission to create databases, an error is returned.
+	// See DisableAutoProvisioning for how to prevent any creation.
 	// Optional ("root@/gokv" by default, which will connect to "127.0.0.1:3306"
 	// and requires the server to be configured with MYSQL_ALLOW_EMPTY_PASSWORD=true,
 	// which should only be done in local test environments, if at all).
@@ -151,18 +165,59 @@ type Options struct {
 	// (Un-)marshal format.
 	// Optional (JSON by default).
 	MarshalFormat MarshalFormat
+	// Maximum length of keys in characters.
+	// The maximum depends on the character set and storage engine,
+	// because MySQL limits the index size in bytes.
+	// For example InnoDB allows 3072 bytes, which are 768 characters with utf8mb4.
+	// Optional (255 by default).
+	KeyLength int
+	// Column type of the value column.
+	// Use MediumBlob or LongBlob for values that are larger than 64 KB.
+	// JSONColumn requires the JSON MarshalFormat.
+	// Optional (Blob by default).
+	ValueType ValueType
+	// Character set of the table, for example "utf8mb4".
+	// Optional (the database's default by default).
+	CharacterSet string
+	// Collation of the table, for example "utf8mb4_bin".
+	// Optional (the character set's default by default).
+	Collation string
+	// Storage engine of the table, for example "InnoDB".
+	// Optional (the server's default by default).
+	Engine string
+	// Prevents NewClient() from creating the database and table and from migrating the table.
+	// Instead, it returns a *gokv.ResourceNotFoundError if the database or table doesn't exist.
+	// This is useful when the database user isn't allowed to make any changes to the schema.
+	// The database and table can then be created separately with Provision().
+	// Optional (false by default).
+	DisableAutoProvisioning bool
+	// Connection string of a read replica, in the same format as DataSourceName.
+	// If set, Get() reads from the replica, while Set() and Delete() still write to the primary (DataSourceName).
+	// Replication is usually asynchronous, so reads can return stale data, for example right after a write.
+	// The database and table must already exist on the replica, they're never created there.
+	// The MaxOpenConnections limit applies to the replica separately.
+	// Optional ("" by default, which means that reads go to the primary as well).
+	ReadReplicaDataSourceName string
 }
 
 // DefaultOptions is an Options object with default values.
-// DataSourceName: "root@/gokv", TableName: "Item", MaxOpenConnections: 100, MarshalFormat: JSON
+// DataSourceName: "root@/gokv", TableName: "Item", MaxOpenConnections: 100, MarshalFormat: JSON,
+// KeyLength: 255, ValueType: Blob
 var DefaultOptions = Options{
 	DataSourceName:     "root@/" + defaultDBname,
 	TableName:          "Item",
 	MaxOpenConnections: 100,
-	// No need to set MarshalFormat to JSON because its zero value is fine.
+	KeyLength:          255,
+	// No need to set MarshalFormat to JSON and ValueType to Blob because their zero values are fine.
 }
 
 // NewClient creates a new MySQL client.
+//
+// Unless DisableAutoProvisioning is set, the database and table are created if they don't exist yet.
+// If the table already exists, but its columns, character set, collation or storage engine
+// don't match the options, the table is altered accordingly.
+// With MySQL's default strict mode this fails with an error instead of truncating data,
+// for example when the KeyLength is reduced while longer keys are stored.
 func NewClient(options Options) (Client, error) {
 	result := Client{}
 
@@ -178,16 +233,162 @@ func NewClient(options Options) (Client, error) {
 	} else if options.MaxOpenConnections == -1 {
 		options.MaxOpenConnections = 0 // 0 actually leads to the MySQL driver using no connection limit.
 	}
+	if options.KeyLength == 0 {
+		options.KeyLength = DefaultOptions.KeyLength
+	} else if options.KeyLength < 0 {
+		return result, errors.New("The KeyLength must not be negative")
+	}
+	valueType, err := options.ValueType.sqlType()
+	if err != nil {
+		return result, err
+	}
+	if options.ValueType == JSONColumn && options.MarshalFormat != JSON {
+		return result, errors.New("The JSONColumn ValueType can only be used with the JSON MarshalFormat")
+	}
 
-	db, err := sql.Open("mysql", options.DataSourceName)
+	db, err := openDB(options)
 	if err != nil {
 		return result, err
 	}
+	// Reads go to the read replica if one is configured.
+	var readDB *sql.DB
+	// Close the connection pools if anything fails from here on, for example a migration in Provision().
+	success := false
+	defer func() {
+		if !success {
+			db.Close()
+			if readDB != nil {
+				readDB.Close()
+			}
+		}
+	}()
 
-	cfg, err := gosqldriver.ParseDSN(options.DataSourceName)
+	if options.DisableAutoProvisioning {
+		// Don't create or alter anything, only make sure the table exists.
+		columns, err := getColumnTypes(db, options.TableName)
+		if err != nil {
+			return result, err
+		}
+		if len(columns) == 0 {
+			return result, &gokv.ResourceNotFoundError{ResourceType: "table", Name: options.TableName}
+		}
+	} else {
+		// Create the table if it doesn't exist yet, or migrate it if it doesn't match the options.
+		err = createOrMigrateTable(db, schema{
+			tableName: options.TableName,
+			keyLength: options.KeyLength,
+			valueType: valueType,
+			charset:   options.CharacterSet,
+			collation: options.Collation,
+			engine:    options.Engine,
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+
+	// Create prepared statements that will be reused for every Set()/Get() operation.
+	// Note: Prepared statements are handled differently from other programming languages in Go,
+	// see: http://go-database-sql.org/prepared.html.
+	// TODO: Prepared statements might prevent the use of other databases that are compatible with the MySQL protocol.
+	insertStmt, err := db.Prepare("INSERT INTO " + options.TableName + " (k, v) VALUES (?, ?) ON DUPLICATE KEY UPDATE v = VALUES(v)")
+	if err != nil {
+		return result, err
+	}
+	if options.ReadReplicaDataSourceName != "" {
+		replicaOptions := options
+		replicaOptions.DataSourceName = options.ReadReplicaDataSourceName
+		// The database and table are replicated from the primary, so nothing must be created on the replica.
+		replicaOptions.DisableAutoProvisioning = true
+		readDB, err = openDB(replicaOptions)
+		if err != nil {
+			return result, err
+		}
+	}
+	getDB := db
+	if readDB != nil {
+		getDB = readDB
+	}
+	getStmt, err := getDB.Prepare("SELECT v FROM " + options.TableName + " WHERE k = ?")
 	if err != nil {
 		return result, err
 	}
+	deleteStmt, err := db.Prepare("DELETE FROM " + options.TableName + " where k = ?")
+	if err != nil {
+		return result, err
+	}
+
+	result.c = db
+	result.readDB = readDB
+	result.insertStmt = insertStmt
+	result.getStmt = getStmt
+	result.deleteStmt = deleteStmt
+	result.marshalFormat = options.MarshalFormat
+	result.valueType = options.ValueType
+
+	success = true
+	return result, nil
+}
+
+// Provision creates the database and table for the given options if they don't exist yet,
+// and migrates an existing table that doesn't match the options, the same way NewClient() does.
+// It's meant to be called once in a separate setup step,
+// for example with a database user that has more permissions than the one that's used by the application.
+// DisableAutoProvisioning is ignored.
+func Provision(options Options) error {
+	options.DisableAutoProvisioning = false
+	client, err := NewClient(options)
+	if err != nil {
+		return err
+	}
+	return client.Close()
+}
+
+// Drop deletes the table for the given options, including all stored key-value pairs.
+// The database isn't deleted, because it might contain other tables.
+// Dropping a non-existing table or a table in a non-existing database does NOT lead to an error.
+func Drop(options Options) error {
+	if options.DataSourceName == "" {
+		options.DataSourceName = DefaultOptions.DataSourceName
+	}
+	if options.TableName == "" {
+		options.TableName = DefaultOptions.TableName
+	}
+	options.DisableAutoProvisioning = true
+
+	db, err := openDB(options)
+	if _, ok := err.(*gokv.ResourceNotFoundError); ok {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec("DROP TABLE IF EXISTS " + options.TableName)
+	return err
+}
+
+// openDB opens the database from the options' DataSourceName.
+// Unless automatic provisioning is disabled, the database is created if it doesn't exist yet.
+// The options must already contain the default values.
+func openDB(options Options) (*sql.DB, error) {
+	db, err := sql.Open("mysql", options.DataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	// Close the connection pool if anything fails from here on.
+	// db can be replaced below, so the closure must use the current value.
+	success := false
+	defer func() {
+		if !success && db != nil {
+			db.Close()
+		}
+	}()
+
+	cfg, err := gosqldriver.ParseDSN(options.DataSourceName)
+	if err != nil {
+		return nil, err
+	}
 
 	err = db.Ping()
 	if err != nil {
@@ -195,6 +396,9 @@ func NewClient(options Options) (Client, error) {
 			// If the package user included a database name in the DataSourceName,
 			// but the database doesn't exist yet, we can try to create + use that database.
 			if driverErr.Number == errDBnotFound {
+				if options.DisableAutoProvisioning {
+					return nil, &gokv.ResourceNotFoundError{ResourceType: "database", Name: cfg.DBName}
+				}
 				// We can't use the existing db object, because that would lead to the same error again.
 				// So create a new one without database name, but "backup" the user provided database name,
 				// which we need to create the database.
@@ -202,38 +406,41 @@ func NewClient(options Options) (Client, error) {
 				cfg.DBName = ""
 				dsnWithoutDBname := cfg.FormatDSN()
 				tempDB, err := sql.Open("mysql", dsnWithoutDBname)
-				// This temporary DB must be closed.
-				defer tempDB.Close()
 				if err != nil {
-					return result, err
+					return nil, err
 				}
+				// This temporary DB must be closed.
+				defer tempDB.Close()
 				err = tempDB.Ping()
 				if err != nil {
-					return result, err
+					return nil, err
 				}
 				// No need to check if userProvidedDBname == "", because in that case the error wouldn't be 1049 (unknown database).
 				// In case the user doesn't have the permission to create a database, an error is returned.
 				err = createDB(tempDB, userProvidedDBname)
 				if err != nil {
-					return result, err
+					return nil, err
 				}
 				// Now the initial ping should work.
 				err = db.Ping()
 				if err != nil {
-					return result, err
+					return nil, err
 				}
 			} else {
-				return result, err
+				return nil, err
 			}
 		} else {
-			return result, err
+			return nil, err
 		}
 	} else if cfg.DBName == "" {
 		// Ping() was successful, but in case the package user didn't include a database name
-		// in the DataSourceName, we must now attempt to create the database.
-		err = createDB(db, defaultDBname)
-		if err != nil {
-			return result, err
+		// in the DataSourceName, we must now attempt to create the default database
+		// (unless automatic provisioning is disabled).
+		if !options.DisableAutoProvisioning {
+			err = createDB(db, defaultDBname)
+			if err != nil {
+				return nil, err
+			}
 		}
 		// Also, we must replace the current value of the db pointer by the new db,
 		// because calling "USE" on a database only works for the single connection that's used
@@ -243,56 +450,21 @@ func NewClient(options Options) (Client, error) {
 		dsnWithDBname := cfg.FormatDSN()
 		db, err = sql.Open("mysql", dsnWithDBname)
 		if err != nil {
-			return result, err
+			return nil, err
 		}
 		err = db.Ping()
-		if err != nil {
-			return result, err
+		if driverErr, ok := err.(*gosqldriver.MySQLError); ok && driverErr.Number == errDBnotFound {
+			return nil, &gokv.ResourceNotFoundError{ResourceType: "database", Name: defaultDBname}
+		} else if err != nil {
+			return nil, err
 		}
 	}
 
 	// Limit number of concurrent connections. Typical max connections on a MySQL server is 100.
 	// This prevents "Error 1040: Too many connections", which otherwise occurs for examaple with 500 concurrent goroutines.
 	db.SetMaxOpenConns(options.MaxOpenConnections)
-
-	// Create table if it doesn't exist yet.
-	//
-	// TEXT can't be used as primary key. VARCHAR default length is 255.
-	// VARCHAR with more characters are converted for example to SMALLTEXT,
-	// which again can't be used as primary key.
-	// TODO: Maybe the 255 character limit only applies to MySQL < 5.x?
-	// If yes, allow the user to define a key length via the options.
-	// Also: There's no hard character limit, but byte limit.
-	// So the 255 characters come from 255 utf8mb3 characters.
-	_, err = db.Exec("CREATE TABLE IF NOT EXISTS " + options.TableName + " (k VARCHAR(" + keyLength + ") PRIMARY KEY, v BLOB NOT NULL)")
-	if err != nil {
-		return result, err
-	}
-
-	// Create prepared statements that will be reused for every Set()/Get() operation.
-	// Note: Prepared statements are handled differently from other programming languages in Go,
-	// see: http://go-database-sql.org/prepared.html.
-	// TODO: Prepared statements might prevent the use of other databases that are compatible with the MySQL protocol.
-	insertStmt, err := db.Prepare("INSERT INTO " + options.TableName + " (k, v) VALUES (?, ?) ON DUPLICATE KEY UPDATE v = VALUES(v)")
-	if err != nil {
-		return result, err
-	}
-	getStmt, err := db.Prepare("SELECT v FROM " + options.TableName + " WHERE k = ?")
-	if err != nil {
-		return result, err
-	}
-	deleteStmt, err := db.Prepare("DELETE FROM " + options.TableName + " where k = ?")
-	if err != nil {
-		return result, err
-	}
-
-	result.c = db
-	result.insertStmt = insertStmt
-	result.getStmt = getStmt
-	result.deleteStmt = deleteStmt
-	result.marshalFormat = options.MarshalFormat
-
-	return result, nil
+	success = true
+	return db, nil
 }
 
 // createDB creates a database with the given name.
@@ -3,10 +3,12 @@ package mysql_test
 import (
 	"database/sql"
 	"log"
+	"strings"
 	"testing"
 
 	_ "github.com/go-sql-driver/mysql"
 
+	"github.com/philippgille/gokv"
 	"github.com/philippgille/gokv/mysql"
 	"github.com/philippgille/gokv/test"
 )
@@ -234,6 +236,332 @@ func TestDefaultMaxOpenConnections(t *testing.T) {
 	}
 }
 
+// TestKeyLength tests if keys that are longer than the default key length can be stored
+// when a higher KeyLength is configured.
+//
+// Note: This test is only executed if the initial connection to MySQL works.
+func TestKeyLength(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to MySQL could be established. Probably not running in a proper test environment.")
+	}
+
+	options := mysql.Options{
+		TableName:    "ItemKeyLength",
+		KeyLength:    500,
+		CharacterSet: "utf8mb4",
+	}
+	client, err := mysql.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	key := strings.Repeat("a", 500)
+	err = client.Set(key, "bar")
+	if err != nil {
+		t.Error(err)
+	}
+	vPtr := new(string)
+	found, err := client.Get(key, vPtr)
+	if err != nil {
+		t.Error(err)
+	}
+	if !found {
+		t.Error("A value should have been found, but wasn't.")
+	}
+	if *vPtr != "bar" {
+		t.Errorf("Expected %v, but was %v", "bar", *vPtr)
+	}
+}
+
+// TestValueTypes tests if all value column types work,
+// including values that are too large for a BLOB column.
+//
+// Note: This test is only executed if the initial connection to MySQL works.
+func TestValueTypes(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to MySQL could be established. Probably not running in a proper test environment.")
+	}
+
+	largeVal := strings.Repeat("a", 100*1024)
+	valueTypes := map[string]mysql.ValueType{
+		"MediumBlob": mysql.MediumBlob,
+		"LongBlob":   mysql.LongBlob,
+		"JSONColumn": mysql.JSONColumn,
+	}
+	for name, valueType := range valueTypes {
+		t.Run(name, func(t *testing.T) {
+			options := mysql.Options{
+				TableName: "Item" + name,
+				ValueType: valueType,
+			}
+			client, err := mysql.NewClient(options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+
+			err = client.Set("foo", largeVal)
+			if err != nil {
+				t.Error(err)
+			}
+			vPtr := new(string)
+			found, err := client.Get("foo", vPtr)
+			if err != nil {
+				t.Error(err)
+			}
+			if !found {
+				t.Error("A value should have been found, but wasn't.")
+			}
+			if *vPtr != largeVal {
+				t.Error("The retrieved value is different from the stored one")
+			}
+
+			test.TestStore(client, t)
+		})
+	}
+}
+
+// TestSchemaMigration tests if an existing table with a different schema is migrated
+// without losing the stored values.
+//
+// Note: This test is only executed if the initial connection to MySQL works.
+func TestSchemaMigration(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to MySQL could be established. Probably not running in a proper test environment.")
+	}
+
+	// Create a table with the previous default schema.
+	options := mysql.Options{
+		TableName: "ItemMigration",
+	}
+	client, err := mysql.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Set("foo", "bar")
+	if err != nil {
+		t.Error(err)
+	}
+	client.Close()
+
+	// MyISAM limits the index size to 1000 bytes, which are 250 characters with utf8mb4.
+	options.KeyLength = 200
+	options.ValueType = mysql.MediumBlob
+	options.Engine = "MyISAM"
+	options.Collation = "utf8mb4_bin"
+	client, err = mysql.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	db, err := sql.Open("mysql", "root@/gokv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var keyType, valueType string
+	err = db.QueryRow("SELECT COLUMN_TYPE FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'ItemMigration' AND COLUMN_NAME = 'k'").Scan(&keyType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.QueryRow("SELECT COLUMN_TYPE FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'ItemMigration' AND COLUMN_NAME = 'v'").Scan(&valueType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyType != "varchar(200)" {
+		t.Errorf("Expected %v, but was %v", "varchar(200)", keyType)
+	}
+	if valueType != "mediumblob" {
+		t.Errorf("Expected %v, but was %v", "mediumblob", valueType)
+	}
+	var engine, collation string
+	err = db.QueryRow("SELECT ENGINE, TABLE_COLLATION FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'ItemMigration'").Scan(&engine, &collation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if engine != "MyISAM" {
+		t.Errorf("Expected %v, but was %v", "MyISAM", engine)
+	}
+	if collation != "utf8mb4_bin" {
+		t.Errorf("Expected %v, but was %v", "utf8mb4_bin", collation)
+	}
+
+	vPtr := new(string)
+	found, err := client.Get("foo", vPtr)
+	if err != nil {
+		t.Error(err)
+	}
+	if !found {
+		t.Error("A value should have been found, but wasn't.")
+	}
+	if *vPtr != "bar" {
+		t.Errorf("Expected %v, but was %v", "bar", *vPtr)
+	}
+
+	// Clean up so that the next test run starts with the previous default schema again.
+	_, err = db.Exec("DROP TABLE ItemMigration")
+	if err != nil {
+		t.Error(err)
+	}
+
+	// Migrate a table with the previous default schema and existing JSON values to a JSON column.
+	options = mysql.Options{
+		TableName: "ItemJSONMigration",
+	}
+	client, err = mysql.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := test.Foo{Bar: "baz"}
+	err = client.Set("foo", expected)
+	if err != nil {
+		t.Error(err)
+	}
+	client.Close()
+
+	options.ValueType = mysql.JSONColumn
+	client, err = mysql.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	err = db.QueryRow("SELECT COLUMN_TYPE FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'ItemJSONMigration' AND COLUMN_NAME = 'v'").Scan(&valueType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if valueType != "json" {
+		t.Errorf("Expected %v, but was %v", "json", valueType)
+	}
+	actual := test.Foo{}
+	found, err = client.Get("foo", &actual)
+	if err != nil {
+		t.Error(err)
+	}
+	if !found {
+		t.Error("A value should have been found, but wasn't.")
+	}
+	if actual != expected {
+		t.Errorf("Expected %+v, but was %+v", expected, actual)
+	}
+
+	_, err = db.Exec("DROP TABLE ItemJSONMigration")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// TestProvisioning tests if NewClient() returns a *gokv.ResourceNotFoundError
+// when automatic provisioning is disabled and the database or table doesn't exist,
+// and if Provision() and Drop() create and delete the table.
+//
+// Note: This test is only executed if the initial connection to MySQL works.
+func TestProvisioning(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to MySQL could be established. Probably not running in a proper test environment.")
+	}
+
+	// Non-existing database
+	options := mysql.Options{
+		DataSourceName:          "root@/gokv_nonexistent",
+		DisableAutoProvisioning: true,
+	}
+	_, err := mysql.NewClient(options)
+	if notFoundErr, ok := err.(*gokv.ResourceNotFoundError); !ok {
+		t.Errorf("Expected a *gokv.ResourceNotFoundError, but was: %v", err)
+	} else if notFoundErr.ResourceType != "database" {
+		t.Errorf("Expected %v, but was %v", "database", notFoundErr.ResourceType)
+	}
+
+	// Non-existing table
+	options = mysql.Options{
+		TableName:               "ItemProvisioning",
+		DisableAutoProvisioning: true,
+	}
+	err = mysql.Drop(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = mysql.NewClient(options)
+	if notFoundErr, ok := err.(*gokv.ResourceNotFoundError); !ok {
+		t.Errorf("Expected a *gokv.ResourceNotFoundError, but was: %v", err)
+	} else if notFoundErr.ResourceType != "table" {
+		t.Errorf("Expected %v, but was %v", "table", notFoundErr.ResourceType)
+	}
+
+	// Provisioned table
+	err = mysql.Provision(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := mysql.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.TestStore(client, t)
+	client.Close()
+
+	// Dropped table
+	err = mysql.Drop(options)
+	if err != nil {
+		t.Error(err)
+	}
+	_, err = mysql.NewClient(options)
+	if _, ok := err.(*gokv.ResourceNotFoundError); !ok {
+		t.Errorf("Expected a *gokv.ResourceNotFoundError, but was: %v", err)
+	}
+}
+
+// TestReadReplica tests if the client works with a read replica
+// and if nothing is created on the replica.
+// The test server doesn't have a replica, so the server itself is used as replica.
+//
+// Note: This test is only executed if the initial connection to MySQL works.
+func TestReadReplica(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to MySQL could be established. Probably not running in a proper test environment.")
+	}
+
+	options := mysql.Options{
+		ReadReplicaDataSourceName: "root@/gokv",
+	}
+	client, err := mysql.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.TestStore(client, t)
+	err = client.Close()
+	if err != nil {
+		t.Error(err)
+	}
+
+	// The database must not be created on the replica
+	options.ReadReplicaDataSourceName = "root@/gokv_nonexistent"
+	_, err = mysql.NewClient(options)
+	if _, ok := err.(*gokv.ResourceNotFoundError); !ok {
+		t.Errorf("Expected a *gokv.ResourceNotFoundError, but was: %v", err)
+	}
+}
+
+// TestBadOptions tests if invalid options lead to an error.
+// It doesn't require a connection to MySQL, because the options are validated first.
+func TestBadOptions(t *testing.T) {
+	badOptions := map[string]mysql.Options{
+		"negative key length": {KeyLength: -1},
+		"invalid value type":  {ValueType: mysql.ValueType(123)},
+		"JSON column and gob": {ValueType: mysql.JSONColumn, MarshalFormat: mysql.Gob},
+	}
+	for name, options := range badOptions {
+		t.Run(name, func(t *testing.T) {
+			_, err := mysql.NewClient(options)
+			if err == nil {
+				t.Error("An error should have occurred, but didn't")
+			}
+		})
+	}
+}
+
 // checkConnection returns true if a connection could be made, false otherwise.
 func checkConnection() bool {
 	db, err := sql.Open("mysql", "root@/")
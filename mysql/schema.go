@@ -0,0 +1,191 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ValueType is an enum for the available column types of the value column.
+type ValueType int
+
+const (
+	// Blob is the ValueType for a BLOB column, which can hold values of up to 64 KB.
+	Blob ValueType = iota
+	// MediumBlob is the ValueType for a MEDIUMBLOB column, which can hold values of up to 16 MB.
+	MediumBlob
+	// LongBlob is the ValueType for a LONGBLOB column, which can hold values of up to 4 GB.
+	LongBlob
+	// JSONColumn is the ValueType for a native JSON column.
+	// It can only be used with the JSON MarshalFormat.
+	// MySQL validates the values and stores them in a binary format,
+	// which allows querying them with MySQL's JSON functions.
+	JSONColumn
+)
+
+// sqlType returns the MySQL data type of the value column.
+// It's lower case, the same way MySQL reports it in the information_schema.
+func (t ValueType) sqlType() (string, error) {
+	switch t {
+	case Blob:
+		return "blob", nil
+	case MediumBlob:
+		return "mediumblob", nil
+	case LongBlob:
+		return "longblob", nil
+	case JSONColumn:
+		return "json", nil
+	default:
+		return "", errors.New("The ValueType is invalid")
+	}
+}
+
+// schema is the table schema that's requested via the options.
+type schema struct {
+	tableName string
+	keyLength int
+	valueType string
+	charset   string
+	collation string
+	engine    string
+}
+
+// createOrMigrateTable creates the table if it doesn't exist yet.
+// If it exists but its columns, character set, collation or storage engine
+// don't match the requested schema, the table is altered.
+// MySQL rejects alterations that would lead to data loss (for example when shortening the key column
+// while a longer key is stored or when changing a BLOB column that contains gob values to JSON)
+// as long as the server runs in strict mode, which is the default. In that case an error is returned.
+// BLOB columns are changed to JSON in two steps, via a utf8mb4 text column, because MySQL doesn't accept
+// data with the binary character set of BLOB columns as JSON, even if it's valid JSON.
+func createOrMigrateTable(db *sql.DB, s schema) error {
+	columns, err := getColumnTypes(db, s.tableName)
+	if err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		return createTable(db, s)
+	}
+
+	var engine, collation string
+	err = db.QueryRow("SELECT ENGINE, IFNULL(TABLE_COLLATION, '') FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?", s.tableName).Scan(&engine, &collation)
+	if err != nil {
+		return err
+	}
+
+	// All changes are made in a single statement, so that limits like the maximum index size
+	// are checked against the resulting schema and not against an intermediate one.
+	var alterations []string
+	if s.engine != "" && !strings.EqualFold(engine, s.engine) {
+		alterations = append(alterations, "ENGINE = "+s.engine)
+	}
+	if !collationMatches(collation, s.charset, s.collation) {
+		// CONVERT TO requires a character set. Each collation name starts with the name of its character set.
+		if s.charset == "" {
+			s.charset = strings.SplitN(s.collation, "_", 2)[0]
+		}
+		alterations = append(alterations, "CONVERT TO"+tableCharset(s))
+	}
+	if columns["k"] != "varchar("+strconv.Itoa(s.keyLength)+")" {
+		alterations = append(alterations, "MODIFY k "+keyColumn(s)+" NOT NULL")
+	}
+	blobToJSON := false
+	if columns["v"] != s.valueType {
+		if s.valueType == "json" && strings.HasSuffix(columns["v"], "blob") {
+			blobToJSON = true
+			alterations = append(alterations, "MODIFY v LONGTEXT CHARACTER SET utf8mb4 NOT NULL")
+		} else {
+			alterations = append(alterations, "MODIFY v "+s.valueType+" NOT NULL")
+		}
+	}
+	if len(alterations) > 0 {
+		_, err = db.Exec("ALTER TABLE " + s.tableName + " " + strings.Join(alterations, ", "))
+		if err != nil {
+			return err
+		}
+	}
+	if blobToJSON {
+		_, err = db.Exec("ALTER TABLE " + s.tableName + " MODIFY v json NOT NULL")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createTable creates the table with the requested schema.
+//
+// TEXT can't be used as primary key, so VARCHAR is used for the key column.
+// Note that the maximum key length depends on the character set and the storage engine,
+// because the limit of the index size is defined in bytes, not characters.
+// For example InnoDB allows 3072 bytes, which are 768 characters with utf8mb4.
+func createTable(db *sql.DB, s schema) error {
+	stmt := "CREATE TABLE IF NOT EXISTS " + s.tableName + " (k " + keyColumn(s) + " PRIMARY KEY, v " + s.valueType + " NOT NULL)"
+	if s.engine != "" {
+		stmt += " ENGINE = " + s.engine
+	}
+	if s.charset != "" || s.collation != "" {
+		stmt += " DEFAULT" + tableCharset(s)
+	}
+	_, err := db.Exec(stmt)
+	return err
+}
+
+// getColumnTypes returns the column types of the given table in the current database,
+// with the column names as map keys. The types are lower case and contain the length,
+// for example "varchar(255)". If the table doesn't exist, the map is empty.
+func getColumnTypes(db *sql.DB, tableName string) (map[string]string, error) {
+	rows, err := db.Query("SELECT COLUMN_NAME, COLUMN_TYPE FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?", tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]string)
+	for rows.Next() {
+		var name, columnType string
+		if err := rows.Scan(&name, &columnType); err != nil {
+			return nil, err
+		}
+		result[strings.ToLower(name)] = strings.ToLower(columnType)
+	}
+	return result, rows.Err()
+}
+
+// keyColumn returns the definition of the key column, without constraints.
+// The character set and collation are the same as the table's.
+func keyColumn(s schema) string {
+	return "VARCHAR(" + strconv.Itoa(s.keyLength) + ")" + tableCharset(s)
+}
+
+// tableCharset returns the character set and collation clause for CREATE TABLE and ALTER TABLE,
+// with a leading space.
+func tableCharset(s schema) string {
+	result := ""
+	if s.charset != "" {
+		result += " CHARACTER SET " + s.charset
+	}
+	if s.collation != "" {
+		result += " COLLATE " + s.collation
+	}
+	return result
+}
+
+// collationMatches returns true if the collation of an existing table
+// matches the requested character set and collation.
+// Empty requested values match any existing value.
+func collationMatches(existing, charset, collation string) bool {
+	existing = strings.ToLower(existing)
+	if collation != "" {
+		return existing == strings.ToLower(collation)
+	}
+	if charset != "" {
+		charset = strings.ToLower(charset)
+		// Each collation name starts with the name of its character set.
+		// MySQL 8 reports the "utf8" alias as "utf8mb3".
+		return strings.HasPrefix(existing, charset+"_") ||
+			(charset == "utf8" && strings.HasPrefix(existing, "utf8mb3_"))
+	}
+	return true
+}
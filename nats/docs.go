@@ -0,0 +1,8 @@
+/*
+Package nats contains an implementation of the `gokv.Store` interface for the key-value buckets of NATS JetStream.
+
+NATS only allows a limited set of characters in keys, so the keys are escaped.
+Letters, digits, "-", "_" and "/" are kept, all other bytes are encoded as "=" followed by two hexadecimal digits.
+For example the key "foo.bar" is stored as "foo=2Ebar".
+*/
+package nats
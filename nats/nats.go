@@ -0,0 +1,374 @@
+package nats
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/philippgille/gokv"
+	"github.com/philippgille/gokv/util"
+)
+
+// Client is a gokv.Store implementation for a NATS JetStream key-value bucket.
+// It also implements gokv.Lister and gokv.Watcher.
+type Client struct {
+	nc            *nats.Conn
+	kv            nats.KeyValue
+	marshalFormat MarshalFormat
+}
+
+// Set stores the given value for the given key.
+// Values are automatically marshalled to JSON or gob (depending on the configuration).
+// The key must not be "" and the value must not be nil.
+func (c Client) Set(k string, v interface{}) error {
+	if err := util.CheckKeyAndValue(k, v); err != nil {
+		return err
+	}
+
+	// First turn the passed object into something that NATS can handle
+	var data []byte
+	var err error
+	switch c.marshalFormat {
+	case JSON:
+		data, err = util.ToJSON(v)
+	case Gob:
+		data, err = util.ToGob(v)
+	default:
+		err = errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = c.kv.Put(escape(k), data)
+	return err
+}
+
+// Get retrieves the stored value for the given key.
+// You need to pass a pointer to the value, so in case of a struct
+// the automatic unmarshalling can populate the fields of the object
+// that v points to with the values of the retrieved object's values.
+// If no value is found it returns (false, nil).
+// The key must not be "" and the pointer must not be nil.
+func (c Client) Get(k string, v interface{}) (found bool, err error) {
+	if err := util.CheckKeyAndValue(k, v); err != nil {
+		return false, err
+	}
+
+	entry, err := c.kv.Get(escape(k))
+	// If no value was found return false.
+	// This is also the case when the latest revision of the key is a deletion.
+	if err == nats.ErrKeyNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	data := entry.Value()
+
+	switch c.marshalFormat {
+	case JSON:
+		return true, util.FromJSON(data, v)
+	case Gob:
+		return true, util.FromGob(data, v)
+	default:
+		return true, errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	}
+}
+
+// Delete deletes the stored value for the given key.
+// The previous revisions stay in the history of the bucket until they're replaced by newer revisions.
+// Deleting a non-existing key-value pair does NOT lead to an error.
+// The key must not be "".
+func (c Client) Delete(k string) error {
+	if err := util.CheckKey(k); err != nil {
+		return err
+	}
+
+	return c.kv.Delete(escape(k))
+}
+
+// List calls fn for every key in the bucket.
+// Note: NATS sends all keys at once, so they must fit into memory.
+// If fn returns an error, the iteration is stopped and the error is returned.
+func (c Client) List(fn func(k string) error) error {
+	keys, err := c.kv.Keys()
+	if err == nats.ErrNoKeysFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		k, err := unescape(key)
+		if err != nil {
+			// Not stored by gokv
+			continue
+		}
+		if err := fn(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Watch returns a channel that receives an event for every key-value pair
+// with a key that starts with the given prefix that is stored or deleted after the call.
+// The channel is closed when the context is done.
+func (c Client) Watch(ctx context.Context, prefix string) (<-chan gokv.WatchEvent, error) {
+	// NATS only supports wildcards for whole tokens, which don't match arbitrary prefixes of the escaped keys,
+	// so watch all keys and filter them here.
+	watcher, err := c.kv.WatchAll(nats.UpdatesOnly(), nats.Context(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan gokv.WatchEvent)
+	go func() {
+		defer close(events)
+		defer watcher.Stop()
+		for {
+			select {
+			case entry, ok := <-watcher.Updates():
+				if !ok {
+					return
+				}
+				// A nil entry marks the end of the initial values, which are skipped anyway
+				if entry == nil {
+					continue
+				}
+				k, err := unescape(entry.Key())
+				if err != nil || !strings.HasPrefix(k, prefix) {
+					continue
+				}
+				event := gokv.WatchEvent{
+					Key:     k,
+					Deleted: entry.Operation() != nats.KeyValuePut,
+				}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// Close closes the client.
+// It must be called to close the connection to the NATS server.
+func (c Client) Close() error {
+	c.nc.Close()
+	return nil
+}
+
+// escape turns the key into a valid NATS key.
+// Letters, digits, "-", "_" and "/" are kept, all other bytes are encoded as "=XX".
+// This also escapes ".", which NATS uses as token separator.
+func escape(k string) string {
+	buf := bytes.Buffer{}
+	for i := 0; i < len(k); i++ {
+		c := k[i]
+		if ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || c == '-' || c == '_' || c == '/' {
+			buf.WriteByte(c)
+		} else {
+			fmt.Fprintf(&buf, "=%02X", c)
+		}
+	}
+	return buf.String()
+}
+
+// unescape is the reverse of escape.
+func unescape(key string) (string, error) {
+	buf := bytes.Buffer{}
+	for i := 0; i < len(key); i++ {
+		if key[i] != '=' {
+			buf.WriteByte(key[i])
+			continue
+		}
+		if i+2 >= len(key) {
+			return "", errors.New("The key " + key + " isn't escaped properly")
+		}
+		c, err := strconv.ParseUint(key[i+1:i+3], 16, 8)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteByte(byte(c))
+		i += 2
+	}
+	return buf.String(), nil
+}
+
+// MarshalFormat is an enum for the available (un-)marshal formats of this gokv.Store implementation.
+type MarshalFormat int
+
+const (
+	// JSON is the MarshalFormat for (un-)marshalling to/from JSON
+	JSON MarshalFormat = iota
+	// Gob is the MarshalFormat for (un-)marshalling to/from gob
+	Gob
+)
+
+// Options are the options for the NATS client.
+type Options struct {
+	// URL of the NATS server.
+	// Multiple URLs of a cluster can be separated by commas.
+	// Optional ("nats://127.0.0.1:4222" by default).
+	URL string
+	// Name of the key-value bucket.
+	// If the bucket doesn't exist yet, gokv creates it with the following options (see DisableAutoProvisioning).
+	// Optional ("gokv" by default).
+	BucketName string
+	// Number of replicas of the bucket in a NATS cluster.
+	// Only used when the bucket is created.
+	// Optional (1 by default).
+	Replicas int
+	// Number of revisions per key that are kept, up to 64.
+	// Only used when the bucket is created.
+	// Optional (1 by default).
+	History uint8
+	// Maximum size of a (marshalled) value, in bytes.
+	// Only used when the bucket is created.
+	// Optional (0 by default, which means that only the server's maximum message size applies).
+	MaxValueSize int32
+	// Time after which key-value pairs expire.
+	// Only used when the bucket is created.
+	// Optional (0 by default, which means that they don't expire).
+	TTL time.Duration
+	// Options for connecting to the server, for example nats.UserCredentials() for authentication.
+	// Optional (nil by default).
+	ConnectOptions []nats.Option
+	// (Un-)marshal format.
+	// Optional (JSON by default).
+	MarshalFormat MarshalFormat
+	// Prevents NewClient() from creating the bucket.
+	// Instead, it returns a *gokv.ResourceNotFoundError if the bucket doesn't exist.
+	// The bucket can then be created separately with Provision().
+	// Optional (false by default).
+	DisableAutoProvisioning bool
+}
+
+// DefaultOptions is an Options object with default values.
+// URL: "nats://127.0.0.1:4222", BucketName: "gokv", Replicas: 1, History: 1, MaxValueSize: 0, TTL: 0, ConnectOptions: nil, MarshalFormat: JSON,
+// DisableAutoProvisioning: false
+var DefaultOptions = Options{
+	URL:        nats.DefaultURL,
+	BucketName: "gokv",
+	Replicas:   1,
+	History:    1,
+	// No need to set MaxValueSize, TTL, ConnectOptions or MarshalFormat because their zero values are fine.
+}
+
+// NewClient creates a new NATS client.
+// JetStream must be enabled on the server.
+// Unless DisableAutoProvisioning is set, the bucket is created if it doesn't exist yet.
+func NewClient(options Options) (Client, error) {
+	result := Client{}
+
+	// Set default values
+	options = setDefaults(options)
+	if options.Replicas == 0 {
+		options.Replicas = DefaultOptions.Replicas
+	}
+	if options.History == 0 {
+		options.History = DefaultOptions.History
+	}
+	if options.MaxValueSize == 0 {
+		options.MaxValueSize = -1 // -1 is NATS' value for no limit
+	}
+
+	nc, js, err := connect(options)
+	if err != nil {
+		return result, err
+	}
+
+	// Create bucket if it doesn't exist yet.
+	kv, err := js.KeyValue(options.BucketName)
+	if err == nats.ErrBucketNotFound {
+		if options.DisableAutoProvisioning {
+			nc.Close()
+			return result, &gokv.ResourceNotFoundError{ResourceType: "bucket", Name: options.BucketName}
+		}
+		kv, err = js.CreateKeyValue(&nats.KeyValueConfig{
+			Bucket:       options.BucketName,
+			Replicas:     options.Replicas,
+			History:      options.History,
+			MaxValueSize: options.MaxValueSize,
+			TTL:          options.TTL,
+		})
+	}
+	if err != nil {
+		nc.Close()
+		return result, err
+	}
+
+	result.nc = nc
+	result.kv = kv
+	result.marshalFormat = options.MarshalFormat
+
+	return result, nil
+}
+
+// Provision creates the bucket for the given options if it doesn't exist yet,
+// the same way NewClient() does.
+// It's meant to be called once in a separate setup step,
+// for example with a user that has more permissions than the one that's used by the application.
+// DisableAutoProvisioning is ignored.
+func Provision(options Options) error {
+	options.DisableAutoProvisioning = false
+	client, err := NewClient(options)
+	if err != nil {
+		return err
+	}
+	return client.Close()
+}
+
+// Drop deletes the bucket for the given options, including all stored key-value pairs.
+// Dropping a non-existing bucket does NOT lead to an error.
+func Drop(options Options) error {
+	options = setDefaults(options)
+	nc, js, err := connect(options)
+	if err != nil {
+		return err
+	}
+	defer nc.Close()
+
+	err = js.DeleteKeyValue(options.BucketName)
+	// Depending on the client version, deleting a non-existing bucket leads to one of these errors
+	if err == nats.ErrBucketNotFound || err == nats.ErrStreamNotFound {
+		return nil
+	}
+	return err
+}
+
+// setDefaults returns a copy of the given options with default values for the URL and bucket name.
+func setDefaults(options Options) Options {
+	if options.URL == "" {
+		options.URL = DefaultOptions.URL
+	}
+	if options.BucketName == "" {
+		options.BucketName = DefaultOptions.BucketName
+	}
+	return options
+}
+
+// connect connects to the server and returns the connection together with its JetStream context.
+func connect(options Options) (*nats.Conn, nats.JetStreamContext, error) {
+	nc, err := nats.Connect(options.URL, options.ConnectOptions...)
+	if err != nil {
+		return nil, nil, err
+	}
+	js, err := nc.JetStream()
+	if err != nil {
+		nc.Close()
+		return nil, nil, err
+	}
+	return nc, js, nil
+}
@@ -0,0 +1,308 @@
+package nats_test
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+
+	"github.com/philippgille/gokv"
+	"github.com/philippgille/gokv/nats"
+	"github.com/philippgille/gokv/test"
+)
+
+// serverURL is the URL of the embedded NATS server that's started in TestMain.
+var serverURL string
+
+// TestMain starts an embedded NATS server with JetStream enabled for all tests,
+// and shuts it down and removes its storage directory afterwards.
+func TestMain(m *testing.M) {
+	storeDir, err := ioutil.TempDir(os.TempDir(), "nats")
+	if err != nil {
+		log.Fatal(err)
+	}
+	serverOptions := &server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1, // Random port
+		JetStream: true,
+		StoreDir:  storeDir,
+	}
+	srv, err := server.NewServer(serverOptions)
+	if err != nil {
+		os.RemoveAll(storeDir)
+		log.Fatal(err)
+	}
+	go srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		srv.Shutdown()
+		os.RemoveAll(storeDir)
+		log.Fatal("The NATS server didn't start in time")
+	}
+	serverURL = srv.ClientURL()
+
+	code := m.Run()
+
+	srv.Shutdown()
+	os.RemoveAll(storeDir)
+	os.Exit(code)
+}
+
+// TestClient tests if reading from, writing to and deleting from the store works properly.
+// A struct is used as value. See TestTypes() for a test that is simpler but tests all types.
+func TestClient(t *testing.T) {
+	// Test with JSON
+	t.Run("JSON", func(t *testing.T) {
+		client := createClient(t, nats.JSON)
+		test.TestStore(client, t)
+	})
+
+	// Test with gob
+	t.Run("gob", func(t *testing.T) {
+		client := createClient(t, nats.Gob)
+		test.TestStore(client, t)
+	})
+}
+
+// TestTypes tests if setting and getting values works with all Go types.
+func TestTypes(t *testing.T) {
+	// Test with JSON
+	t.Run("JSON", func(t *testing.T) {
+		client := createClient(t, nats.JSON)
+		test.TestTypes(client, t)
+	})
+
+	// Test with gob
+	t.Run("gob", func(t *testing.T) {
+		client := createClient(t, nats.Gob)
+		test.TestTypes(client, t)
+	})
+}
+
+// TestClientConcurrent launches a bunch of goroutines that concurrently work with the NATS client.
+func TestClientConcurrent(t *testing.T) {
+	client := createClient(t, nats.JSON)
+
+	goroutineCount := 1000
+
+	test.TestConcurrentInteractions(t, goroutineCount, client)
+}
+
+// TestKeyEscaping tests if keys with characters that NATS doesn't allow can be used.
+func TestKeyEscaping(t *testing.T) {
+	client := createClient(t, nats.JSON)
+
+	keys := []string{"foo.bar", "foo bar", ".foo.", "*", ">", "foo=bar", "äöü", "foo/bar"}
+	for _, k := range keys {
+		err := client.Set(k, k)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	for _, k := range keys {
+		actual := ""
+		found, err := client.Get(k, &actual)
+		if err != nil {
+			t.Error(err)
+		}
+		if !found {
+			t.Errorf("No value was found for key %v, but should have been", k)
+		}
+		if actual != k {
+			t.Errorf("Expected: %v, but was: %v", k, actual)
+		}
+	}
+}
+
+// TestList tests if the keys are unescaped when listing them.
+func TestList(t *testing.T) {
+	client := createClient(t, nats.JSON)
+	test.TestList(client, t)
+}
+
+// TestWatch tests if changes of keys with the given prefix are sent to the channel.
+func TestWatch(t *testing.T) {
+	client := createClient(t, nats.JSON)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := client.Watch(ctx, "foo.")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.Set("bar", "baz")
+	if err != nil {
+		t.Error(err)
+	}
+	err = client.Set("foo.bar", "baz")
+	if err != nil {
+		t.Error(err)
+	}
+	err = client.Delete("foo.bar")
+	if err != nil {
+		t.Error(err)
+	}
+
+	for _, expected := range []gokv.WatchEvent{{Key: "foo.bar"}, {Key: "foo.bar", Deleted: true}} {
+		select {
+		case event := <-events:
+			if event != expected {
+				t.Errorf("Expected: %v, but was: %v", expected, event)
+			}
+		case <-time.After(2 * time.Second):
+			t.Error("No event was received, but should have been")
+		}
+	}
+}
+
+// TestProvisioning tests if NewClient() returns a *gokv.ResourceNotFoundError
+// when automatic provisioning is disabled and the bucket doesn't exist,
+// and if Provision() and Drop() create and delete the bucket.
+func TestProvisioning(t *testing.T) {
+	options := nats.Options{
+		URL:                     serverURL,
+		BucketName:              "gokvProvisioning",
+		DisableAutoProvisioning: true,
+	}
+	// Dropping a non-existing bucket must not lead to an error
+	err := nats.Drop(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = nats.NewClient(options)
+	if notFoundErr, ok := err.(*gokv.ResourceNotFoundError); !ok {
+		t.Errorf("Expected a *gokv.ResourceNotFoundError, but was: %v", err)
+	} else if notFoundErr.Name != options.BucketName {
+		t.Errorf("Expected %v, but was %v", options.BucketName, notFoundErr.Name)
+	}
+
+	err = nats.Provision(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := nats.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.TestStore(client, t)
+	client.Close()
+
+	err = nats.Drop(options)
+	if err != nil {
+		t.Error(err)
+	}
+	_, err = nats.NewClient(options)
+	if _, ok := err.(*gokv.ResourceNotFoundError); !ok {
+		t.Errorf("Expected a *gokv.ResourceNotFoundError, but was: %v", err)
+	}
+}
+
+// TestErrors tests some error cases.
+func TestErrors(t *testing.T) {
+	// Test with a bad MarshalFormat enum value
+
+	client := createClient(t, nats.MarshalFormat(19))
+	err := client.Set("foo", "bar")
+	if err == nil {
+		t.Error("An error should have occurred, but didn't")
+	}
+
+	// Test empty key
+	err = client.Set("", "bar")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	_, err = client.Get("", new(string))
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	err = client.Delete("")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+// TestNil tests the behaviour when passing nil or pointers to nil values to some methods.
+func TestNil(t *testing.T) {
+	// Test setting nil
+
+	t.Run("set nil with JSON marshalling", func(t *testing.T) {
+		client := createClient(t, nats.JSON)
+		err := client.Set("foo", nil)
+		if err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	t.Run("set nil with Gob marshalling", func(t *testing.T) {
+		client := createClient(t, nats.Gob)
+		err := client.Set("foo", nil)
+		if err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	// Test passing nil or pointer to nil value for retrieval
+
+	createTest := func(mf nats.MarshalFormat) func(t *testing.T) {
+		return func(t *testing.T) {
+			client := createClient(t, mf)
+
+			// Prep
+			err := client.Set("foo", test.Foo{Bar: "baz"})
+			if err != nil {
+				t.Error(err)
+			}
+
+			_, err = client.Get("foo", nil) // actually nil
+			if err == nil {
+				t.Error("An error was expected")
+			}
+
+			var i interface{} // actually nil
+			_, err = client.Get("foo", i)
+			if err == nil {
+				t.Error("An error was expected")
+			}
+
+			var valPtr *test.Foo // nil value
+			_, err = client.Get("foo", valPtr)
+			if err == nil {
+				t.Error("An error was expected")
+			}
+		}
+	}
+	t.Run("get with nil / nil value parameter", createTest(nats.JSON))
+	t.Run("get with nil / nil value parameter", createTest(nats.Gob))
+}
+
+// TestClose tests if the close method returns any errors.
+func TestClose(t *testing.T) {
+	client := createClient(t, nats.JSON)
+	err := client.Close()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// createClient returns a client that's connected to the embedded NATS server.
+// The bucket is dropped first, so that each test starts with an empty store.
+func createClient(t *testing.T, mf nats.MarshalFormat) nats.Client {
+	options := nats.Options{
+		URL:           serverURL,
+		MarshalFormat: mf,
+	}
+	err := nats.Drop(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := nats.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
@@ -0,0 +1,10 @@
+/*
+Package postgresql contains an implementation of the `gokv.Store` interface for PostgreSQL.
+
+With the JSON marshal format the values are stored in a JSONB column, so they can be queried with SQL, for example:
+
+	SELECT k FROM Item WHERE v->>'Bar' = 'baz'
+
+With the gob marshal format they're stored in a BYTEA column.
+*/
+package postgresql
@@ -0,0 +1,367 @@
+package postgresql_test
+
+import (
+	"database/sql"
+	"log"
+	"testing"
+
+	_ "github.com/lib/pq"
+
+	"github.com/philippgille/gokv"
+	"github.com/philippgille/gokv/postgresql"
+	"github.com/philippgille/gokv/test"
+)
+
+// TestClient tests if reading from, writing to and deleting from the store works properly.
+// A struct is used as value. See TestTypes() for a test that is simpler but tests all types.
+//
+// Note: This test is only executed if the initial connection to PostgreSQL works.
+func TestClient(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to PostgreSQL could be established. Probably not running in a proper test environment.")
+	}
+
+	// Test with JSON
+	t.Run("JSON", func(t *testing.T) {
+		client := createClient(t, postgresql.JSON)
+		test.TestStore(client, t)
+	})
+
+	// Test with gob
+	t.Run("gob", func(t *testing.T) {
+		client := createClient(t, postgresql.Gob)
+		test.TestStore(client, t)
+	})
+}
+
+// TestTypes tests if setting and getting values works with all Go types.
+//
+// Note: This test is only executed if the initial connection to PostgreSQL works.
+func TestTypes(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to PostgreSQL could be established. Probably not running in a proper test environment.")
+	}
+
+	// Test with JSON
+	t.Run("JSON", func(t *testing.T) {
+		client := createClient(t, postgresql.JSON)
+		test.TestTypes(client, t)
+	})
+
+	// Test with gob
+	t.Run("gob", func(t *testing.T) {
+		client := createClient(t, postgresql.Gob)
+		test.TestTypes(client, t)
+	})
+}
+
+// TestClientConcurrent launches a bunch of goroutines that concurrently work with the PostgreSQL client.
+//
+// Note: This test is only executed if the initial connection to PostgreSQL works.
+func TestClientConcurrent(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to PostgreSQL could be established. Probably not running in a proper test environment.")
+	}
+
+	client := createClient(t, postgresql.JSON)
+
+	goroutineCount := 1000
+
+	test.TestConcurrentInteractions(t, goroutineCount, client)
+}
+
+// TestErrors tests some error cases.
+//
+// Note: This test is only executed if the initial connection to PostgreSQL works.
+func TestErrors(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to PostgreSQL could be established. Probably not running in a proper test environment.")
+	}
+
+	// Test with a bad MarshalFormat enum value.
+	// The column type depends on the marshal format, so the error already occurs when creating the client.
+
+	_, err := postgresql.NewClient(postgresql.Options{MarshalFormat: postgresql.MarshalFormat(19)})
+	if err == nil {
+		t.Error("An error should have occurred, but didn't")
+	}
+
+	client := createClient(t, postgresql.JSON)
+
+	// Test empty key
+	err = client.Set("", "bar")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	_, err = client.Get("", new(string))
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	err = client.Delete("")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+// TestNil tests the behaviour when passing nil or pointers to nil values to some methods.
+//
+// Note: This test is only executed if the initial connection to PostgreSQL works.
+func TestNil(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to PostgreSQL could be established. Probably not running in a proper test environment.")
+	}
+
+	// Test setting nil
+
+	t.Run("set nil with JSON marshalling", func(t *testing.T) {
+		client := createClient(t, postgresql.JSON)
+		err := client.Set("foo", nil)
+		if err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	t.Run("set nil with Gob marshalling", func(t *testing.T) {
+		client := createClient(t, postgresql.Gob)
+		err := client.Set("foo", nil)
+		if err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	// Test passing nil or pointer to nil value for retrieval
+
+	createTest := func(mf postgresql.MarshalFormat) func(t *testing.T) {
+		return func(t *testing.T) {
+			client := createClient(t, mf)
+
+			// Prep
+			err := client.Set("foo", test.Foo{Bar: "baz"})
+			if err != nil {
+				t.Error(err)
+			}
+
+			_, err = client.Get("foo", nil) // actually nil
+			if err == nil {
+				t.Error("An error was expected")
+			}
+
+			var i interface{} // actually nil
+			_, err = client.Get("foo", i)
+			if err == nil {
+				t.Error("An error was expected")
+			}
+
+			var valPtr *test.Foo // nil value
+			_, err = client.Get("foo", valPtr)
+			if err == nil {
+				t.Error("An error was expected")
+			}
+		}
+	}
+	t.Run("get with nil / nil value parameter", createTest(postgresql.JSON))
+	t.Run("get with nil / nil value parameter", createTest(postgresql.Gob))
+}
+
+// TestDBcreation tests if the DB gets created successfully when the DSN doesn't contain one.
+// The other tests call createClient, which doesn't use any DSN,
+// which leads to the gokv implementation to use "postgres://postgres@localhost/gokv?sslmode=disable" by default.
+//
+// Note: This test is only executed if the initial connection to PostgreSQL works.
+func TestDBcreation(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to PostgreSQL could be established. Probably not running in a proper test environment.")
+	}
+
+	options := postgresql.Options{
+		DataSourceName: "user=postgres host=localhost sslmode=disable",
+	}
+	client, err := postgresql.NewClient(options)
+	if err != nil {
+		t.Error(err)
+	}
+	err = client.Set("foo", "bar")
+	if err != nil {
+		t.Error(err)
+	}
+	actualPtr := new(string)
+	found, err := client.Get("foo", actualPtr)
+	if !found {
+		t.Error("Value not found, but should've been.")
+	}
+	if err != nil {
+		t.Error(err)
+	}
+	actual := *actualPtr
+	if actual != "bar" {
+		t.Errorf("Expected %v, but was: %v", "bar", actual)
+	}
+}
+
+// TestClose tests if the close method returns any errors.
+//
+// Note: This test is only executed if the initial connection to PostgreSQL works.
+func TestClose(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to PostgreSQL could be established. Probably not running in a proper test environment.")
+	}
+
+	client := createClient(t, postgresql.JSON)
+	err := client.Close()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// TestJSONB tests if values that are stored with the JSON marshal format can be queried with SQL.
+//
+// Note: This test is only executed if the initial connection to PostgreSQL works.
+func TestJSONB(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to PostgreSQL could be established. Probably not running in a proper test environment.")
+	}
+
+	client := createClient(t, postgresql.JSON)
+	err := client.Set("jsonb", test.Foo{Bar: "jsonb"})
+	if err != nil {
+		t.Error(err)
+	}
+
+	db, err := sql.Open("postgres", postgresql.DefaultOptions.DataSourceName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var k string
+	err = db.QueryRow("SELECT k FROM "+postgresql.DefaultOptions.TableName+" WHERE v->>'Bar' = $1", "jsonb").Scan(&k)
+	if err != nil {
+		t.Error(err)
+	}
+	if k != "jsonb" {
+		t.Errorf("Expected %v, but was %v", "jsonb", k)
+	}
+}
+
+// TestDefaultMaxOpenConnections tests if the client works with the default options.
+//
+// Note: This test is only executed if the initial connection to PostgreSQL works.
+func TestDefaultMaxOpenConnections(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to PostgreSQL could be established. Probably not running in a proper test environment.")
+	}
+
+	options := postgresql.Options{}
+	client, err := postgresql.NewClient(options)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = client.Set("foo", "bar")
+	if err != nil {
+		t.Error(err)
+	}
+	vPtr := new(string)
+	found, err := client.Get("foo", vPtr)
+	if err != nil {
+		t.Error(err)
+	}
+	if !found {
+		t.Error("A value should have been found, but wasn't.")
+	}
+	if *vPtr != "bar" {
+		t.Errorf("Expectec %v, but was %v", "bar", *vPtr)
+	}
+}
+
+// checkConnection returns true if a connection could be made, false otherwise.
+// TestProvisioning tests if NewClient() returns a *gokv.ResourceNotFoundError when automatic provisioning is disabled
+// and if Provision() and Drop() create and delete the table.
+//
+// Note: This test is only executed if the initial connection to PostgreSQL works.
+func TestProvisioning(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to PostgreSQL could be established. Probably not running in a proper test environment.")
+	}
+
+	// Non-existing database
+	options := postgresql.Options{
+		DataSourceName:          "postgres://postgres@localhost/gokv_nonexistent?sslmode=disable",
+		DisableAutoProvisioning: true,
+	}
+	_, err := postgresql.NewClient(options)
+	if notFoundErr, ok := err.(*gokv.ResourceNotFoundError); !ok {
+		t.Errorf("Expected a *gokv.ResourceNotFoundError, but was: %v", err)
+	} else if notFoundErr.ResourceType != "database" {
+		t.Errorf("Expected %v, but was %v", "database", notFoundErr.ResourceType)
+	}
+
+	// Non-existing table
+	options = postgresql.Options{
+		TableName:               "ItemProvisioning",
+		DisableAutoProvisioning: true,
+	}
+	err = postgresql.Drop(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = postgresql.NewClient(options)
+	if notFoundErr, ok := err.(*gokv.ResourceNotFoundError); !ok {
+		t.Errorf("Expected a *gokv.ResourceNotFoundError, but was: %v", err)
+	} else if notFoundErr.ResourceType != "table" {
+		t.Errorf("Expected %v, but was %v", "table", notFoundErr.ResourceType)
+	}
+
+	// Provisioned table
+	err = postgresql.Provision(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := postgresql.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.TestStore(client, t)
+	client.Close()
+
+	// Dropped table
+	err = postgresql.Drop(options)
+	if err != nil {
+		t.Error(err)
+	}
+	_, err = postgresql.NewClient(options)
+	if _, ok := err.(*gokv.ResourceNotFoundError); !ok {
+		t.Errorf("Expected a *gokv.ResourceNotFoundError, but was: %v", err)
+	}
+}
+
+func checkConnection() bool {
+	db, err := sql.Open("postgres", "postgres://postgres@localhost/postgres?sslmode=disable")
+	if err != nil {
+		log.Printf("An error occurred during testing the connection to the server: %v\n", err)
+		return false
+	}
+
+	err = db.Ping()
+	if err != nil {
+		log.Printf("An error occurred during testing the connection to the server: %v\n", err)
+		return false
+	}
+
+	return true
+}
+
+func createClient(t *testing.T, mf postgresql.MarshalFormat) postgresql.Client {
+	options := postgresql.Options{
+		MaxOpenConnections: 25,
+	}
+	options.MarshalFormat = mf
+	// The column type depends on the marshal format, so the table can't be shared.
+	if mf == postgresql.Gob {
+		options.TableName = "ItemGob"
+	}
+	client, err := postgresql.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
@@ -0,0 +1,17 @@
+package gokv
+
+// ResourceNotFoundError is returned by the NewClient() functions of gokv.Store implementations
+// when the automatic provisioning is disabled and a resource that the store requires,
+// like a database or table, doesn't exist.
+// Such resources can be created with the Provision() function of the respective package.
+type ResourceNotFoundError struct {
+	// Type of the resource, for example "database" or "table".
+	ResourceType string
+	// Name of the resource.
+	Name string
+}
+
+// Error returns a description of the missing resource.
+func (e *ResourceNotFoundError) Error() string {
+	return "The " + e.ResourceType + " \"" + e.Name + "\" doesn't exist and automatic provisioning is disabled"
+}
@@ -1,4 +1,34 @@
 /*
 Package redis contains an implementation of the `gokv.Store` interface for Redis.
+
+By default every value is marshalled and stored as a single string.
+With the Hashes option, structs are stored as Redis hashes instead, which allows other tools to read individual fields
+and SetFields to update single fields without rewriting the whole value.
+Every exported struct field is stored in a hash field with the name of the struct field,
+which can be changed with a `redis:"name"` tag. Fields with a `redis:"-"` tag are skipped.
+String fields are stored as they are, all other fields are marshalled with the configured marshal format.
+
+With the AutoPipelining option, commands that are issued concurrently by multiple goroutines
+are collected for a short time and sent to Redis in a single pipeline, which reduces the number of round trips.
+The benchmarks in this package compare the throughput with and without auto-pipelining:
+
+	go test -run=NONE -bench=. ./redis
+
+They call Get or Set from 50 goroutines per CPU, with the default connection pool of 10 connections per CPU.
+With one CPU and a server that's reached with a round-trip time of about 2.2 ms, auto-pipelining increased the throughput about fourfold:
+
+	BenchmarkGet                 278 µs/op
+	BenchmarkGetAutoPipelining    70 µs/op
+	BenchmarkSet                 302 µs/op
+	BenchmarkSetAutoPipelining    69 µs/op
+
+Without network latency (the same machine, round-trip time below 0.1 ms), there was no gain:
+Get took 16 µs/op without and 18 µs/op with auto-pipelining, Set 18 µs/op and 17 µs/op.
+Both measurements used the in-memory Redis implementation miniredis, so the server's processing time was negligible.
+Auto-pipelining pays off when the round-trip time dominates, which is the case for most servers that are reached via a network.
+
+With the ReadFromReplicas option, Get reads from replicas, while writes and locks always go to the master.
+Replication is asynchronous, so a value that was just set might not be readable from a replica yet.
+Reads from replicas aren't auto-pipelined.
 */
 package redis
@@ -0,0 +1,129 @@
+package redis
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/go-redis/redis"
+
+	"github.com/philippgille/gokv/util"
+)
+
+// Key of the struct tag for renaming the hash field of a struct field.
+const hashTagKey = "redis"
+
+// SetFields stores the given values in the fields of the hash that's stored for the given key.
+// Other fields of the hash stay unchanged. If no hash exists for the key yet, it's created.
+// The map keys are the names of the hash fields, see the package documentation for how they're derived from struct fields.
+// Values are converted the same way as struct fields in the hash mode.
+// This works independent of the Hashes option, but the key must not be stored as string.
+// The key must not be "", the map must not be empty and its values must not be nil.
+func (c Client) SetFields(k string, fields map[string]interface{}) error {
+	if err := util.CheckKey(k); err != nil {
+		return err
+	}
+	if len(fields) == 0 {
+		return errors.New("The fields must not be empty")
+	}
+
+	args := make([]interface{}, 0, 2+2*len(fields))
+	args = append(args, "hmset", k)
+	for name, v := range fields {
+		if err := util.CheckVal(v); err != nil {
+			return err
+		}
+		value, err := c.marshalField(reflect.ValueOf(v))
+		if err != nil {
+			return err
+		}
+		args = append(args, name, value)
+	}
+
+	return c.process(redis.NewStatusCmd(args...))
+}
+
+// setHash stores the exported fields of the given struct (or pointer to a struct) as hash.
+// Fields of a previously stored value are removed.
+func (c Client) setHash(k string, v interface{}) error {
+	structVal := reflect.Indirect(reflect.ValueOf(v))
+	if !structVal.IsValid() {
+		return errors.New("The value must not be a nil pointer")
+	}
+	hash := make(map[string]interface{})
+	for _, field := range util.StructFields(structVal.Type(), hashTagKey) {
+		value, err := c.marshalField(structVal.Field(field.Index))
+		if err != nil {
+			return err
+		}
+		hash[field.Name] = value
+	}
+
+	// Delete and create the hash in a transaction, so that other clients never see a partial value.
+	_, err := c.c.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Del(k)
+		pipe.HMSet(k, hash)
+		return nil
+	})
+	return err
+}
+
+// getHash populates the fields of the struct that v points to with the fields of the hash.
+// Hash fields without a corresponding struct field are ignored.
+func (c Client) getHash(k string, v interface{}) (found bool, err error) {
+	cmd := redis.NewStringStringMapCmd("hgetall", k)
+	if err := c.processRead(cmd); err != nil {
+		return false, err
+	}
+	hash, err := cmd.Result()
+	if err != nil {
+		return false, err
+	}
+	// Redis doesn't differentiate between empty and non-existing hashes
+	if len(hash) == 0 {
+		return false, nil
+	}
+
+	structVal := reflect.ValueOf(v).Elem()
+	for _, field := range util.StructFields(structVal.Type(), hashTagKey) {
+		value, ok := hash[field.Name]
+		if !ok {
+			continue
+		}
+		if err := c.unmarshalField(value, structVal.Field(field.Index)); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// marshalField turns the given value into a string for a hash field.
+// Strings are stored as they are, so they're readable for other tools,
+// all other values are marshalled with the configured marshal format.
+func (c Client) marshalField(v reflect.Value) (string, error) {
+	if v.Kind() == reflect.String {
+		return v.String(), nil
+	}
+	data, err := c.marshal(v.Interface())
+	return string(data), err
+}
+
+// unmarshalField is the reverse of marshalField.
+func (c Client) unmarshalField(value string, v reflect.Value) error {
+	if v.Kind() == reflect.String {
+		v.SetString(value)
+		return nil
+	}
+	return c.unmarshal([]byte(value), v.Addr().Interface())
+}
+
+// isHashable returns true if values of the given type are stored as hash in the hash mode.
+func isHashable(t reflect.Type) bool {
+	return len(util.StructFields(t, hashTagKey)) > 0
+}
+
+// isWrongType returns true if the error is Redis' error for an operation
+// against a key holding the wrong kind of value.
+func isWrongType(err error) bool {
+	return strings.HasPrefix(err.Error(), "WRONGTYPE")
+}
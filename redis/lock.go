@@ -0,0 +1,67 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+
+	"github.com/philippgille/gokv"
+)
+
+// lockPrefix is the prefix of the keys in which locks are stored,
+// so that lock names don't collide with the keys of key-value pairs.
+const lockPrefix = "gokv-lock:"
+
+// Lua scripts that only modify the lock if it still holds the token.
+const (
+	refreshLockScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("pexpire", KEYS[1], ARGV[2]) else return 0 end`
+	releaseLockScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`
+)
+
+// Lock acquires the lock with the given name.
+// The lock is stored in the key "gokv-lock:" + name with an expiry of gokv.DefaultLockTTL,
+// which is extended in the background while the lock is held.
+// It blocks until the lock is acquired or the context is done.
+//
+// Note: With Redis Sentinel or replication in general, a lock can be lost on failover
+// because the replication is asynchronous.
+func (c Client) Lock(ctx context.Context, name string) (gokv.Lock, error) {
+	return gokv.AcquireTTLLock(ctx, lockBackend{c: c}, name, gokv.DefaultLockTTL)
+}
+
+// lockBackend implements gokv.TTLLockBackend for Redis.
+type lockBackend struct {
+	c Client
+}
+
+func (b lockBackend) TryLock(name, token string, ttl time.Duration) (bool, error) {
+	cmd := redis.NewStatusCmd("set", lockPrefix+name, token, "px", milliseconds(ttl), "nx")
+	err := b.c.process(cmd)
+	// Redis returns nil if the key wasn't set
+	if err == redis.Nil {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (b lockBackend) RefreshLock(name, token string, ttl time.Duration) (bool, error) {
+	cmd := redis.NewIntCmd("eval", refreshLockScript, 1, lockPrefix+name, token, milliseconds(ttl))
+	err := b.c.process(cmd)
+	if err != nil {
+		return false, err
+	}
+	return cmd.Val() == 1, nil
+}
+
+func (b lockBackend) ReleaseLock(name, token string) error {
+	return b.c.process(redis.NewIntCmd("eval", releaseLockScript, 1, lockPrefix+name, token))
+}
+
+// milliseconds returns the duration in milliseconds as string.
+func milliseconds(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Millisecond), 10)
+}
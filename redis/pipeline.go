@@ -0,0 +1,122 @@
+package redis
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// pipelineRequest is a command that waits for being sent in a pipeline.
+type pipelineRequest struct {
+	cmd  redis.Cmder
+	done chan struct{}
+}
+
+// autoPipeliner collects commands from concurrent callers and sends them in pipelines.
+// A pipeline is sent when it contains batchSize commands or when window elapsed after its first command.
+// Multiple pipelines can be in flight at the same time, so collecting the next pipeline
+// doesn't have to wait for the previous one.
+type autoPipeliner struct {
+	client    redis.UniversalClient
+	window    time.Duration
+	batchSize int
+	requests  chan pipelineRequest
+	closing   chan struct{}
+	closeOnce sync.Once
+	// Done when the collecting goroutine stopped
+	stopped chan struct{}
+	// Pipelines that are in flight
+	inFlight sync.WaitGroup
+}
+
+func newAutoPipeliner(client redis.UniversalClient, window time.Duration, batchSize int) *autoPipeliner {
+	p := &autoPipeliner{
+		client:    client,
+		window:    window,
+		batchSize: batchSize,
+		requests:  make(chan pipelineRequest),
+		closing:   make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// process adds the given command to the next pipeline and blocks until the pipeline was executed.
+// The result can be read from the command afterwards.
+func (p *autoPipeliner) process(cmd redis.Cmder) error {
+	req := pipelineRequest{
+		cmd:  cmd,
+		done: make(chan struct{}),
+	}
+	select {
+	case p.requests <- req:
+	case <-p.closing:
+		return errors.New("The client is closed")
+	}
+	<-req.done
+	return cmd.Err()
+}
+
+// run collects the requests into batches until the pipeliner is closed.
+func (p *autoPipeliner) run() {
+	defer close(p.stopped)
+	timer := time.NewTimer(p.window)
+	for {
+		var batch []pipelineRequest
+		select {
+		case req := <-p.requests:
+			batch = append(batch, req)
+		case <-p.closing:
+			return
+		}
+
+		// Stop and drain the timer before resetting it, see the documentation of timer.Reset().
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(p.window)
+	collect:
+		for len(batch) < p.batchSize {
+			select {
+			case req := <-p.requests:
+				batch = append(batch, req)
+			case <-timer.C:
+				break collect
+			}
+		}
+
+		p.inFlight.Add(1)
+		go p.exec(batch)
+	}
+}
+
+// exec sends the commands of the batch in a single pipeline and notifies the callers.
+func (p *autoPipeliner) exec(batch []pipelineRequest) {
+	defer p.inFlight.Done()
+	pipe := p.client.Pipeline()
+	for _, req := range batch {
+		pipe.Process(req.cmd)
+	}
+	// Errors are also set in the individual commands, which the callers check.
+	pipe.Exec()
+	pipe.Close()
+	for _, req := range batch {
+		close(req.done)
+	}
+}
+
+// close stops accepting new commands and waits until all pending pipelines are executed.
+// It can be called multiple times.
+func (p *autoPipeliner) close() {
+	p.closeOnce.Do(func() {
+		close(p.closing)
+	})
+	<-p.stopped
+	p.inFlight.Wait()
+}
@@ -1,7 +1,11 @@
 package redis
 
 import (
+	"crypto/tls"
 	"errors"
+	"reflect"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-redis/redis"
 
@@ -9,38 +13,42 @@ import (
 )
 
 // Client is a gokv.Store implementation for Redis.
+// It works with a single Redis server, a Redis Cluster or a master set that's managed by Redis Sentinel.
+// It also implements gokv.Locker.
 type Client struct {
-	c             *redis.Client
+	c redis.UniversalClient
+	// Clients for the ReplicaAddresses. Empty if reads go to c.
+	replicas []*redis.Client
+	// Index of the replica for the next read, used round-robin.
+	nextReplica   *uint32
+	hashes        bool
+	pipeliner     *autoPipeliner
 	marshalFormat MarshalFormat
 }
 
 // Set stores the given value for the given key.
 // Values are automatically marshalled to JSON or gob (depending on the configuration).
+// With the Hashes option, structs are stored as hashes instead (see the package documentation).
 // The key must not be "" and the value must not be nil.
 func (c Client) Set(k string, v interface{}) error {
 	if err := util.CheckKeyAndValue(k, v); err != nil {
 		return err
 	}
 
+	if c.hashes && isHashable(reflect.TypeOf(v)) {
+		return c.setHash(k, v)
+	}
+
 	// First turn the passed object into something that Redis can handle
 	// (the Set method takes an interface{}, but the Get method only returns a string,
 	// so it can be assumed that the interface{} parameter type is only for convenience
 	// for a couple of builtin types like int etc.).
-	var data []byte
-	var err error
-	switch c.marshalFormat {
-	case JSON:
-		data, err = util.ToJSON(v)
-	case Gob:
-		data, err = util.ToGob(v)
-	default:
-		err = errors.New("The store seems to be configured with a marshal format that's not implemented yet")
-	}
+	data, err := c.marshal(v)
 	if err != nil {
 		return err
 	}
 
-	err = c.c.Set(k, string(data), 0).Err()
+	err = c.process(redis.NewStatusCmd("set", k, string(data)))
 	if err != nil {
 		return err
 	}
@@ -51,6 +59,7 @@ func (c Client) Set(k string, v interface{}) error {
 // You need to pass a pointer to the value, so in case of a struct
 // the automatic unmarshalling can populate the fields of the object
 // that v points to with the values of the retrieved object's values.
+// With ReadFromReplicas, the value is read from a replica.
 // If no value is found it returns (false, nil).
 // The key must not be "" and the pointer must not be nil.
 func (c Client) Get(k string, v interface{}) (found bool, err error) {
@@ -58,22 +67,30 @@ func (c Client) Get(k string, v interface{}) (found bool, err error) {
 		return false, err
 	}
 
-	data, err := c.c.Get(k).Result()
-	if err != nil {
+	if c.hashes && isHashable(reflect.TypeOf(v)) {
+		found, err = c.getHash(k, v)
+		// The value might have been stored as string,
+		// for example before the hash mode was activated.
+		if err == nil || !isWrongType(err) {
+			return found, err
+		}
+	}
+
+	// The error of processRead must be checked, because commands that aren't executed at all,
+	// for example after the auto-pipelining client is closed, have an empty result without error.
+	cmd := redis.NewStringCmd("get", k)
+	if err := c.processRead(cmd); err != nil {
 		if err == redis.Nil {
 			return false, nil
 		}
 		return false, err
 	}
-
-	switch c.marshalFormat {
-	case JSON:
-		return true, util.FromJSON([]byte(data), v)
-	case Gob:
-		return true, util.FromGob([]byte(data), v)
-	default:
-		return true, errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	data, err := cmd.Result()
+	if err != nil {
+		return false, err
 	}
+
+	return true, c.unmarshal([]byte(data), v)
 }
 
 // Delete deletes the stored value for the given key.
@@ -84,16 +101,66 @@ func (c Client) Delete(k string) error {
 		return err
 	}
 
-	_, err := c.c.Del(k).Result()
-	return err
+	return c.process(redis.NewIntCmd("del", k))
 }
 
 // Close closes the client.
 // It must be called to release any open resources.
+// With auto-pipelining, it waits until all pending commands are executed.
 func (c Client) Close() error {
+	if c.pipeliner != nil {
+		c.pipeliner.close()
+	}
+	for _, replica := range c.replicas {
+		replica.Close()
+	}
 	return c.c.Close()
 }
 
+// process sends the given command to Redis, either directly or via the auto-pipeliner.
+// The result can be read from the command afterwards.
+func (c Client) process(cmd redis.Cmder) error {
+	if c.pipeliner != nil {
+		return c.pipeliner.process(cmd)
+	}
+	return c.c.Process(cmd)
+}
+
+// processRead sends the given read-only command to the next replica if ReplicaAddresses are configured,
+// otherwise it's the same as process.
+// Commands for replicas don't use the auto-pipeliner.
+func (c Client) processRead(cmd redis.Cmder) error {
+	if len(c.replicas) == 0 {
+		return c.process(cmd)
+	}
+	i := atomic.AddUint32(c.nextReplica, 1) % uint32(len(c.replicas))
+	return c.replicas[i].Process(cmd)
+}
+
+// marshal marshals the given value according to the configured marshal format.
+func (c Client) marshal(v interface{}) ([]byte, error) {
+	switch c.marshalFormat {
+	case JSON:
+		return util.ToJSON(v)
+	case Gob:
+		return util.ToGob(v)
+	default:
+		return nil, errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	}
+}
+
+// unmarshal unmarshals the given data according to the configured marshal format.
+func (c Client) unmarshal(data []byte, v interface{}) error {
+	switch c.marshalFormat {
+	case JSON:
+		return util.FromJSON(data, v)
+	case Gob:
+		return util.FromGob(data, v)
+	default:
+		return errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	}
+}
+
 // MarshalFormat is an enum for the available (un-)marshal formats of this gokv.Store implementation.
 type MarshalFormat int
 
@@ -105,30 +172,92 @@ const (
 )
 
 // Options are the options for the Redis client.
+// By default the client connects to a single Redis server.
+// To connect to a Redis Cluster, set ClusterAddresses.
+// To connect to a master set that's managed by Redis Sentinel, set MasterName and SentinelAddresses.
 type Options struct {
 	// Address of the Redis server, including the port.
+	// Ignored when connecting to a Redis Cluster or via Redis Sentinel.
 	// Optional ("localhost:6379" by default).
 	Address string
-	// Password for the Redis server.
+	// Addresses of nodes of a Redis Cluster, including the port.
+	// The other nodes of the cluster are discovered automatically.
+	// Optional (nil by default, which means that no cluster is used).
+	ClusterAddresses []string
+	// Name of the master set that's managed by Redis Sentinel.
+	// Optional ("" by default, which means that Sentinel isn't used).
+	MasterName string
+	// Addresses of the Sentinel servers, including the port.
+	// Required when MasterName is set.
+	SentinelAddresses []string
+	// Username for the ACL system of Redis 6 and newer.
+	// Optional ("" by default, which means the "default" user).
+	Username string
+	// Password for the Redis server(s).
 	// Optional ("" by default).
 	Password string
 	// DB to use.
+	// Redis Cluster only supports DB 0.
 	// Optional (0 by default).
 	DB int
+	// TLS configuration for connecting to the Redis server(s).
+	// Optional (nil by default, which means that TLS isn't used).
+	TLSConfig *tls.Config
+	// If true, structs (and pointers to structs) are stored as Redis hashes,
+	// with one hash field per exported struct field, so that other tools can read individual fields
+	// and SetFields can update them.
+	// See the package documentation for details.
+	// Other values are still stored as strings.
+	// Optional (false by default).
+	Hashes bool
+	// If true, commands that are issued concurrently by multiple goroutines are collected
+	// and sent to Redis in a single pipeline, which reduces the number of round trips.
+	// This increases the throughput under concurrent load, but adds up to PipelineWindow of latency to every command.
+	// Storing structs as hashes doesn't use the auto-pipelining, because it requires a transaction.
+	// Optional (false by default).
+	AutoPipelining bool
+	// Maximum time to wait for more commands before a pipeline is sent.
+	// Only used with AutoPipelining.
+	// Optional (100 * time.Microsecond by default).
+	PipelineWindow time.Duration
+	// Maximum number of commands in a pipeline.
+	// A pipeline is sent as soon as it's full, even if the PipelineWindow didn't elapse yet.
+	// Only used with AutoPipelining.
+	// Optional (100 by default).
+	PipelineBatchSize int
+	// If true, Get() reads from replicas, while all other commands are still sent to the master.
+	// With a Redis Cluster, the replicas of each master are discovered automatically (by sending READONLY to them).
+	// Otherwise ReplicaAddresses must be set.
+	// Replication is asynchronous, so reads can return stale data, for example right after a write.
+	// Optional (false by default).
+	ReadFromReplicas bool
+	// Addresses of replicas of the Redis server or of the master set that's managed by Redis Sentinel, including the port.
+	// Reads are distributed round-robin across them.
+	// The Username, Password, DB and TLSConfig are the same as for the master.
+	// Only used with ReadFromReplicas and not allowed with a Redis Cluster.
+	// Optional (nil by default).
+	ReplicaAddresses []string
 	// (Un-)marshal format.
 	// Optional (JSON by default).
 	MarshalFormat MarshalFormat
 }
 
 // DefaultOptions is an Options object with default values.
-// Address: "localhost:6379", Password: "", DB: 0, MarshalFormat: JSON
+// Address: "localhost:6379", ClusterAddresses: nil, MasterName: "", SentinelAddresses: nil,
+// Username: "", Password: "", DB: 0, TLSConfig: nil, Hashes: false,
+// AutoPipelining: false, PipelineWindow: 100 * time.Microsecond, PipelineBatchSize: 100,
+// ReadFromReplicas: false, ReplicaAddresses: nil, MarshalFormat: JSON
 var DefaultOptions = Options{
-	Address: "localhost:6379",
-	// No need to set Password, DB or MarshalFormat
+	Address:           "localhost:6379",
+	PipelineWindow:    100 * time.Microsecond,
+	PipelineBatchSize: 100,
+	// No need to set the other options
 	// because their Go zero values are fine for that.
 }
 
 // NewClient creates a new Redis client.
+// Depending on the options it connects to a single Redis server, a Redis Cluster
+// or the current master of a master set that's managed by Redis Sentinel.
 func NewClient(options Options) (Client, error) {
 	result := Client{}
 
@@ -136,19 +265,114 @@ func NewClient(options Options) (Client, error) {
 	if options.Address == "" {
 		options.Address = DefaultOptions.Address
 	}
+	if options.PipelineWindow == 0 {
+		options.PipelineWindow = DefaultOptions.PipelineWindow
+	}
+	if options.PipelineBatchSize == 0 {
+		options.PipelineBatchSize = DefaultOptions.PipelineBatchSize
+	}
 
-	client := redis.NewClient(&redis.Options{
-		Addr:     options.Address,
-		Password: options.Password,
-		DB:       options.DB,
-	})
+	// Validate options
+	if len(options.ClusterAddresses) > 0 && options.MasterName != "" {
+		return result, errors.New("ClusterAddresses and MasterName must not be set at the same time")
+	}
+	if len(options.ClusterAddresses) > 0 && options.DB != 0 {
+		return result, errors.New("Redis Cluster only supports DB 0")
+	}
+	if options.MasterName != "" && len(options.SentinelAddresses) == 0 {
+		return result, errors.New("SentinelAddresses must be set when MasterName is set")
+	}
+	if len(options.ReplicaAddresses) > 0 && !options.ReadFromReplicas {
+		return result, errors.New("ReplicaAddresses are only used when ReadFromReplicas is set")
+	}
+	if len(options.ReplicaAddresses) > 0 && len(options.ClusterAddresses) > 0 {
+		return result, errors.New("ReplicaAddresses must not be set for a Redis Cluster, because its replicas are discovered automatically")
+	}
+	if options.ReadFromReplicas && len(options.ClusterAddresses) == 0 && len(options.ReplicaAddresses) == 0 {
+		return result, errors.New("ReplicaAddresses must be set when ReadFromReplicas is set without a Redis Cluster")
+	}
+
+	// With a username, the AUTH command must be sent with two arguments, which the Redis client library doesn't do.
+	// So in that case authenticate and select the DB on every new connection instead.
+	password := options.Password
+	db := options.DB
+	var onConnect func(*redis.Conn) error
+	if options.Username != "" {
+		password = ""
+		db = 0
+		onConnect = func(conn *redis.Conn) error {
+			err := conn.Process(redis.NewStatusCmd("auth", options.Username, options.Password))
+			if err != nil {
+				return err
+			}
+			if options.DB != 0 {
+				return conn.Select(options.DB).Err()
+			}
+			return nil
+		}
+	}
+
+	var client redis.UniversalClient
+	if len(options.ClusterAddresses) > 0 {
+		client = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:     options.ClusterAddresses,
+			ReadOnly:  options.ReadFromReplicas,
+			Password:  password,
+			TLSConfig: options.TLSConfig,
+			OnConnect: onConnect,
+		})
+	} else if options.MasterName != "" {
+		client = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:    options.MasterName,
+			SentinelAddrs: options.SentinelAddresses,
+			Password:      password,
+			DB:            db,
+			TLSConfig:     options.TLSConfig,
+			OnConnect:     onConnect,
+		})
+	} else {
+		client = redis.NewClient(&redis.Options{
+			Addr:      options.Address,
+			Password:  password,
+			DB:        db,
+			TLSConfig: options.TLSConfig,
+			OnConnect: onConnect,
+		})
+	}
 
 	err := client.Ping().Err()
 	if err != nil {
+		client.Close()
 		return result, err
 	}
 
+	var replicas []*redis.Client
+	for _, address := range options.ReplicaAddresses {
+		replica := redis.NewClient(&redis.Options{
+			Addr:      address,
+			Password:  password,
+			DB:        db,
+			TLSConfig: options.TLSConfig,
+			OnConnect: onConnect,
+		})
+		replicas = append(replicas, replica)
+		err = replica.Ping().Err()
+		if err != nil {
+			for _, replica := range replicas {
+				replica.Close()
+			}
+			client.Close()
+			return result, err
+		}
+	}
+
 	result.c = client
+	result.replicas = replicas
+	result.nextReplica = new(uint32)
+	result.hashes = options.Hashes
+	if options.AutoPipelining {
+		result.pipeliner = newAutoPipeliner(client, options.PipelineWindow, options.PipelineBatchSize)
+	}
 	result.marshalFormat = options.MarshalFormat
 
 	return result, nil
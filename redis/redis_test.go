@@ -1,7 +1,13 @@
 package redis_test
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
 	"log"
+	"os"
+	"reflect"
+	"strings"
 	"testing"
 
 	goredis "github.com/go-redis/redis"
@@ -75,6 +81,19 @@ func TestClientConcurrent(t *testing.T) {
 	test.TestConcurrentInteractions(t, goroutineCount, client)
 }
 
+// TestLock tests if the locks provide mutual exclusion.
+//
+// Note: This test is only executed if the initial connection to Redis works.
+func TestLock(t *testing.T) {
+	if !checkConnection(testDbNumber) {
+		t.Skip("No connection to Redis could be established. Probably not running in a proper test environment.")
+	}
+	deleteRedisDb(testDbNumber) // Prep for previous test runs
+
+	client := createClient(t, redis.JSON)
+	test.TestLocker(client, t)
+}
+
 // TestErrors tests some error cases.
 //
 // Note: This test is only executed if the initial connection to Redis works.
@@ -113,6 +132,315 @@ func TestErrors(t *testing.T) {
 	}
 }
 
+// TestHashes tests if the store works properly when structs are stored as hashes.
+//
+// Note: This test is only executed if the initial connection to Redis works.
+func TestHashes(t *testing.T) {
+	if !checkConnection(testDbNumber) {
+		t.Skip("No connection to Redis could be established. Probably not running in a proper test environment.")
+	}
+	deleteRedisDb(testDbNumber) // Prep for previous test runs
+
+	for _, mf := range []redis.MarshalFormat{redis.JSON, redis.Gob} {
+		client := createHashClient(t, mf)
+		test.TestStore(client, t)
+		test.TestTypes(client, t)
+	}
+}
+
+type hashFoo struct {
+	Name    string
+	Count   int `redis:"count"`
+	Tags    []string
+	Ignored string `redis:"-"`
+}
+
+// TestSetFields tests if struct fields are stored as hash fields and if SetFields updates single fields.
+//
+// Note: This test is only executed if the initial connection to Redis works.
+func TestSetFields(t *testing.T) {
+	if !checkConnection(testDbNumber) {
+		t.Skip("No connection to Redis could be established. Probably not running in a proper test environment.")
+	}
+	deleteRedisDb(testDbNumber) // Prep for previous test runs
+
+	client := createHashClient(t, redis.JSON)
+	goredisClient := goredis.NewClient(&goredis.Options{
+		Addr: redis.DefaultOptions.Address,
+		DB:   testDbNumber,
+	})
+	defer goredisClient.Close()
+
+	err := client.Set("foo", hashFoo{Name: "bar", Count: 1, Tags: []string{"a"}, Ignored: "baz"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Other tools can read the fields
+	hash, err := goredisClient.HGetAll("foo").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedHash := map[string]string{"Name": "bar", "count": "1", "Tags": `["a"]`}
+	if !reflect.DeepEqual(hash, expectedHash) {
+		t.Errorf("Expected: %v, but was: %v", expectedHash, hash)
+	}
+
+	err = client.SetFields("foo", map[string]interface{}{"count": 2})
+	if err != nil {
+		t.Error(err)
+	}
+	actual := hashFoo{}
+	found, err := client.Get("foo", &actual)
+	if err != nil {
+		t.Error(err)
+	}
+	if !found {
+		t.Error("No value was found, but should have been")
+	}
+	expected := hashFoo{Name: "bar", Count: 2, Tags: []string{"a"}}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected: %+v, but was: %+v", expected, actual)
+	}
+
+	// A value that was stored as string can still be retrieved as struct
+	stringClient := createClient(t, redis.JSON)
+	err = stringClient.Set("foo", expected)
+	if err != nil {
+		t.Error(err)
+	}
+	actual = hashFoo{}
+	_, err = client.Get("foo", &actual)
+	if err != nil {
+		t.Error(err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected: %+v, but was: %+v", expected, actual)
+	}
+
+	// Errors
+	err = client.SetFields("foo", map[string]interface{}{"count": 3})
+	if err == nil {
+		t.Error("Expected an error, because the value is stored as string")
+	}
+	err = client.SetFields("", map[string]interface{}{"count": 3})
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	err = client.SetFields("foo", nil)
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	err = client.SetFields("foo", map[string]interface{}{"count": nil})
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+// TestAutoPipelining tests if the store works properly when commands are automatically pipelined.
+//
+// Note: This test is only executed if the initial connection to Redis works.
+func TestAutoPipelining(t *testing.T) {
+	if !checkConnection(testDbNumber) {
+		t.Skip("No connection to Redis could be established. Probably not running in a proper test environment.")
+	}
+	deleteRedisDb(testDbNumber) // Prep for previous test runs
+
+	options := redis.Options{
+		DB:             testDbNumber,
+		AutoPipelining: true,
+	}
+	client, err := redis.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test.TestStore(client, t)
+	test.TestTypes(client, t)
+	test.TestConcurrentInteractions(t, 1000, client)
+
+	err = client.Close()
+	if err != nil {
+		t.Error(err)
+	}
+	// Commands after closing the client must lead to an error instead of blocking
+	err = client.Set("foo", "bar")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	found, err := client.Get("foo", new(string))
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	if found {
+		t.Error("A value was found, but shouldn't have been")
+	}
+}
+
+// TestReadFromReplicas tests if the client works when reads are sent to replicas.
+// The test environment only has a single Redis server, so it's used as its own replica.
+//
+// Note: This test is only executed if the initial connection to Redis works.
+func TestReadFromReplicas(t *testing.T) {
+	if !checkConnection(testDbNumber) {
+		t.Skip("No connection to Redis could be established. Probably not running in a proper test environment.")
+	}
+	deleteRedisDb(testDbNumber) // Prep for previous test runs
+
+	options := redis.Options{
+		DB:               testDbNumber,
+		ReadFromReplicas: true,
+		ReplicaAddresses: []string{"localhost:6379", "localhost:6379"},
+	}
+	client, err := redis.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	test.TestStore(client, t)
+	test.TestTypes(client, t)
+
+	// An unreachable replica must lead to an error
+	options.ReplicaAddresses = []string{"localhost:1"}
+	_, err = redis.NewClient(options)
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+// TestCluster tests if the client works with a Redis Cluster.
+// The addresses of one or more cluster nodes must be configured in the environment variable
+// "REDIS_CLUSTER_ADDRESSES", separated by commas.
+// Redis Cluster only supports DB 0, so the test writes to DB 0 of the cluster.
+//
+// Note: This test is only executed if the environment variable is set.
+func TestCluster(t *testing.T) {
+	addresses := os.Getenv("REDIS_CLUSTER_ADDRESSES")
+	if addresses == "" {
+		t.Skip("No Redis Cluster is configured. Probably not running in a proper test environment.")
+	}
+
+	options := redis.Options{
+		ClusterAddresses: strings.Split(addresses, ","),
+	}
+	client, err := redis.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	test.TestStore(client, t)
+}
+
+// TestSentinel tests if the client works with a master set that's managed by Redis Sentinel.
+// The addresses of the Sentinel servers must be configured in the environment variable
+// "REDIS_SENTINEL_ADDRESSES", separated by commas,
+// and the name of the master set in "REDIS_MASTER_NAME".
+//
+// Note: This test is only executed if the environment variables are set.
+func TestSentinel(t *testing.T) {
+	addresses := os.Getenv("REDIS_SENTINEL_ADDRESSES")
+	masterName := os.Getenv("REDIS_MASTER_NAME")
+	if addresses == "" || masterName == "" {
+		t.Skip("No Redis Sentinel is configured. Probably not running in a proper test environment.")
+	}
+
+	options := redis.Options{
+		MasterName:        masterName,
+		SentinelAddresses: strings.Split(addresses, ","),
+		DB:                testDbNumber,
+	}
+	client, err := redis.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	test.TestStore(client, t)
+}
+
+// TestTLS tests if the client can connect to a Redis server that requires TLS.
+// The address of the server must be configured in the environment variable "REDIS_TLS_ADDRESS"
+// and the CA certificate that signed the server's certificate in "REDIS_TLS_CA_FILE".
+//
+// Note: This test is only executed if the environment variables are set.
+func TestTLS(t *testing.T) {
+	address := os.Getenv("REDIS_TLS_ADDRESS")
+	caFile := os.Getenv("REDIS_TLS_CA_FILE")
+	if address == "" || caFile == "" {
+		t.Skip("No Redis server with TLS is configured. Probably not running in a proper test environment.")
+	}
+
+	caCert, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM(caCert) {
+		t.Fatal("The CA file doesn't contain a valid certificate")
+	}
+	options := redis.Options{
+		Address:   address,
+		DB:        testDbNumber,
+		TLSConfig: &tls.Config{RootCAs: rootCAs},
+	}
+	client, err := redis.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	test.TestStore(client, t)
+}
+
+// TestUsername tests if the client can authenticate as a user of the ACL system of Redis 6 and newer.
+// The user must be allowed to run all commands on all keys,
+// and its name and password must be configured in the environment variables
+// "REDIS_ACL_USERNAME" and "REDIS_ACL_PASSWORD".
+//
+// Note: This test is only executed if the environment variables are set.
+func TestUsername(t *testing.T) {
+	username := os.Getenv("REDIS_ACL_USERNAME")
+	if username == "" {
+		t.Skip("No Redis ACL user is configured. Probably not running in a proper test environment.")
+	}
+
+	options := redis.Options{
+		Username: username,
+		Password: os.Getenv("REDIS_ACL_PASSWORD"),
+		DB:       testDbNumber,
+	}
+	client, err := redis.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	test.TestStore(client, t)
+
+	// A wrong password must lead to an error
+	options.Password += "wrong"
+	_, err = redis.NewClient(options)
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+// TestBadOptions tests if invalid combinations of options lead to an error.
+// They're detected before connecting, so this test doesn't require a running Redis server.
+func TestBadOptions(t *testing.T) {
+	badOptions := []redis.Options{
+		{ClusterAddresses: []string{"localhost:7000"}, MasterName: "mymaster", SentinelAddresses: []string{"localhost:26379"}},
+		{ClusterAddresses: []string{"localhost:7000"}, DB: testDbNumber},
+		{MasterName: "mymaster"},
+		{ReplicaAddresses: []string{"localhost:6380"}},
+		{ReadFromReplicas: true},
+		{ClusterAddresses: []string{"localhost:7000"}, ReadFromReplicas: true, ReplicaAddresses: []string{"localhost:7001"}},
+	}
+	for _, options := range badOptions {
+		_, err := redis.NewClient(options)
+		if err == nil {
+			t.Errorf("Expected an error for options %+v", options)
+		}
+	}
+}
+
 // TestNil tests the behaviour when passing nil or pointers to nil values to some methods.
 //
 // Note: This test is only executed if the initial connection to Redis works.
@@ -226,3 +554,97 @@ func createClient(t *testing.T, mf redis.MarshalFormat) redis.Client {
 	}
 	return client
 }
+
+func createHashClient(t *testing.T, mf redis.MarshalFormat) redis.Client {
+	options := redis.Options{
+		DB:            testDbNumber,
+		Hashes:        true,
+		MarshalFormat: mf,
+	}
+	client, err := redis.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// BenchmarkGet and BenchmarkGetAutoPipelining compare the throughput of concurrent Get calls
+// with and without auto-pipelining. Run them with:
+//
+//	go test -run=NONE -bench=Get ./redis
+//
+// Note: These benchmarks are only executed if the initial connection to Redis works.
+func BenchmarkGet(b *testing.B) {
+	benchmarkGet(b, false)
+}
+
+func BenchmarkGetAutoPipelining(b *testing.B) {
+	benchmarkGet(b, true)
+}
+
+// BenchmarkSet and BenchmarkSetAutoPipelining compare the throughput of concurrent Set calls
+// with and without auto-pipelining.
+//
+// Note: These benchmarks are only executed if the initial connection to Redis works.
+func BenchmarkSet(b *testing.B) {
+	benchmarkSet(b, false)
+}
+
+func BenchmarkSetAutoPipelining(b *testing.B) {
+	benchmarkSet(b, true)
+}
+
+// benchmarkParallelism is multiplied with GOMAXPROCS to get the number of goroutines
+// that call the client concurrently.
+const benchmarkParallelism = 50
+
+func benchmarkGet(b *testing.B, autoPipelining bool) {
+	client := createBenchmarkClient(b, autoPipelining)
+	defer client.Close()
+	err := client.Set("foo", test.Foo{Bar: "baz"})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.SetParallelism(benchmarkParallelism)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, err := client.Get("foo", new(test.Foo))
+			if err != nil {
+				b.Error(err)
+			}
+		}
+	})
+}
+
+func benchmarkSet(b *testing.B, autoPipelining bool) {
+	client := createBenchmarkClient(b, autoPipelining)
+	defer client.Close()
+
+	b.SetParallelism(benchmarkParallelism)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			err := client.Set("foo", test.Foo{Bar: "baz"})
+			if err != nil {
+				b.Error(err)
+			}
+		}
+	})
+}
+
+func createBenchmarkClient(b *testing.B, autoPipelining bool) redis.Client {
+	if !checkConnection(testDbNumber) {
+		b.Skip("No connection to Redis could be established. Probably not running in a proper test environment.")
+	}
+	options := redis.Options{
+		DB:             testDbNumber,
+		AutoPipelining: autoPipelining,
+	}
+	client, err := redis.NewClient(options)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return client
+}
@@ -0,0 +1,6 @@
+/*
+Package s3 contains an implementation of the `gokv.Store` interface for Amazon S3 and other S3-compatible object storages like MinIO.
+
+Each key-value pair is stored as an object, so unlike with DynamoDB the values can be very large (up to 5 GB).
+*/
+package s3
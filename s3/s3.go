@@ -0,0 +1,482 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/philippgille/gokv"
+	"github.com/philippgille/gokv/util"
+)
+
+// Client is a gokv.Store implementation for S3.
+// It also implements gokv.Lister.
+type Client struct {
+	c              *awss3.S3
+	bucketName     string
+	prefix         string
+	sse            string
+	sseKMSkeyID    string
+	sseCustomerKey string
+	marshalFormat  MarshalFormat
+}
+
+// Set stores the given value for the given key.
+// Values are automatically marshalled to JSON or gob (depending on the configuration).
+// The key must not be "" and the value must not be nil.
+func (c Client) Set(k string, v interface{}) error {
+	if err := util.CheckKeyAndValue(k, v); err != nil {
+		return err
+	}
+
+	// First turn the passed object into something that S3 can handle
+	var data []byte
+	var contentType string
+	var err error
+	switch c.marshalFormat {
+	case JSON:
+		data, err = util.ToJSON(v)
+		contentType = "application/json"
+	case Gob:
+		data, err = util.ToGob(v)
+		contentType = "application/octet-stream"
+	default:
+		err = errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	}
+	if err != nil {
+		return err
+	}
+
+	putObjectInput := awss3.PutObjectInput{
+		Bucket:      &c.bucketName,
+		Key:         aws.String(c.prefix + k),
+		Body:        bytes.NewReader(data),
+		ContentType: &contentType,
+	}
+	if c.sse != "" {
+		putObjectInput.ServerSideEncryption = &c.sse
+		if c.sseKMSkeyID != "" {
+			putObjectInput.SSEKMSKeyId = &c.sseKMSkeyID
+		}
+	}
+	if c.sseCustomerKey != "" {
+		putObjectInput.SSECustomerAlgorithm = aws.String(awss3.ServerSideEncryptionAes256)
+		putObjectInput.SSECustomerKey = &c.sseCustomerKey
+	}
+	_, err = c.c.PutObject(&putObjectInput)
+	return err
+}
+
+// Get retrieves the stored value for the given key.
+// You need to pass a pointer to the value, so in case of a struct
+// the automatic unmarshalling can populate the fields of the object
+// that v points to with the values of the retrieved object's values.
+// If no value is found it returns (false, nil).
+// If the bucket doesn't exist (anymore), an error is returned.
+// The key must not be "" and the pointer must not be nil.
+func (c Client) Get(k string, v interface{}) (found bool, err error) {
+	if err := util.CheckKeyAndValue(k, v); err != nil {
+		return false, err
+	}
+
+	getObjectInput := awss3.GetObjectInput{
+		Bucket: &c.bucketName,
+		Key:    aws.String(c.prefix + k),
+	}
+	// Objects that are encrypted with a customer-provided key can only be read with the same key
+	if c.sseCustomerKey != "" {
+		getObjectInput.SSECustomerAlgorithm = aws.String(awss3.ServerSideEncryptionAes256)
+		getObjectInput.SSECustomerKey = &c.sseCustomerKey
+	}
+	getObjectOutput, err := c.c.GetObject(&getObjectInput)
+	if err != nil {
+		// Return false if the key-value pair doesn't exist
+		if isKeyNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer getObjectOutput.Body.Close()
+	data, err := ioutil.ReadAll(getObjectOutput.Body)
+	if err != nil {
+		return false, err
+	}
+
+	switch c.marshalFormat {
+	case JSON:
+		return true, util.FromJSON(data, v)
+	case Gob:
+		return true, util.FromGob(data, v)
+	default:
+		return true, errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	}
+}
+
+// Delete deletes the stored value for the given key.
+// Deleting a non-existing key-value pair does NOT lead to an error.
+// The key must not be "".
+func (c Client) Delete(k string) error {
+	if err := util.CheckKey(k); err != nil {
+		return err
+	}
+
+	deleteObjectInput := awss3.DeleteObjectInput{
+		Bucket: &c.bucketName,
+		Key:    aws.String(c.prefix + k),
+	}
+	_, err := c.c.DeleteObject(&deleteObjectInput)
+	return err
+}
+
+// List calls fn for every key in the bucket that starts with the configured prefix.
+// The prefix is removed from the keys before fn is called.
+// The keys are retrieved in pages of up to 1000 keys, so the bucket can contain more keys than fit into memory.
+// If fn returns an error, the iteration is stopped and the error is returned.
+func (c Client) List(fn func(k string) error) error {
+	listObjectsInput := awss3.ListObjectsV2Input{
+		Bucket: &c.bucketName,
+	}
+	if c.prefix != "" {
+		listObjectsInput.Prefix = &c.prefix
+	}
+	var fnErr error
+	err := c.c.ListObjectsV2Pages(&listObjectsInput, func(page *awss3.ListObjectsV2Output, lastPage bool) bool {
+		for _, object := range page.Contents {
+			if fnErr = fn((*object.Key)[len(c.prefix):]); fnErr != nil {
+				return false
+			}
+		}
+		return true
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	return err
+}
+
+// Close closes the client.
+// In the S3 implementation this doesn't have any effect.
+func (c Client) Close() error {
+	return nil
+}
+
+// isBucketNotFound returns true if the error indicates that the bucket doesn't exist.
+// HEAD requests don't have a response body, so the SDK reports them with the generic "NotFound" code.
+func isBucketNotFound(err error) bool {
+	if awsErr, ok := err.(awserr.Error); ok {
+		switch awsErr.Code() {
+		case awss3.ErrCodeNoSuchBucket, "NotFound":
+			return true
+		}
+	}
+	return false
+}
+
+// isKeyNotFound returns true if the error indicates that an object doesn't exist.
+// Errors for a missing bucket are NOT included, so that a misconfiguration isn't hidden behind a (false, nil) result.
+func isKeyNotFound(err error) bool {
+	awsErr, ok := err.(awserr.Error)
+	return ok && awsErr.Code() == awss3.ErrCodeNoSuchKey
+}
+
+// MarshalFormat is an enum for the available (un-)marshal formats of this gokv.Store implementation.
+type MarshalFormat int
+
+const (
+	// JSON is the MarshalFormat for (un-)marshalling to/from JSON
+	JSON MarshalFormat = iota
+	// Gob is the MarshalFormat for (un-)marshalling to/from gob
+	Gob
+)
+
+// Options are the options for the S3 client.
+type Options struct {
+	// Name of the S3 bucket.
+	// If the bucket doesn't exist yet, gokv creates it (see DisableAutoProvisioning).
+	// Optional ("gokv" by default).
+	BucketName string
+	// Prefix that's prepended to all keys, for example "cache/".
+	// This allows multiple stores to share a bucket.
+	// The combined length of prefix and key must not exceed 1024 bytes.
+	// Optional ("" by default).
+	Prefix string
+	// Region of the S3 service you want to use.
+	// Valid values: https://docs.aws.amazon.com/general/latest/gr/rande.html#s3_region.
+	// E.g. "us-west-2".
+	// Optional (read from shared config file or environment variable if not set).
+	// Environment variable: "AWS_REGION".
+	Region string
+	// AWS access key ID (part of the credentials).
+	// Optional (read from shared credentials file or environment variable if not set).
+	// Environment variable: "AWS_ACCESS_KEY_ID".
+	AWSaccessKeyID string
+	// AWS secret access key (part of the credentials).
+	// Optional (read from shared credentials file or environment variable if not set).
+	// Environment variable: "AWS_SECRET_ACCESS_KEY".
+	AWSsecretAccessKey string
+	// CustomEndpoint allows you to set a custom S3 service endpoint.
+	// This is especially useful if you're using an S3-compatible object storage like MinIO.
+	// Typical value for the MinIO Docker container: "http://localhost:9000".
+	// See https://hub.docker.com/r/minio/minio/.
+	// Optional ("" by default)
+	CustomEndpoint string
+	// Use path-style URLs ("https://endpoint/bucket/key") instead of virtual-hosted-style URLs ("https://bucket.endpoint/key").
+	// Most S3-compatible object storages like MinIO require it.
+	// Optional (false by default).
+	ForcePathStyle bool
+	// Server-side encryption with keys that are managed by S3 or KMS.
+	// Valid values: "AES256" for S3-managed keys, "aws:kms" for KMS-managed keys.
+	// Optional ("" by default, which leads to the bucket's default encryption being used).
+	ServerSideEncryption string
+	// ID of the KMS key for server-side encryption.
+	// Only used when ServerSideEncryption is "aws:kms".
+	// Optional ("" by default, which leads to the default KMS key of the account being used).
+	SSEKMSKeyID string
+	// Customer-provided 256 bit key for server-side encryption (SSE-C).
+	// S3 doesn't store the key, so the values can only be retrieved with the same key.
+	// Requires an HTTPS endpoint. Can't be combined with ServerSideEncryption.
+	// Optional ("" by default).
+	SSECustomerKey string
+	// (Un-)marshal format.
+	// Optional (JSON by default).
+	MarshalFormat MarshalFormat
+	// Prevents NewClient() from creating the bucket.
+	// Instead, it returns a *gokv.ResourceNotFoundError if the bucket doesn't exist.
+	// The bucket can then be created separately with Provision().
+	// Optional (false by default).
+	DisableAutoProvisioning bool
+}
+
+// DefaultOptions is an Options object with default values.
+// BucketName: "gokv", Prefix: "", Region: "" (use shared config file or environment variable),
+// AWSaccessKeyID: "" (use shared credentials file or environment variable),
+// AWSsecretAccessKey: "" (use shared credentials file or environment variable),
+// CustomEndpoint: "", ForcePathStyle: false, ServerSideEncryption: "", SSEKMSKeyID: "", SSECustomerKey: "", MarshalFormat: JSON,
+// DisableAutoProvisioning: false
+var DefaultOptions = Options{
+	BucketName: "gokv",
+	// No need to set the other fields because their Go zero values are fine.
+}
+
+// NewClient creates a new S3 client.
+// Unless DisableAutoProvisioning is set, the bucket is created if it doesn't exist yet.
+//
+// Credentials can be set in the options, but it's recommended to either use the shared credentials file
+// (Linux: "~/.aws/credentials", Windows: "%UserProfile%\.aws\credentials")
+// or environment variables (AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY).
+// See https://github.com/awsdocs/aws-go-developer-guide/blob/0ae5712d120d43867cf81de875cb7505f62f2d71/doc_source/configuring-sdk.rst#specifying-credentials.
+func NewClient(options Options) (Client, error) {
+	result := Client{}
+
+	// Set default values
+	options = setDefaults(options)
+	if options.ServerSideEncryption != "" && options.SSECustomerKey != "" {
+		return result, errors.New("ServerSideEncryption and SSECustomerKey can't be combined")
+	}
+	if options.SSECustomerKey != "" && len(options.SSECustomerKey) != 32 {
+		return result, errors.New("The SSECustomerKey must be 256 bits (32 bytes) long")
+	}
+
+	svc, err := newService(options)
+	if err != nil {
+		return result, err
+	}
+
+	// Create bucket if it doesn't exist.
+	// Also serves as connection test.
+	// Use context for timeout.
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	headBucketInput := awss3.HeadBucketInput{
+		Bucket: &options.BucketName,
+	}
+	_, err = svc.HeadBucketWithContext(timeoutCtx, &headBucketInput)
+	if err != nil {
+		if !isBucketNotFound(err) {
+			return result, err
+		}
+		if options.DisableAutoProvisioning {
+			return result, &gokv.ResourceNotFoundError{ResourceType: "bucket", Name: options.BucketName}
+		}
+		createBucketInput := awss3.CreateBucketInput{
+			Bucket: &options.BucketName,
+		}
+		// Outside of us-east-1 the region must be set explicitly
+		region := aws.StringValue(svc.Config.Region)
+		if region != "" && region != "us-east-1" {
+			createBucketInput.CreateBucketConfiguration = &awss3.CreateBucketConfiguration{
+				LocationConstraint: &region,
+			}
+		}
+		_, err = svc.CreateBucketWithContext(timeoutCtx, &createBucketInput)
+		if err != nil {
+			// Another client could have created the bucket in the meantime
+			if awsErr, ok := err.(awserr.Error); !ok || awsErr.Code() != awss3.ErrCodeBucketAlreadyOwnedByYou {
+				return result, err
+			}
+		}
+	}
+
+	result.c = svc
+	result.bucketName = options.BucketName
+	result.prefix = options.Prefix
+	result.sse = options.ServerSideEncryption
+	result.sseKMSkeyID = options.SSEKMSKeyID
+	result.sseCustomerKey = options.SSECustomerKey
+	result.marshalFormat = options.MarshalFormat
+
+	return result, nil
+}
+
+// Provision creates the bucket for the given options if it doesn't exist yet,
+// the same way NewClient() does.
+// It's meant to be called once in a separate setup step,
+// for example with credentials that have more permissions than the ones that are used by the application.
+// DisableAutoProvisioning is ignored.
+func Provision(options Options) error {
+	options.DisableAutoProvisioning = false
+	_, err := NewClient(options)
+	return err
+}
+
+// Drop deletes all objects whose keys start with the configured Prefix, which are the key-value pairs of the store.
+// The bucket itself is only deleted when it's empty afterwards,
+// so other stores that share the bucket with a different Prefix keep their data.
+// Dropping a non-existing bucket does NOT lead to an error.
+func Drop(options Options) error {
+	options = setDefaults(options)
+	svc, err := newService(options)
+	if err != nil {
+		return err
+	}
+
+	err = deleteObjects(svc, options.BucketName, options.Prefix)
+	if isBucketNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// Objects with other prefixes can only remain when a Prefix is configured
+	if options.Prefix != "" {
+		listObjectsInput := awss3.ListObjectsV2Input{
+			Bucket:  &options.BucketName,
+			MaxKeys: aws.Int64(1),
+		}
+		listObjectsOutput, err := svc.ListObjectsV2(&listObjectsInput)
+		if isBucketNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if len(listObjectsOutput.Contents) > 0 {
+			return nil
+		}
+	}
+
+	deleteBucketInput := awss3.DeleteBucketInput{
+		Bucket: &options.BucketName,
+	}
+	_, err = svc.DeleteBucket(&deleteBucketInput)
+	if isBucketNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// deleteObjects deletes all objects in the bucket whose keys start with the given prefix.
+// The objects are deleted in batches of up to 1000 keys (the maximum of a single DeleteObjects request).
+func deleteObjects(svc *awss3.S3, bucketName, prefix string) error {
+	listObjectsInput := awss3.ListObjectsV2Input{
+		Bucket: &bucketName,
+	}
+	if prefix != "" {
+		listObjectsInput.Prefix = &prefix
+	}
+	var deleteErr error
+	err := svc.ListObjectsV2Pages(&listObjectsInput, func(page *awss3.ListObjectsV2Output, lastPage bool) bool {
+		if len(page.Contents) == 0 {
+			return true
+		}
+		objects := make([]*awss3.ObjectIdentifier, len(page.Contents))
+		for i, object := range page.Contents {
+			objects[i] = &awss3.ObjectIdentifier{Key: object.Key}
+		}
+		deleteObjectsInput := awss3.DeleteObjectsInput{
+			Bucket: &bucketName,
+			Delete: &awss3.Delete{
+				Objects: objects,
+				Quiet:   aws.Bool(true),
+			},
+		}
+		output, err := svc.DeleteObjects(&deleteObjectsInput)
+		if err != nil {
+			deleteErr = err
+			return false
+		}
+		if len(output.Errors) > 0 {
+			deleteErr = errors.New("Couldn't delete object " + aws.StringValue(output.Errors[0].Key) + ": " + aws.StringValue(output.Errors[0].Message))
+			return false
+		}
+		return true
+	})
+	if deleteErr != nil {
+		return deleteErr
+	}
+	return err
+}
+
+// setDefaults returns a copy of the given options with default values for all unset fields.
+func setDefaults(options Options) Options {
+	if options.BucketName == "" {
+		options.BucketName = DefaultOptions.BucketName
+	}
+	return options
+}
+
+// newService creates an S3 service client from the region, credentials and endpoint of the given options.
+func newService(options Options) (*awss3.S3, error) {
+	// Set credentials only if set in the options.
+	// If not set, the SDK uses the shared credentials file or environment variables, which is the preferred way.
+	// Return an error if only one of the values is set.
+	var creds *credentials.Credentials
+	if (options.AWSaccessKeyID != "" && options.AWSsecretAccessKey == "") || (options.AWSaccessKeyID == "" && options.AWSsecretAccessKey != "") {
+		return nil, errors.New("When passing credentials via options, you need to set BOTH AWSaccessKeyID AND AWSsecretAccessKey")
+	} else if options.AWSaccessKeyID != "" {
+		// Due to the previous check we can be sure that in this case AWSsecretAccessKey is not empty as well.
+		creds = credentials.NewStaticCredentials(options.AWSaccessKeyID, options.AWSsecretAccessKey, "")
+	}
+
+	config := aws.NewConfig()
+	if options.Region != "" {
+		config = config.WithRegion(options.Region)
+	}
+	if creds != nil {
+		config = config.WithCredentials(creds)
+	}
+	if options.CustomEndpoint != "" {
+		config = config.WithEndpoint(options.CustomEndpoint)
+	}
+	if options.ForcePathStyle {
+		config = config.WithS3ForcePathStyle(true)
+	}
+	// Use shared config file...
+	sessionOpts := session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	}
+	// ...but allow overwrite of region and credentials if they are set in the options.
+	sessionOpts.Config.MergeIn(config)
+	session, err := session.NewSessionWithOptions(sessionOpts)
+	if err != nil {
+		return nil, err
+	}
+	return awss3.New(session), nil
+}
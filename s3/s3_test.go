@@ -0,0 +1,492 @@
+package s3_test
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/session"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/philippgille/gokv"
+	"github.com/philippgille/gokv/s3"
+	"github.com/philippgille/gokv/test"
+)
+
+// For the MinIO Docker container, started with:
+// docker run -d --rm -p 9000:9000 -e MINIO_ACCESS_KEY=gokvuser -e MINIO_SECRET_KEY=gokvsecret minio/minio server /data
+// See https://hub.docker.com/r/minio/minio/.
+var customEndpoint = "http://localhost:9000"
+
+const (
+	accessKeyID     = "gokvuser"
+	secretAccessKey = "gokvsecret"
+)
+
+// TestClient tests if reading from, writing to and deleting from the store works properly.
+// A struct is used as value. See TestTypes() for a test that is simpler but tests all types.
+//
+// Note: This test is only executed if the initial connection to S3 works.
+func TestClient(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to S3 could be established. Probably not running in a proper test environment.")
+	}
+
+	// Test with JSON
+	t.Run("JSON", func(t *testing.T) {
+		client := createClient(t, s3.JSON)
+		test.TestStore(client, t)
+	})
+
+	// Test with gob
+	t.Run("gob", func(t *testing.T) {
+		client := createClient(t, s3.Gob)
+		test.TestStore(client, t)
+	})
+}
+
+// TestTypes tests if setting and getting values works with all Go types.
+//
+// Note: This test is only executed if the initial connection to S3 works.
+func TestTypes(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to S3 could be established. Probably not running in a proper test environment.")
+	}
+
+	// Test with JSON
+	t.Run("JSON", func(t *testing.T) {
+		client := createClient(t, s3.JSON)
+		test.TestTypes(client, t)
+	})
+
+	// Test with gob
+	t.Run("gob", func(t *testing.T) {
+		client := createClient(t, s3.Gob)
+		test.TestTypes(client, t)
+	})
+}
+
+// TestClientConcurrent launches a bunch of goroutines that concurrently work with the S3 client.
+//
+// Note: This test is only executed if the initial connection to S3 works.
+func TestClientConcurrent(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to S3 could be established. Probably not running in a proper test environment.")
+	}
+
+	client := createClient(t, s3.JSON)
+
+	goroutineCount := 1000
+
+	test.TestConcurrentInteractions(t, goroutineCount, client)
+}
+
+// TestList tests if the keys with the configured prefix are listed.
+//
+// Note: This test is only executed if the initial connection to S3 works.
+func TestList(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to S3 could be established. Probably not running in a proper test environment.")
+	}
+
+	// Use a unique prefix, because other tests use the same bucket
+	prefix := "list" + strconv.FormatInt(time.Now().UnixNano(), 10) + "/"
+	client := createClientWithPrefix(t, s3.JSON, prefix)
+	test.TestList(client, t)
+}
+
+// TestServerSideEncryption tests if values can be stored and retrieved with server-side encryption.
+//
+// Note: This test is only executed if the initial connection to S3 works.
+func TestServerSideEncryption(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to S3 could be established. Probably not running in a proper test environment.")
+	}
+
+	options := s3.Options{
+		Region:               endpoints.UsEast1RegionID,
+		AWSaccessKeyID:       accessKeyID,
+		AWSsecretAccessKey:   secretAccessKey,
+		CustomEndpoint:       customEndpoint,
+		ForcePathStyle:       true,
+		ServerSideEncryption: awss3.ServerSideEncryptionAes256,
+	}
+	client, err := s3.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Set("sse", test.Foo{Bar: "baz"})
+	if err != nil {
+		// MinIO only supports server-side encryption when it's configured with a KMS
+		t.Skipf("Server-side encryption doesn't seem to be supported by the server: %v", err)
+	}
+	actualPtr := new(test.Foo)
+	found, err := client.Get("sse", actualPtr)
+	if err != nil {
+		t.Error(err)
+	}
+	if !found {
+		t.Error("No value was found, but should have been")
+	}
+	if actualPtr.Bar != "baz" {
+		t.Errorf("Expected: %v, but was: %v", "baz", actualPtr.Bar)
+	}
+}
+
+// TestProvisioning tests if NewClient() returns a *gokv.ResourceNotFoundError
+// when automatic provisioning is disabled and the bucket doesn't exist,
+// and if Provision() and Drop() create and delete the bucket.
+//
+// Note: This test is only executed if the initial connection to S3 works.
+func TestProvisioning(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to S3 could be established. Probably not running in a proper test environment.")
+	}
+
+	options := s3.Options{
+		BucketName:              "gokv-provisioning",
+		Region:                  endpoints.UsEast1RegionID,
+		AWSaccessKeyID:          accessKeyID,
+		AWSsecretAccessKey:      secretAccessKey,
+		CustomEndpoint:          customEndpoint,
+		ForcePathStyle:          true,
+		DisableAutoProvisioning: true,
+	}
+	err := s3.Drop(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s3.NewClient(options)
+	if notFoundErr, ok := err.(*gokv.ResourceNotFoundError); !ok {
+		t.Errorf("Expected a *gokv.ResourceNotFoundError, but was: %v", err)
+	} else if notFoundErr.Name != options.BucketName {
+		t.Errorf("Expected %v, but was %v", options.BucketName, notFoundErr.Name)
+	}
+
+	err = s3.Provision(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := s3.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.TestStore(client, t)
+
+	// Drop() must also delete the bucket when it still contains objects
+	err = client.Set("foo", "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s3.Drop(options)
+	if err != nil {
+		t.Error(err)
+	}
+	_, err = s3.NewClient(options)
+	if _, ok := err.(*gokv.ResourceNotFoundError); !ok {
+		t.Errorf("Expected a *gokv.ResourceNotFoundError, but was: %v", err)
+	}
+}
+
+// TestDropWithPrefix tests if Drop() only deletes the objects of the store with the configured prefix
+// when multiple stores share a bucket, and if it deletes the bucket after the last store was dropped.
+//
+// Note: This test is only executed if the initial connection to S3 works.
+func TestDropWithPrefix(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to S3 could be established. Probably not running in a proper test environment.")
+	}
+
+	optionsA := s3.Options{
+		BucketName:         "gokv-shared",
+		Prefix:             "a/",
+		Region:             endpoints.UsEast1RegionID,
+		AWSaccessKeyID:     accessKeyID,
+		AWSsecretAccessKey: secretAccessKey,
+		CustomEndpoint:     customEndpoint,
+		ForcePathStyle:     true,
+	}
+	optionsB := optionsA
+	optionsB.Prefix = "b/"
+	clientA, err := s3.NewClient(optionsA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientB, err := s3.NewClient(optionsB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = clientA.Set("foo", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = clientB.Set("foo", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s3.Drop(optionsA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := clientA.Get("foo", new(string))
+	if err != nil {
+		t.Error(err)
+	}
+	if found {
+		t.Error("A value was found, but shouldn't have been")
+	}
+	actual := ""
+	found, err = clientB.Get("foo", &actual)
+	if err != nil {
+		t.Error(err)
+	}
+	if !found {
+		t.Error("No value was found, but should have been")
+	}
+	if actual != "b" {
+		t.Errorf("Expected: %v, but was: %v", "b", actual)
+	}
+
+	// Now the bucket is empty and must be deleted as well
+	err = s3.Drop(optionsB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	optionsB.DisableAutoProvisioning = true
+	_, err = s3.NewClient(optionsB)
+	if _, ok := err.(*gokv.ResourceNotFoundError); !ok {
+		t.Errorf("Expected a *gokv.ResourceNotFoundError, but was: %v", err)
+	}
+}
+
+// TestMissingBucket tests if Get() returns an error instead of (false, nil)
+// when the bucket doesn't exist (anymore).
+//
+// Note: This test is only executed if the initial connection to S3 works.
+func TestMissingBucket(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to S3 could be established. Probably not running in a proper test environment.")
+	}
+
+	options := s3.Options{
+		BucketName:         "gokv-missing",
+		Region:             endpoints.UsEast1RegionID,
+		AWSaccessKeyID:     accessKeyID,
+		AWSsecretAccessKey: secretAccessKey,
+		CustomEndpoint:     customEndpoint,
+		ForcePathStyle:     true,
+	}
+	client, err := s3.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := client.Get("foo", new(string))
+	if err != nil {
+		t.Error(err)
+	}
+	if found {
+		t.Error("A value was found, but shouldn't have been")
+	}
+
+	err = s3.Drop(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err = client.Get("foo", new(string))
+	if err == nil {
+		t.Error("An error should have occurred, but didn't")
+	}
+	if found {
+		t.Error("A value was found, but shouldn't have been")
+	}
+}
+
+// TestErrors tests some error cases.
+//
+// Note: This test is only executed if the initial connection to S3 works.
+func TestErrors(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to S3 could be established. Probably not running in a proper test environment.")
+	}
+
+	// Test with a bad MarshalFormat enum value
+
+	client := createClient(t, s3.MarshalFormat(19))
+	err := client.Set("foo", "bar")
+	if err == nil {
+		t.Error("An error should have occurred, but didn't")
+	}
+
+	// Test empty key
+	err = client.Set("", "bar")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	_, err = client.Get("", new(string))
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	err = client.Delete("")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+
+	// Test client creation with bad options
+	options := s3.Options{
+		AWSaccessKeyID: "foo",
+	}
+	client, err = s3.NewClient(options)
+	if err.Error() != "When passing credentials via options, you need to set BOTH AWSaccessKeyID AND AWSsecretAccessKey" {
+		t.Error("An error was expected, but didn't occur.")
+	}
+	options = s3.Options{
+		AWSsecretAccessKey: "foo",
+	}
+	client, err = s3.NewClient(options)
+	if err.Error() != "When passing credentials via options, you need to set BOTH AWSaccessKeyID AND AWSsecretAccessKey" {
+		t.Error("An error was expected, but didn't occur.")
+	}
+	// Server-side encryption options that can't be combined
+	options = s3.Options{
+		ServerSideEncryption: "AES256",
+		SSECustomerKey:       "0123456789abcdef0123456789abcdef",
+	}
+	_, err = s3.NewClient(options)
+	if err == nil {
+		t.Error("An error was expected, but didn't occur.")
+	}
+	// Customer-provided key with wrong length
+	options = s3.Options{
+		SSECustomerKey: "foo",
+	}
+	_, err = s3.NewClient(options)
+	if err == nil {
+		t.Error("An error was expected, but didn't occur.")
+	}
+}
+
+// TestNil tests the behaviour when passing nil or pointers to nil values to some methods.
+//
+// Note: This test is only executed if the initial connection to S3 works.
+func TestNil(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to S3 could be established. Probably not running in a proper test environment.")
+	}
+
+	// Test setting nil
+
+	t.Run("set nil with JSON marshalling", func(t *testing.T) {
+		client := createClient(t, s3.JSON)
+		err := client.Set("foo", nil)
+		if err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	t.Run("set nil with Gob marshalling", func(t *testing.T) {
+		client := createClient(t, s3.Gob)
+		err := client.Set("foo", nil)
+		if err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	// Test passing nil or pointer to nil value for retrieval
+
+	createTest := func(mf s3.MarshalFormat) func(t *testing.T) {
+		return func(t *testing.T) {
+			client := createClient(t, mf)
+
+			// Prep
+			err := client.Set("foo", test.Foo{Bar: "baz"})
+			if err != nil {
+				t.Error(err)
+			}
+
+			_, err = client.Get("foo", nil) // actually nil
+			if err == nil {
+				t.Error("An error was expected")
+			}
+
+			var i interface{} // actually nil
+			_, err = client.Get("foo", i)
+			if err == nil {
+				t.Error("An error was expected")
+			}
+
+			var valPtr *test.Foo // nil value
+			_, err = client.Get("foo", valPtr)
+			if err == nil {
+				t.Error("An error was expected")
+			}
+		}
+	}
+	t.Run("get with nil / nil value parameter", createTest(s3.JSON))
+	t.Run("get with nil / nil value parameter", createTest(s3.Gob))
+}
+
+// TestClose tests if the close method returns any errors.
+//
+// Note: This test is only executed if the initial connection to S3 works.
+func TestClose(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to S3 could be established. Probably not running in a proper test environment.")
+	}
+
+	client := createClient(t, s3.JSON)
+	err := client.Close()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// checkConnection returns true if a connection could be made, false otherwise.
+func checkConnection() bool {
+	config := aws.NewConfig().
+		WithRegion(endpoints.UsEast1RegionID).
+		WithEndpoint(customEndpoint).
+		WithS3ForcePathStyle(true).
+		WithCredentials(credentials.NewStaticCredentials(accessKeyID, secretAccessKey, ""))
+	sess, err := session.NewSession(config)
+	if err != nil {
+		log.Printf("An error occurred during testing the connection to the server: %v\n", err)
+		return false
+	}
+	svc := awss3.New(sess)
+
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = svc.ListBucketsWithContext(timeoutCtx, &awss3.ListBucketsInput{})
+	if err != nil {
+		log.Printf("An error occurred during testing the connection to the server: %v\n", err)
+		return false
+	}
+	return true
+}
+
+func createClient(t *testing.T, mf s3.MarshalFormat) s3.Client {
+	return createClientWithPrefix(t, mf, "")
+}
+
+func createClientWithPrefix(t *testing.T, mf s3.MarshalFormat, prefix string) s3.Client {
+	options := s3.Options{
+		Prefix:             prefix,
+		Region:             endpoints.UsEast1RegionID,
+		AWSaccessKeyID:     accessKeyID,
+		AWSsecretAccessKey: secretAccessKey,
+		CustomEndpoint:     customEndpoint,
+		ForcePathStyle:     true,
+		MarshalFormat:      mf,
+	}
+	client, err := s3.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
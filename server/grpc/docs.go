@@ -0,0 +1,10 @@
+/*
+Package grpc contains a gRPC server that exposes any `gokv.Store` via the service defined in `grpc/pb/gokv.proto`.
+
+Use the `grpc` package for a client that implements the `gokv.Store` interface.
+
+The values are stored as the bytes that the client marshalled them to (as `[]byte` value),
+so the server doesn't need to know the Go types of the values.
+This also means that local users of the same store see those `[]byte` values instead of the original values.
+*/
+package grpc
@@ -0,0 +1,206 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/philippgille/gokv"
+	"github.com/philippgille/gokv/grpc/pb"
+	"github.com/philippgille/gokv/util"
+)
+
+// Server is an implementation of the gRPC Store service (pb.StoreServer) that wraps a gokv.Store.
+// The optional methods (List, SetMany, DeleteMany, CompareAndSwap and Watch) return
+// an error with the code codes.Unimplemented if the store doesn't implement the respective interface.
+type Server struct {
+	store gokv.Store
+}
+
+// Set stores the given value for the given key.
+func (s Server) Set(ctx context.Context, req *pb.SetRequest) (*pb.SetResponse, error) {
+	if err := checkKeyAndValue(req.Key, req.Value); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Set(req.Key, req.Value); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.SetResponse{}, nil
+}
+
+// Get retrieves the stored value for the given key.
+func (s Server) Get(ctx context.Context, req *pb.GetRequest) (*pb.GetResponse, error) {
+	if err := util.CheckKey(req.Key); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	data := new([]byte)
+	found, err := s.store.Get(req.Key, data)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.GetResponse{
+		Found: found,
+		Value: *data,
+	}, nil
+}
+
+// Delete deletes the stored value for the given key.
+func (s Server) Delete(ctx context.Context, req *pb.DeleteRequest) (*pb.DeleteResponse, error) {
+	if err := util.CheckKey(req.Key); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := s.store.Delete(req.Key); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.DeleteResponse{}, nil
+}
+
+// List sends all keys of the store to the stream.
+// The store must implement gokv.Lister.
+func (s Server) List(req *pb.ListRequest, stream pb.Store_ListServer) error {
+	lister, ok := s.store.(gokv.Lister)
+	if !ok {
+		return status.Error(codes.Unimplemented, "The store doesn't support listing keys")
+	}
+
+	err := lister.List(func(k string) error {
+		return stream.Send(&pb.ListResponse{Key: k})
+	})
+	if err != nil {
+		return toStatusError(err)
+	}
+	return nil
+}
+
+// SetMany stores the given values for the given keys.
+// The store must implement gokv.Batcher.
+func (s Server) SetMany(ctx context.Context, req *pb.SetManyRequest) (*pb.SetManyResponse, error) {
+	batcher, ok := s.store.(gokv.Batcher)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "The store doesn't support batch operations")
+	}
+
+	kvs := make(map[string]interface{}, len(req.Kvs))
+	for k, v := range req.Kvs {
+		if err := checkKeyAndValue(k, v); err != nil {
+			return nil, err
+		}
+		kvs[k] = v
+	}
+	if err := batcher.SetMany(kvs); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.SetManyResponse{}, nil
+}
+
+// DeleteMany deletes the stored values for the given keys.
+// The store must implement gokv.Batcher.
+func (s Server) DeleteMany(ctx context.Context, req *pb.DeleteManyRequest) (*pb.DeleteManyResponse, error) {
+	batcher, ok := s.store.(gokv.Batcher)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "The store doesn't support batch operations")
+	}
+
+	for _, k := range req.Keys {
+		if err := util.CheckKey(k); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	if err := batcher.DeleteMany(req.Keys); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.DeleteManyResponse{}, nil
+}
+
+// CompareAndSwap stores the new value for the given key if the currently stored value equals the old value.
+// The store must implement gokv.CompareAndSwapper.
+func (s Server) CompareAndSwap(ctx context.Context, req *pb.CompareAndSwapRequest) (*pb.CompareAndSwapResponse, error) {
+	cas, ok := s.store.(gokv.CompareAndSwapper)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "The store doesn't support compare-and-swap")
+	}
+
+	if err := checkKeyAndValue(req.Key, req.OldValue); err != nil {
+		return nil, err
+	}
+	if err := checkKeyAndValue(req.Key, req.NewValue); err != nil {
+		return nil, err
+	}
+	swapped, err := cas.CompareAndSwap(req.Key, req.OldValue, req.NewValue)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.CompareAndSwapResponse{Swapped: swapped}, nil
+}
+
+// Watch sends an event for every change of a key-value pair with a key that has the given prefix to the stream,
+// until the client cancels the call.
+// The store must implement gokv.Watcher.
+func (s Server) Watch(req *pb.WatchRequest, stream pb.Store_WatchServer) error {
+	watcher, ok := s.store.(gokv.Watcher)
+	if !ok {
+		return status.Error(codes.Unimplemented, "The store doesn't support watching keys")
+	}
+
+	events, err := watcher.Watch(stream.Context(), req.Prefix)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	for event := range events {
+		err := stream.Send(&pb.WatchEvent{
+			Key:     event.Key,
+			Deleted: event.Deleted,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Register registers the server with the given gRPC server.
+func (s Server) Register(grpcServer *grpc.Server) {
+	pb.RegisterStoreServer(grpcServer, s)
+}
+
+// checkKeyAndValue returns an error with the code codes.InvalidArgument if k == "" or if v is nil.
+// A nil slice of bytes is what the client sends for nil values, because protobuf doesn't differentiate
+// between nil and empty byte slices, but the client never sends an empty value
+// (the marshalled form of a value is never empty).
+func checkKeyAndValue(k string, v []byte) error {
+	if err := util.CheckKey(k); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if len(v) == 0 {
+		return status.Error(codes.InvalidArgument, "The passed value is nil, which is not allowed")
+	}
+	return nil
+}
+
+// toStatusError converts the given error into a gRPC status error,
+// unless it already is one (e.g. an error returned by a stream's Send method).
+func toStatusError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// NewServer creates a new gRPC server for the given store.
+// Register it with a *grpc.Server via Register() or pb.RegisterStoreServer().
+func NewServer(store gokv.Store) (Server, error) {
+	result := Server{}
+
+	if store == nil {
+		return result, errors.New("The passed store is nil, which is not allowed")
+	}
+
+	result.store = store
+	return result, nil
+}
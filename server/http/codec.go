@@ -0,0 +1,49 @@
+package http
+
+import (
+	"github.com/philippgille/gokv/util"
+)
+
+// Codec (un-)marshals request and response bodies in a specific format.
+type Codec interface {
+	// ContentType returns the media type of the format, e.g. "application/json".
+	ContentType() string
+	// Marshal marshals the given value.
+	Marshal(v interface{}) ([]byte, error)
+	// Unmarshal unmarshals the given data and populates the value that the given pointer points to.
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec is the Codec for JSON ("application/json").
+var JSONCodec Codec = jsonCodec{}
+
+// GobCodec is the Codec for gob ("application/x-gob").
+var GobCodec Codec = gobCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string {
+	return "application/json"
+}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return util.ToJSON(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return util.FromJSON(data, v)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) ContentType() string {
+	return "application/x-gob"
+}
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	return util.ToGob(v)
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return util.FromGob(data, v)
+}
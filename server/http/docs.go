@@ -0,0 +1,23 @@
+/*
+Package http contains an HTTP server that exposes any `gokv.Store` via a REST API.
+
+The following endpoints are available (with the default path prefix "/kv"):
+
+	GET    /kv/{key}  Retrieves the value for the key (404 if not found)
+	PUT    /kv/{key}  Stores the value in the request body for the key
+	DELETE /kv/{key}  Deletes the value for the key
+	GET    /kv        Lists all keys as JSON array (only if the store implements gokv.Lister)
+	POST   /kv        Stores and deletes multiple key-value pairs (only if the store implements gokv.Batcher)
+
+The format of request and response bodies is negotiated via the "Content-Type" and "Accept" headers.
+JSON and gob are supported by default, other formats can be added by implementing the Codec interface.
+
+Responses to GET requests contain an "ETag" header.
+PUT requests with an "If-Match" header only store the value if the currently stored value still has the given ETag.
+If the store implements gokv.CompareAndSwapper, this check and the write are atomic.
+DELETE requests with an "If-Match" header are only executed if the ETag matches as well,
+but the check and the deletion are not atomic, because gokv.Store doesn't offer a conditional delete.
+"If-Match" uses the strong comparison, so weak ETags ("W/...") never match.
+GET requests with an "If-None-Match" header use the weak comparison.
+*/
+package http
@@ -0,0 +1,534 @@
+package http
+
+import (
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/philippgille/gokv"
+	"github.com/philippgille/gokv/util"
+)
+
+// Server is an http.Handler that exposes a gokv.Store via a REST API.
+type Server struct {
+	store         gokv.Store
+	pathPrefix    string
+	codecs        []Codec
+	newValue      func() interface{}
+	authenticator Authenticator
+}
+
+// batchRequest is the body of a batch request.
+// The values are decoded individually, so they can be unmarshalled into the type returned by Options.NewValue.
+type batchRequest struct {
+	Set    map[string]json.RawMessage `json:"set"`
+	Delete []string                   `json:"delete"`
+}
+
+// ServeHTTP handles a single request.
+func (s Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.authenticator != nil {
+		if err := s.authenticator.Authenticate(r); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	path := r.URL.EscapedPath()
+	if path == s.pathPrefix {
+		switch r.Method {
+		case http.MethodGet:
+			s.list(w, r)
+		case http.MethodPost:
+			s.batch(w, r)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	if !strings.HasPrefix(path, s.pathPrefix+"/") {
+		http.NotFound(w, r)
+		return
+	}
+	k, err := url.PathUnescape(strings.TrimPrefix(path, s.pathPrefix+"/"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := util.CheckKey(k); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		s.get(w, r, k)
+	case http.MethodPut:
+		s.set(w, r, k)
+	case http.MethodDelete:
+		s.delete(w, r, k)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT, DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s Server) get(w http.ResponseWriter, r *http.Request, k string) {
+	codec := s.responseCodec(r)
+	if codec == nil {
+		http.Error(w, "None of the accepted media types is supported", http.StatusNotAcceptable)
+		return
+	}
+
+	v, found, err := s.getValue(k)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+
+	etag, err := s.etag(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	data, err := codec.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", codec.ContentType())
+	if r.Method == http.MethodHead {
+		return
+	}
+	w.Write(data)
+}
+
+func (s Server) set(w http.ResponseWriter, r *http.Request, k string) {
+	codec := s.requestCodec(r)
+	if codec == nil {
+		http.Error(w, "The media type of the request body is not supported", http.StatusUnsupportedMediaType)
+		return
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	vPtr := s.newValue()
+	if err := codec.Unmarshal(data, vPtr); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	v := reflect.ValueOf(vPtr).Elem().Interface()
+	if err := util.CheckVal(v); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		err = s.store.Set(k, v)
+	} else {
+		var stored interface{}
+		stored, err = s.checkPrecondition(k, ifMatch)
+		if err == errPreconditionFailed {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return
+		} else if err == nil {
+			// Only an atomic compare-and-swap prevents lost updates between the check and the write.
+			if cas, ok := s.store.(gokv.CompareAndSwapper); ok {
+				var swapped bool
+				swapped, err = cas.CompareAndSwap(k, stored, v)
+				if err == nil && !swapped {
+					http.Error(w, errPreconditionFailed.Error(), http.StatusPreconditionFailed)
+					return
+				}
+			} else {
+				err = s.store.Set(k, v)
+			}
+		}
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if etag, err := s.etag(v); err == nil {
+		w.Header().Set("ETag", etag)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// delete deletes the value for the given key.
+// gokv.Store doesn't offer a conditional delete, so with an If-Match header the check and the deletion
+// are NOT atomic. A value that's stored between them is deleted as well.
+func (s Server) delete(w http.ResponseWriter, r *http.Request, k string) {
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+		_, err := s.checkPrecondition(k, ifMatch)
+		if err == errPreconditionFailed {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := s.store.Delete(k); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// list writes all keys of the store as JSON array.
+// The keys are streamed, so the store can contain more keys than fit into memory.
+func (s Server) list(w http.ResponseWriter, r *http.Request) {
+	lister, ok := s.store.(gokv.Lister)
+	if !ok {
+		http.Error(w, "The store doesn't support listing keys", http.StatusNotImplemented)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("["))
+	first := true
+	err := lister.List(func(k string) error {
+		data, err := json.Marshal(k)
+		if err != nil {
+			return err
+		}
+		if !first {
+			data = append([]byte(","), data...)
+		}
+		first = false
+		_, err = w.Write(data)
+		return err
+	})
+	// The status code has already been sent, so in case of an error
+	// the only thing we can do is to leave the JSON array unterminated,
+	// so the client notices that the response is incomplete.
+	if err != nil {
+		return
+	}
+	w.Write([]byte("]"))
+}
+
+// batch stores and deletes multiple key-value pairs.
+// The request body must be a JSON object with the optional fields "set" (object of keys and values)
+// and "delete" (array of keys).
+func (s Server) batch(w http.ResponseWriter, r *http.Request) {
+	batcher, ok := s.store.(gokv.Batcher)
+	if !ok {
+		http.Error(w, "The store doesn't support batch operations", http.StatusNotImplemented)
+		return
+	}
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "" && mediaType != JSONCodec.ContentType() {
+		http.Error(w, "Batch requests must be sent as JSON", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	req := batchRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	kvs := make(map[string]interface{}, len(req.Set))
+	for k, data := range req.Set {
+		vPtr := s.newValue()
+		if err := json.Unmarshal(data, vPtr); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		v := reflect.ValueOf(vPtr).Elem().Interface()
+		if err := util.CheckKeyAndValue(k, v); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		kvs[k] = v
+	}
+	for _, k := range req.Delete {
+		if err := util.CheckKey(k); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if len(kvs) > 0 {
+		if err := batcher.SetMany(kvs); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if len(req.Delete) > 0 {
+		if err := batcher.DeleteMany(req.Delete); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+var errPreconditionFailed = errors.New("The stored value doesn't match the ETag in the If-Match header")
+
+// checkPrecondition returns the currently stored value if it matches the given If-Match header value.
+// The value is returned in the form that's suitable as old value for CompareAndSwap (see getStoredValue).
+// If no value is stored or it doesn't match, errPreconditionFailed is returned.
+func (s Server) checkPrecondition(k string, ifMatch string) (interface{}, error) {
+	current, stored, found, err := s.getStoredValue(k)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errPreconditionFailed
+	}
+	etag, err := s.etag(current)
+	if err != nil {
+		return nil, err
+	}
+	if !etagMatches(ifMatch, etag, false) {
+		return nil, errPreconditionFailed
+	}
+	return stored, nil
+}
+
+// getStoredValue is like getValue, but additionally returns the value in the form in which it's stored, if possible.
+// Stores compare the old value of CompareAndSwap in its marshalled form, but marshalling a value that was unmarshalled
+// into an interface{} again can lead to different data, for example the order of the fields of a struct
+// or the precision of large numbers change.
+// For stores that use JSON the stored data is retrieved as json.RawMessage, which the store marshals to exactly the same data.
+// Other stores can't return the stored data, so the unmarshalled value is returned for them,
+// which only leads to the same data if NewValue returns a pointer to the type of the stored values.
+func (s Server) getStoredValue(k string) (v interface{}, stored interface{}, found bool, err error) {
+	raw := json.RawMessage{}
+	found, err = s.store.Get(k, &raw)
+	if err == nil && !found {
+		return nil, nil, false, nil
+	}
+	if err == nil && json.Valid(raw) {
+		vPtr := s.newValue()
+		if err := json.Unmarshal(raw, vPtr); err == nil {
+			return reflect.ValueOf(vPtr).Elem().Interface(), raw, true, nil
+		}
+	}
+	// The store doesn't use JSON or the value doesn't fit into the type returned by NewValue
+	v, found, err = s.getValue(k)
+	return v, v, found, err
+}
+
+// getValue retrieves the value for the given key into a new value created by the newValue function
+// and returns the value that the pointer points to.
+func (s Server) getValue(k string) (interface{}, bool, error) {
+	vPtr := s.newValue()
+	found, err := s.store.Get(k, vPtr)
+	if err != nil || !found {
+		return nil, found, err
+	}
+	return reflect.ValueOf(vPtr).Elem().Interface(), true, nil
+}
+
+// etag returns a strong ETag for the given value.
+// It's a hash of the value marshalled with the first configured codec,
+// so it's the same no matter in which format the value is sent to the client.
+func (s Server) etag(v interface{}) (string, error) {
+	data, err := s.codecs[0].Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	hash := sha1.Sum(data)
+	return `"` + hex.EncodeToString(hash[:]) + `"`, nil
+}
+
+// etagMatches returns true if the value of an If-Match or If-None-Match header
+// contains the given ETag or is "*".
+// If-None-Match uses the weak comparison, which ignores the "W/" prefix of weak ETags.
+// If-Match requires the strong comparison, so weak ETags never match (see RFC 7232, section 3.1).
+func etagMatches(header string, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// requestCodec returns the codec for the Content-Type of the request
+// or nil if it's not supported.
+// If the request doesn't have a Content-Type, the first configured codec is used.
+func (s Server) requestCodec(r *http.Request) Codec {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return s.codecs[0]
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+	for _, codec := range s.codecs {
+		if codec.ContentType() == mediaType {
+			return codec
+		}
+	}
+	return nil
+}
+
+// responseCodec returns the first codec that matches the Accept header of the request
+// or nil if none of the accepted media types is supported.
+// If the request doesn't have an Accept header, the first configured codec is used.
+func (s Server) responseCodec(r *http.Request) Codec {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return s.codecs[0]
+	}
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(mediaRange)
+		if err != nil || params["q"] == "0" {
+			continue
+		}
+		for _, codec := range s.codecs {
+			contentType := codec.ContentType()
+			if mediaType == "*/*" || mediaType == contentType ||
+				(strings.HasSuffix(mediaType, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(mediaType, "*"))) {
+				return codec
+			}
+		}
+	}
+	return nil
+}
+
+// Authenticator authenticates requests before they're handled by the server.
+type Authenticator interface {
+	// Authenticate returns an error if the request isn't allowed.
+	// The server then responds with 401 Unauthorized.
+	Authenticate(r *http.Request) error
+}
+
+// AuthenticatorFunc is an adapter to allow the use of ordinary functions as Authenticator.
+type AuthenticatorFunc func(r *http.Request) error
+
+// Authenticate calls f(r).
+func (f AuthenticatorFunc) Authenticate(r *http.Request) error {
+	return f(r)
+}
+
+// BasicAuth returns an Authenticator that requires HTTP basic authentication with the given credentials.
+func BasicAuth(username, password string) Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) error {
+		u, p, ok := r.BasicAuth()
+		if !ok || !secureCompare(u, username) || !secureCompare(p, password) {
+			return errors.New("Invalid username or password")
+		}
+		return nil
+	})
+}
+
+// BearerToken returns an Authenticator that requires an "Authorization: Bearer <token>" header
+// with the given token.
+func BearerToken(token string) Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) error {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "Bearer ") || !secureCompare(strings.TrimPrefix(auth, "Bearer "), token) {
+			return errors.New("Invalid bearer token")
+		}
+		return nil
+	})
+}
+
+// secureCompare compares the two strings in constant time to prevent timing attacks.
+func secureCompare(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// Options are the options for the HTTP server.
+type Options struct {
+	// Path under which the key-value pairs are served.
+	// Must start with "/" and must not end with "/".
+	// Optional ("/kv" by default).
+	PathPrefix string
+	// Codecs for (un-)marshalling request and response bodies.
+	// The first codec is used when the client doesn't specify a format
+	// and for calculating ETags.
+	// Optional ([]Codec{JSONCodec, GobCodec} by default).
+	Codecs []Codec
+	// Function that returns a pointer to a new value
+	// into which the values are unmarshalled.
+	// Setting this to the type that your application stores leads to the values being
+	// (un-)marshalled the same way as by your application, which is required for stores
+	// that are configured to use gob.
+	// Optional (func() interface{} { return new(interface{}) } by default).
+	NewValue func() interface{}
+	// Authenticator that's called for every request.
+	// Optional (nil by default, which means that all requests are allowed).
+	Authenticator Authenticator
+}
+
+// DefaultOptions is an Options object with default values.
+// PathPrefix: "/kv", Codecs: JSONCodec and GobCodec, NewValue: returns a pointer to an interface{},
+// Authenticator: nil
+var DefaultOptions = Options{
+	PathPrefix: "/kv",
+	Codecs:     []Codec{JSONCodec, GobCodec},
+	NewValue:   func() interface{} { return new(interface{}) },
+	// No need to set Authenticator because its zero value is fine.
+}
+
+// NewServer creates a new HTTP server for the given store.
+// The server is an http.Handler, so it can be used with http.ListenAndServe() or be mounted in any router.
+func NewServer(store gokv.Store, options Options) (Server, error) {
+	result := Server{}
+
+	if store == nil {
+		return result, errors.New("The passed store is nil, which is not allowed")
+	}
+
+	// Set default values
+	if options.PathPrefix == "" {
+		options.PathPrefix = DefaultOptions.PathPrefix
+	}
+	if len(options.Codecs) == 0 {
+		options.Codecs = DefaultOptions.Codecs
+	}
+	if options.NewValue == nil {
+		options.NewValue = DefaultOptions.NewValue
+	}
+
+	if !strings.HasPrefix(options.PathPrefix, "/") || strings.HasSuffix(options.PathPrefix, "/") {
+		return result, errors.New("The PathPrefix must start with \"/\" and must not end with \"/\"")
+	}
+	if vPtr := options.NewValue(); vPtr == nil || reflect.TypeOf(vPtr).Kind() != reflect.Ptr {
+		return result, errors.New("The NewValue function must return a pointer")
+	}
+
+	result = Server{
+		store:         store,
+		pathPrefix:    options.PathPrefix,
+		codecs:        options.Codecs,
+		newValue:      options.NewValue,
+		authenticator: options.Authenticator,
+	}
+
+	return result, nil
+}
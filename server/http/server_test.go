@@ -0,0 +1,338 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/philippgille/gokv"
+	"github.com/philippgille/gokv/gomap"
+	gokvhttp "github.com/philippgille/gokv/server/http"
+	"github.com/philippgille/gokv/syncmap"
+	"github.com/philippgille/gokv/test"
+	"github.com/philippgille/gokv/util"
+)
+
+// TestServer tests if storing, retrieving and deleting values via HTTP works properly.
+func TestServer(t *testing.T) {
+	store := gomap.NewStore(gomap.DefaultOptions)
+	server := createServer(t, store, gokvhttp.DefaultOptions)
+	defer server.Close()
+
+	res := doRequest(t, http.MethodGet, server.URL+"/kv/foo", nil, nil)
+	checkStatus(t, res, http.StatusNotFound)
+
+	res = doRequest(t, http.MethodPut, server.URL+"/kv/foo", []byte(`{"Bar":"baz"}`), map[string]string{"Content-Type": "application/json"})
+	checkStatus(t, res, http.StatusNoContent)
+
+	// The value must be readable via the store as well
+	actualPtr := new(test.Foo)
+	found, err := store.Get("foo", actualPtr)
+	if err != nil {
+		t.Error(err)
+	}
+	if !found {
+		t.Error("No value was found, but should have been")
+	}
+	if actualPtr.Bar != "baz" {
+		t.Errorf("Expected: %v, but was: %v", "baz", actualPtr.Bar)
+	}
+
+	res = doRequest(t, http.MethodGet, server.URL+"/kv/foo", nil, nil)
+	checkStatus(t, res, http.StatusOK)
+	if contentType := res.Header.Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Expected: %v, but was: %v", "application/json", contentType)
+	}
+	body := readBody(t, res)
+	if string(body) != `{"Bar":"baz"}` {
+		t.Errorf("Expected: %v, but was: %s", `{"Bar":"baz"}`, body)
+	}
+
+	res = doRequest(t, http.MethodDelete, server.URL+"/kv/foo", nil, nil)
+	checkStatus(t, res, http.StatusNoContent)
+	res = doRequest(t, http.MethodGet, server.URL+"/kv/foo", nil, nil)
+	checkStatus(t, res, http.StatusNotFound)
+
+	// Keys with special characters
+	res = doRequest(t, http.MethodPut, server.URL+"/kv/foo%2Fbar%20baz", []byte(`"qux"`), nil)
+	checkStatus(t, res, http.StatusNoContent)
+	found, err = store.Get("foo/bar baz", new(string))
+	if err != nil {
+		t.Error(err)
+	}
+	if !found {
+		t.Error("No value was found, but should have been")
+	}
+}
+
+// TestContentNegotiation tests if values can be sent and retrieved as gob.
+func TestContentNegotiation(t *testing.T) {
+	store := gomap.NewStore(gomap.Options{MarshalFormat: gomap.Gob})
+	options := gokvhttp.DefaultOptions
+	options.NewValue = func() interface{} { return new(test.Foo) }
+	server := createServer(t, store, options)
+	defer server.Close()
+
+	data, err := util.ToGob(test.Foo{Bar: "baz"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := doRequest(t, http.MethodPut, server.URL+"/kv/foo", data, map[string]string{"Content-Type": "application/x-gob"})
+	checkStatus(t, res, http.StatusNoContent)
+
+	res = doRequest(t, http.MethodGet, server.URL+"/kv/foo", nil, map[string]string{"Accept": "application/xml;q=0.9, application/x-gob"})
+	checkStatus(t, res, http.StatusOK)
+	if contentType := res.Header.Get("Content-Type"); contentType != "application/x-gob" {
+		t.Errorf("Expected: %v, but was: %v", "application/x-gob", contentType)
+	}
+	actualPtr := new(test.Foo)
+	err = util.FromGob(readBody(t, res), actualPtr)
+	if err != nil {
+		t.Error(err)
+	}
+	if actualPtr.Bar != "baz" {
+		t.Errorf("Expected: %v, but was: %v", "baz", actualPtr.Bar)
+	}
+
+	// JSON must work as well
+	res = doRequest(t, http.MethodGet, server.URL+"/kv/foo", nil, map[string]string{"Accept": "application/*"})
+	checkStatus(t, res, http.StatusOK)
+	if body := readBody(t, res); string(body) != `{"Bar":"baz"}` {
+		t.Errorf("Expected: %v, but was: %s", `{"Bar":"baz"}`, body)
+	}
+
+	// Unsupported formats
+	res = doRequest(t, http.MethodGet, server.URL+"/kv/foo", nil, map[string]string{"Accept": "application/xml"})
+	checkStatus(t, res, http.StatusNotAcceptable)
+	res = doRequest(t, http.MethodPut, server.URL+"/kv/foo", []byte("<Bar>baz</Bar>"), map[string]string{"Content-Type": "application/xml"})
+	checkStatus(t, res, http.StatusUnsupportedMediaType)
+}
+
+// TestETag tests if ETags are returned and conditional requests work.
+func TestETag(t *testing.T) {
+	store := gomap.NewStore(gomap.DefaultOptions)
+	server := createServer(t, store, gokvhttp.DefaultOptions)
+	defer server.Close()
+
+	res := doRequest(t, http.MethodPut, server.URL+"/kv/foo", []byte(`{"Bar":"baz"}`), nil)
+	checkStatus(t, res, http.StatusNoContent)
+	res = doRequest(t, http.MethodGet, server.URL+"/kv/foo", nil, nil)
+	checkStatus(t, res, http.StatusOK)
+	etag := res.Header.Get("ETag")
+	if etag == "" {
+		t.Fatal("No ETag was returned")
+	}
+
+	res = doRequest(t, http.MethodGet, server.URL+"/kv/foo", nil, map[string]string{"If-None-Match": etag})
+	checkStatus(t, res, http.StatusNotModified)
+	res = doRequest(t, http.MethodGet, server.URL+"/kv/foo", nil, map[string]string{"If-None-Match": "W/" + etag})
+	checkStatus(t, res, http.StatusNotModified)
+
+	// If-Match requires the strong comparison, so a weak ETag must not match
+	res = doRequest(t, http.MethodPut, server.URL+"/kv/foo", []byte(`{"Bar":"qux"}`), map[string]string{"If-Match": "W/" + etag})
+	checkStatus(t, res, http.StatusPreconditionFailed)
+	res = doRequest(t, http.MethodDelete, server.URL+"/kv/foo", nil, map[string]string{"If-Match": "W/" + etag})
+	checkStatus(t, res, http.StatusPreconditionFailed)
+
+	// Update with the current ETag
+	res = doRequest(t, http.MethodPut, server.URL+"/kv/foo", []byte(`{"Bar":"qux"}`), map[string]string{"If-Match": etag})
+	checkStatus(t, res, http.StatusNoContent)
+	newETag := res.Header.Get("ETag")
+	if newETag == "" || newETag == etag {
+		t.Errorf("Expected a new ETag, but was: %v", newETag)
+	}
+
+	// Update with an outdated ETag
+	res = doRequest(t, http.MethodPut, server.URL+"/kv/foo", []byte(`{"Bar":"quux"}`), map[string]string{"If-Match": etag})
+	checkStatus(t, res, http.StatusPreconditionFailed)
+	res = doRequest(t, http.MethodDelete, server.URL+"/kv/foo", nil, map[string]string{"If-Match": etag})
+	checkStatus(t, res, http.StatusPreconditionFailed)
+
+	// Non-existing key
+	res = doRequest(t, http.MethodPut, server.URL+"/kv/bar", []byte(`{"Bar":"baz"}`), map[string]string{"If-Match": "*"})
+	checkStatus(t, res, http.StatusPreconditionFailed)
+
+	res = doRequest(t, http.MethodDelete, server.URL+"/kv/foo", nil, map[string]string{"If-Match": newETag})
+	checkStatus(t, res, http.StatusNoContent)
+}
+
+// record is a value whose fields aren't in alphabetical order and that contains an integer
+// that can't be represented exactly as float64.
+type record struct {
+	Name string
+	ID   int64
+}
+
+// TestETagWithApplicationValues tests if conditional updates work for values that were stored
+// by an application directly, which are marshalled differently than values that are unmarshalled into an interface{}.
+func TestETagWithApplicationValues(t *testing.T) {
+	t.Run("JSON", func(t *testing.T) {
+		store := gomap.NewStore(gomap.DefaultOptions)
+		testETagWithApplicationValues(t, store, gokvhttp.DefaultOptions)
+	})
+	t.Run("gob", func(t *testing.T) {
+		store := gomap.NewStore(gomap.Options{MarshalFormat: gomap.Gob})
+		options := gokvhttp.DefaultOptions
+		options.NewValue = func() interface{} { return new(record) }
+		testETagWithApplicationValues(t, store, options)
+	})
+}
+
+func testETagWithApplicationValues(t *testing.T, store gokv.Store, options gokvhttp.Options) {
+	server := createServer(t, store, options)
+	defer server.Close()
+
+	err := store.Set("foo", record{Name: "foo", ID: 1<<60 + 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := doRequest(t, http.MethodGet, server.URL+"/kv/foo", nil, nil)
+	checkStatus(t, res, http.StatusOK)
+	etag := res.Header.Get("ETag")
+
+	res = doRequest(t, http.MethodPut, server.URL+"/kv/foo", []byte(`{"Name":"bar","ID":2}`), map[string]string{"If-Match": etag})
+	checkStatus(t, res, http.StatusNoContent)
+	actual := record{}
+	found, err := store.Get("foo", &actual)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found || actual.Name != "bar" {
+		t.Errorf("Expected the value to be updated, but was: %+v", actual)
+	}
+}
+
+// TestListAndBatch tests the list and batch endpoints.
+func TestListAndBatch(t *testing.T) {
+	store := gomap.NewStore(gomap.DefaultOptions)
+	server := createServer(t, store, gokvhttp.DefaultOptions)
+	defer server.Close()
+
+	res := doRequest(t, http.MethodGet, server.URL+"/kv", nil, nil)
+	checkStatus(t, res, http.StatusOK)
+	if body := readBody(t, res); string(body) != "[]" {
+		t.Errorf("Expected: %v, but was: %s", "[]", body)
+	}
+
+	res = doRequest(t, http.MethodPost, server.URL+"/kv", []byte(`{"set":{"foo":"bar","baz":"qux"}}`), nil)
+	checkStatus(t, res, http.StatusNoContent)
+
+	res = doRequest(t, http.MethodGet, server.URL+"/kv", nil, nil)
+	checkStatus(t, res, http.StatusOK)
+	keys := []string{}
+	err := json.Unmarshal(readBody(t, res), &keys)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(keys) != 2 {
+		t.Errorf("Expected two keys, but was: %v", keys)
+	}
+
+	res = doRequest(t, http.MethodPost, server.URL+"/kv", []byte(`{"delete":["foo","baz"]}`), nil)
+	checkStatus(t, res, http.StatusNoContent)
+	found, err := store.Get("foo", new(string))
+	if err != nil {
+		t.Error(err)
+	}
+	if found {
+		t.Error("A value was found, but no value was expected")
+	}
+
+	// syncmap.Store doesn't implement gokv.Batcher
+	syncmapServer := createServer(t, syncmap.NewStore(syncmap.DefaultOptions), gokvhttp.DefaultOptions)
+	defer syncmapServer.Close()
+	res = doRequest(t, http.MethodPost, syncmapServer.URL+"/kv", []byte(`{"delete":["foo"]}`), nil)
+	checkStatus(t, res, http.StatusNotImplemented)
+}
+
+// TestAuth tests if requests are authenticated.
+func TestAuth(t *testing.T) {
+	store := gomap.NewStore(gomap.DefaultOptions)
+	options := gokvhttp.DefaultOptions
+	options.Authenticator = gokvhttp.BearerToken("secret")
+	server := createServer(t, store, options)
+	defer server.Close()
+
+	res := doRequest(t, http.MethodGet, server.URL+"/kv/foo", nil, nil)
+	checkStatus(t, res, http.StatusUnauthorized)
+	res = doRequest(t, http.MethodGet, server.URL+"/kv/foo", nil, map[string]string{"Authorization": "Bearer wrong"})
+	checkStatus(t, res, http.StatusUnauthorized)
+	res = doRequest(t, http.MethodGet, server.URL+"/kv/foo", nil, map[string]string{"Authorization": "Bearer secret"})
+	checkStatus(t, res, http.StatusNotFound)
+}
+
+// TestErrors tests some error cases.
+func TestErrors(t *testing.T) {
+	_, err := gokvhttp.NewServer(nil, gokvhttp.DefaultOptions)
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	options := gokvhttp.DefaultOptions
+	options.NewValue = func() interface{} { return test.Foo{} }
+	_, err = gokvhttp.NewServer(gomap.NewStore(gomap.DefaultOptions), options)
+	if err == nil {
+		t.Error("Expected an error")
+	}
+
+	server := createServer(t, gomap.NewStore(gomap.DefaultOptions), gokvhttp.DefaultOptions)
+	defer server.Close()
+
+	// Empty key
+	res := doRequest(t, http.MethodGet, server.URL+"/kv/", nil, nil)
+	checkStatus(t, res, http.StatusBadRequest)
+	// Nil value
+	res = doRequest(t, http.MethodPut, server.URL+"/kv/foo", []byte("null"), nil)
+	checkStatus(t, res, http.StatusBadRequest)
+	// Invalid body
+	res = doRequest(t, http.MethodPut, server.URL+"/kv/foo", []byte("{"), nil)
+	checkStatus(t, res, http.StatusBadRequest)
+	// Unsupported method
+	res = doRequest(t, http.MethodPatch, server.URL+"/kv/foo", nil, nil)
+	checkStatus(t, res, http.StatusMethodNotAllowed)
+	// Path outside of the prefix
+	res = doRequest(t, http.MethodGet, server.URL+"/foo", nil, nil)
+	checkStatus(t, res, http.StatusNotFound)
+}
+
+func createServer(t *testing.T, store gokv.Store, options gokvhttp.Options) *httptest.Server {
+	handler, err := gokvhttp.NewServer(store, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return httptest.NewServer(handler)
+}
+
+func doRequest(t *testing.T, method, url string, body []byte, headers map[string]string) *http.Response {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func readBody(t *testing.T, res *http.Response) []byte {
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+// checkStatus checks the status code of the response.
+// It doesn't close the body, so it can still be read afterwards.
+func checkStatus(t *testing.T, res *http.Response, expected int) {
+	if res.StatusCode != expected {
+		t.Errorf("Expected status code %v, but was %v", expected, res.StatusCode)
+	}
+}
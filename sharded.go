@@ -0,0 +1,504 @@
+package gokv
+
+import (
+	"errors"
+	"hash/fnv"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/philippgille/gokv/util"
+)
+
+// Shard is one of the stores that a Sharded store distributes the key-value pairs over.
+type Shard struct {
+	// Name of the shard.
+	// It determines the positions of the shard on the hash ring,
+	// so it must be unique and must not change as long as the shard contains data,
+	// otherwise the key-value pairs can't be found anymore.
+	Name string
+	// The store that holds the key-value pairs of the shard.
+	Store Store
+	// Weight of the shard. A shard with weight 2 gets about twice as many key-value pairs
+	// as a shard with weight 1.
+	// Optional (1 by default).
+	Weight int
+}
+
+// Sharded is a gokv.Store implementation that distributes the key-value pairs over multiple stores
+// using consistent hashing.
+// Each shard is placed on a hash ring multiple times (virtual nodes), and a key-value pair is stored
+// in the shard that follows the hash of the key on the ring.
+// When adding or removing a shard only the key-value pairs of the adjacent ring segments
+// have to be moved, which can be done with Rebalance().
+//
+// Sharded also implements Lister, Batcher and CompareAndSwapper.
+// The methods of these interfaces return an error if one of the shards' stores
+// doesn't implement the respective interface.
+type Sharded struct {
+	lock         *sync.RWMutex
+	state        *shardedState
+	virtualNodes int
+}
+
+// shardedState is the part of a Sharded store that changes when shards are added or removed.
+type shardedState struct {
+	shards map[string]Shard
+	// Sorted positions of the virtual nodes on the hash ring.
+	points []uint64
+	// Shard names of the virtual nodes, by position.
+	owners map[uint64]string
+	// Shards that were removed but might still contain key-value pairs.
+	// They're drained by Rebalance().
+	retired []Shard
+}
+
+// Set stores the given value for the given key in the shard that's responsible for the key.
+// The key must not be "" and the value must not be nil.
+func (s Sharded) Set(k string, v interface{}) error {
+	if err := util.CheckKey(k); err != nil {
+		return err
+	}
+	return s.shardFor(k).Store.Set(k, v)
+}
+
+// Get retrieves the stored value for the given key from the shard that's responsible for the key.
+// If no value is found it returns (false, nil).
+// The key must not be "" and the pointer must not be nil.
+func (s Sharded) Get(k string, v interface{}) (found bool, err error) {
+	if err := util.CheckKey(k); err != nil {
+		return false, err
+	}
+	return s.shardFor(k).Store.Get(k, v)
+}
+
+// Delete deletes the stored value for the given key from the shard that's responsible for the key.
+// Deleting a non-existing key-value pair does NOT lead to an error.
+// The key must not be "".
+func (s Sharded) Delete(k string) error {
+	if err := util.CheckKey(k); err != nil {
+		return err
+	}
+	return s.shardFor(k).Store.Delete(k)
+}
+
+// Close closes the stores of all shards, including shards that were removed but not drained yet.
+// All stores are closed even if closing one of them fails. In that case the first error is returned.
+func (s Sharded) Close() error {
+	s.lock.RLock()
+	stores := s.stores()
+	s.lock.RUnlock()
+
+	var result error
+	for _, store := range stores {
+		if err := store.Close(); err != nil && result == nil {
+			result = err
+		}
+	}
+	return result
+}
+
+// List calls fn for every key in every shard.
+// The shards are iterated concurrently, but fn is never called concurrently.
+// If fn returns an error, the iteration of all shards is stopped.
+// All shards' stores must implement Lister.
+func (s Sharded) List(fn func(k string) error) error {
+	s.lock.RLock()
+	stores := s.stores()
+	s.lock.RUnlock()
+
+	listers := make([]Lister, 0, len(stores))
+	for _, store := range stores {
+		lister, ok := store.(Lister)
+		if !ok {
+			return errors.New("At least one of the shards doesn't support listing keys")
+		}
+		listers = append(listers, lister)
+	}
+
+	// Serializes the calls of fn and stops the other shards' iterations after fn returned an error
+	fnLock := new(sync.Mutex)
+	var fnErr error
+	listFns := make([]func() error, 0, len(listers))
+	for _, lister := range listers {
+		lister := lister
+		listFns = append(listFns, func() error {
+			return lister.List(func(k string) error {
+				fnLock.Lock()
+				defer fnLock.Unlock()
+				if fnErr != nil {
+					return fnErr
+				}
+				fnErr = fn(k)
+				return fnErr
+			})
+		})
+	}
+	if err := fanOut(listFns); err != nil {
+		return err
+	}
+	return fnErr
+}
+
+// SetMany stores the given values for the given keys.
+// The key-value pairs are grouped by shard, and each group is stored with one call
+// to the shard's SetMany method if its store implements Batcher, or with multiple calls to Set otherwise.
+// The groups are stored concurrently.
+// The operation is not atomic across shards.
+func (s Sharded) SetMany(kvs map[string]interface{}) error {
+	groups := make(map[string]map[string]interface{})
+	stores := make(map[string]Store)
+	for k, v := range kvs {
+		if err := util.CheckKey(k); err != nil {
+			return err
+		}
+		shard := s.shardFor(k)
+		if groups[shard.Name] == nil {
+			groups[shard.Name] = make(map[string]interface{})
+			stores[shard.Name] = shard.Store
+		}
+		groups[shard.Name][k] = v
+	}
+
+	setFns := make([]func() error, 0, len(groups))
+	for name, group := range groups {
+		store, group := stores[name], group
+		setFns = append(setFns, func() error {
+			if batcher, ok := store.(Batcher); ok {
+				return batcher.SetMany(group)
+			}
+			for k, v := range group {
+				if err := store.Set(k, v); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	return fanOut(setFns)
+}
+
+// DeleteMany deletes the stored values for the given keys.
+// The keys are grouped by shard, and each group is deleted with one call
+// to the shard's DeleteMany method if its store implements Batcher, or with multiple calls to Delete otherwise.
+// The groups are deleted concurrently.
+// The operation is not atomic across shards.
+func (s Sharded) DeleteMany(ks []string) error {
+	groups := make(map[string][]string)
+	stores := make(map[string]Store)
+	for _, k := range ks {
+		if err := util.CheckKey(k); err != nil {
+			return err
+		}
+		shard := s.shardFor(k)
+		groups[shard.Name] = append(groups[shard.Name], k)
+		stores[shard.Name] = shard.Store
+	}
+
+	deleteFns := make([]func() error, 0, len(groups))
+	for name, group := range groups {
+		store, group := stores[name], group
+		deleteFns = append(deleteFns, func() error {
+			if batcher, ok := store.(Batcher); ok {
+				return batcher.DeleteMany(group)
+			}
+			for _, k := range group {
+				if err := store.Delete(k); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	return fanOut(deleteFns)
+}
+
+// CompareAndSwap calls the CompareAndSwap method of the shard that's responsible for the key.
+// The shard's store must implement CompareAndSwapper.
+func (s Sharded) CompareAndSwap(k string, oldV, newV interface{}) (swapped bool, err error) {
+	if err := util.CheckKey(k); err != nil {
+		return false, err
+	}
+	cas, ok := s.shardFor(k).Store.(CompareAndSwapper)
+	if !ok {
+		return false, errors.New("The shard that's responsible for the key doesn't support compare-and-swap")
+	}
+	return cas.CompareAndSwap(k, oldV, newV)
+}
+
+// AddShard adds a shard to the hash ring.
+// From then on the new shard is responsible for some of the keys that other shards were responsible for before.
+// Until Rebalance() is called, the values of those keys can't be found.
+// The name of a removed shard can only be used again after Rebalance() drained the removed shard,
+// otherwise Rebalance() would move the key-value pairs of the removed shard to the new one.
+func (s Sharded) AddShard(shard Shard) error {
+	if err := checkShard(shard); err != nil {
+		return err
+	}
+	if shard.Weight == 0 {
+		shard.Weight = 1
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, exists := s.state.shards[shard.Name]; exists {
+		return errors.New("A shard with the name " + shard.Name + " already exists")
+	}
+	for _, retired := range s.state.retired {
+		if retired.Name == shard.Name {
+			return errors.New("The shard with the name " + shard.Name + " was removed, but Rebalance() wasn't called yet")
+		}
+	}
+	s.state.shards[shard.Name] = shard
+	s.buildRing()
+	return nil
+}
+
+// RemoveShard removes the shard with the given name from the hash ring.
+// From then on other shards are responsible for the keys of the removed shard.
+// The removed shard's store is kept until Rebalance() moved its key-value pairs to the other shards.
+// Until then the values of those keys can't be found.
+// The removed shard's store is not closed.
+func (s Sharded) RemoveShard(name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	shard, exists := s.state.shards[name]
+	if !exists {
+		return errors.New("A shard with the name " + name + " doesn't exist")
+	}
+	if len(s.state.shards) == 1 {
+		return errors.New("The last shard can't be removed")
+	}
+	delete(s.state.shards, name)
+	s.state.retired = append(s.state.retired, shard)
+	s.buildRing()
+	return nil
+}
+
+// Rebalance moves all key-value pairs that are stored in a shard that's not responsible for them
+// (anymore) to the responsible shard. This is required after adding or removing shards.
+// All shards' stores must implement Lister.
+//
+// newValue must return a pointer to a new value into which the values are retrieved before they're
+// stored in the responsible shard. For stores that use JSON as marshal format a pointer to an
+// interface{} works for all values, but for gob the pointer must point to the actual type of the values.
+// If newValue is nil, a pointer to an interface{} is used.
+//
+// Rebalance should not be called concurrently with writes, because a value that's written during the move
+// could be overwritten by the moved value.
+// It returns the number of moved key-value pairs.
+func (s Sharded) Rebalance(newValue func() interface{}) (moved int, err error) {
+	if newValue == nil {
+		newValue = func() interface{} { return new(interface{}) }
+	}
+
+	s.lock.RLock()
+	shards := make([]Shard, 0, len(s.state.shards)+len(s.state.retired))
+	for _, shard := range s.state.shards {
+		shards = append(shards, shard)
+	}
+	retired := s.state.retired
+	shards = append(shards, retired...)
+	s.lock.RUnlock()
+
+	activeCount := len(shards) - len(retired)
+	for i, shard := range shards {
+		isRetired := i >= activeCount
+		lister, ok := shard.Store.(Lister)
+		if !ok {
+			return moved, errors.New("The store of shard " + shard.Name + " doesn't support listing keys")
+		}
+		// Collect the keys first, because not all stores allow modifications during the iteration.
+		var keys []string
+		err := lister.List(func(k string) error {
+			if isRetired || s.shardFor(k).Name != shard.Name {
+				keys = append(keys, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return moved, err
+		}
+		for _, k := range keys {
+			vPtr := newValue()
+			found, err := shard.Store.Get(k, vPtr)
+			if err != nil {
+				return moved, err
+			}
+			if !found {
+				continue
+			}
+			if err := s.shardFor(k).Store.Set(k, reflect.ValueOf(vPtr).Elem().Interface()); err != nil {
+				return moved, err
+			}
+			if err := shard.Store.Delete(k); err != nil {
+				return moved, err
+			}
+			moved++
+		}
+	}
+
+	// All retired shards are drained now.
+	s.lock.Lock()
+	s.state.retired = s.state.retired[len(retired):]
+	s.lock.Unlock()
+
+	return moved, nil
+}
+
+// fanOut calls the given functions concurrently and waits until all of them returned.
+// If one or more of them return an error, the one that was returned first is returned.
+func fanOut(fns []func() error) error {
+	errs := make(chan error, len(fns))
+	for _, fn := range fns {
+		go func(fn func() error) {
+			errs <- fn()
+		}(fn)
+	}
+	var result error
+	for range fns {
+		if err := <-errs; err != nil && result == nil {
+			result = err
+		}
+	}
+	return result
+}
+
+// shardFor returns the shard that's responsible for the given key.
+func (s Sharded) shardFor(k string) Shard {
+	h := hash(k)
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	points := s.state.points
+	i := sort.Search(len(points), func(i int) bool { return points[i] >= h })
+	// Wrap around the ring
+	if i == len(points) {
+		i = 0
+	}
+	return s.state.shards[s.state.owners[points[i]]]
+}
+
+// stores returns the stores of all shards, including retired ones.
+// The caller must hold the lock.
+func (s Sharded) stores() []Store {
+	result := make([]Store, 0, len(s.state.shards)+len(s.state.retired))
+	for _, shard := range s.state.shards {
+		result = append(result, shard.Store)
+	}
+	for _, shard := range s.state.retired {
+		result = append(result, shard.Store)
+	}
+	return result
+}
+
+// buildRing places the virtual nodes of all shards on the hash ring.
+// The caller must hold the write lock.
+func (s Sharded) buildRing() {
+	points := make([]uint64, 0)
+	owners := make(map[uint64]string)
+	for name, shard := range s.state.shards {
+		for i := 0; i < s.virtualNodes*shard.Weight; i++ {
+			point := hash(name + "#" + strconv.Itoa(i))
+			// In the unlikely case of a collision, the shard with the lower name wins,
+			// so the ring is the same no matter in which order the map is iterated.
+			if owner, exists := owners[point]; exists {
+				if owner < name {
+					continue
+				}
+			} else {
+				points = append(points, point)
+			}
+			owners[point] = name
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i] < points[j] })
+	s.state.points = points
+	s.state.owners = owners
+}
+
+// hash returns the 64 bit FNV-1a hash of the given string,
+// mixed with the MurmurHash3 finalizer.
+// FNV-1a alone doesn't distribute similar short strings (like "shard1#1", "shard1#2") evenly
+// enough over the ring.
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// checkShard returns an error if the shard's name is empty, its store is nil or its weight is negative.
+func checkShard(shard Shard) error {
+	if shard.Name == "" {
+		return errors.New("The name of a shard must not be empty")
+	}
+	if shard.Store == nil {
+		return errors.New("The store of shard " + shard.Name + " is nil, which is not allowed")
+	}
+	if shard.Weight < 0 {
+		return errors.New("The weight of shard " + shard.Name + " must not be negative")
+	}
+	return nil
+}
+
+// ShardedOptions are the options for the Sharded store.
+type ShardedOptions struct {
+	// Number of virtual nodes per shard (per weight unit) on the hash ring.
+	// Higher values lead to a more even distribution of the key-value pairs,
+	// but make adding and removing shards slower.
+	// Must not be negative.
+	// Optional (100 by default).
+	VirtualNodes int
+}
+
+// DefaultShardedOptions is a ShardedOptions object with default values.
+// VirtualNodes: 100
+var DefaultShardedOptions = ShardedOptions{
+	VirtualNodes: 100,
+}
+
+// NewSharded creates a new Sharded store that distributes the key-value pairs over the given shards.
+// At least one shard is required.
+func NewSharded(shards []Shard, options ShardedOptions) (Sharded, error) {
+	result := Sharded{}
+
+	// Set default values
+	if options.VirtualNodes == 0 {
+		options.VirtualNodes = DefaultShardedOptions.VirtualNodes
+	} else if options.VirtualNodes < 0 {
+		return result, errors.New("The number of virtual nodes must not be negative")
+	}
+
+	if len(shards) == 0 {
+		return result, errors.New("At least one shard is required")
+	}
+	shardMap := make(map[string]Shard, len(shards))
+	for _, shard := range shards {
+		if err := checkShard(shard); err != nil {
+			return result, err
+		}
+		if _, exists := shardMap[shard.Name]; exists {
+			return result, errors.New("A shard with the name " + shard.Name + " already exists")
+		}
+		if shard.Weight == 0 {
+			shard.Weight = 1
+		}
+		shardMap[shard.Name] = shard
+	}
+
+	result = Sharded{
+		lock: new(sync.RWMutex),
+		state: &shardedState{
+			shards: shardMap,
+		},
+		virtualNodes: options.VirtualNodes,
+	}
+	result.buildRing()
+	return result, nil
+}
@@ -0,0 +1,325 @@
+package gokv_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/philippgille/gokv"
+	"github.com/philippgille/gokv/gomap"
+	"github.com/philippgille/gokv/syncmap"
+	"github.com/philippgille/gokv/test"
+)
+
+// TestSharded tests if reading from, writing to and deleting from the store works properly.
+// A struct is used as value. See TestShardedTypes() for a test that is simpler but tests all types.
+func TestSharded(t *testing.T) {
+	store, _ := createSharded(t, 3)
+	test.TestStore(store, t)
+}
+
+// TestShardedTypes tests if setting and getting values works with all Go types.
+func TestShardedTypes(t *testing.T) {
+	store, _ := createSharded(t, 3)
+	test.TestTypes(store, t)
+}
+
+// TestShardedConcurrent launches a bunch of goroutines that concurrently work with the store.
+func TestShardedConcurrent(t *testing.T) {
+	store, _ := createSharded(t, 3)
+
+	goroutineCount := 1000
+
+	test.TestConcurrentInteractions(t, goroutineCount, store)
+}
+
+// TestShardedList tests if the keys of all shards are listed.
+func TestShardedList(t *testing.T) {
+	store, _ := createSharded(t, 3)
+	test.TestList(store, t)
+}
+
+// TestShardedDistribution tests if the key-value pairs are distributed according to the weights.
+func TestShardedDistribution(t *testing.T) {
+	light := gomap.NewStore(gomap.DefaultOptions)
+	heavy := gomap.NewStore(gomap.DefaultOptions)
+	store, err := gokv.NewSharded([]gokv.Shard{
+		{Name: "light", Store: light},
+		{Name: "heavy", Store: heavy, Weight: 3},
+	}, gokv.DefaultShardedOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		err := store.Set(strconv.Itoa(i), i)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	lightCount := count(t, light)
+	heavyCount := count(t, heavy)
+	if lightCount+heavyCount != 1000 {
+		t.Errorf("Expected 1000 key-value pairs, but was %v", lightCount+heavyCount)
+	}
+	// The expected ratio is 1:3, so 250 key-value pairs in the light shard.
+	if lightCount < 150 || lightCount > 350 {
+		t.Errorf("Expected about 250 key-value pairs in the light shard, but was %v", lightCount)
+	}
+}
+
+// TestShardedBatch tests if batch calls are distributed to the shards.
+func TestShardedBatch(t *testing.T) {
+	store, shards := createSharded(t, 3)
+
+	kvs := make(map[string]interface{})
+	keys := make([]string, 0)
+	for i := 0; i < 100; i++ {
+		kvs[strconv.Itoa(i)] = i
+		keys = append(keys, strconv.Itoa(i))
+	}
+	err := store.SetMany(kvs)
+	if err != nil {
+		t.Error(err)
+	}
+	total := 0
+	for _, shard := range shards {
+		shardCount := count(t, shard.Store)
+		if shardCount == 0 {
+			t.Error("Expected key-value pairs in every shard, but one was empty")
+		}
+		total += shardCount
+	}
+	if total != 100 {
+		t.Errorf("Expected 100 key-value pairs, but was %v", total)
+	}
+
+	err = store.DeleteMany(keys)
+	if err != nil {
+		t.Error(err)
+	}
+	for _, shard := range shards {
+		if shardCount := count(t, shard.Store); shardCount != 0 {
+			t.Errorf("Expected no key-value pairs, but was %v", shardCount)
+		}
+	}
+
+	// syncmap.Store doesn't implement gokv.Batcher, so the values must be stored one by one
+	syncmapStore, err := gokv.NewSharded([]gokv.Shard{
+		{Name: "a", Store: syncmap.NewStore(syncmap.DefaultOptions)},
+		{Name: "b", Store: syncmap.NewStore(syncmap.DefaultOptions)},
+	}, gokv.DefaultShardedOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = syncmapStore.SetMany(kvs)
+	if err != nil {
+		t.Error(err)
+	}
+	found, err := syncmapStore.Get("42", new(int))
+	if err != nil {
+		t.Error(err)
+	}
+	if !found {
+		t.Error("No value was found, but should have been")
+	}
+}
+
+// TestShardedRebalance tests if key-value pairs are moved when shards are added and removed.
+func TestShardedRebalance(t *testing.T) {
+	store, shards := createSharded(t, 2)
+
+	for i := 0; i < 100; i++ {
+		err := store.Set(strconv.Itoa(i), test.Foo{Bar: strconv.Itoa(i)})
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	// Add a shard
+	newShard := gokv.Shard{Name: "new", Store: gomap.NewStore(gomap.DefaultOptions)}
+	err := store.AddShard(newShard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	moved, err := store.Rebalance(func() interface{} { return new(test.Foo) })
+	if err != nil {
+		t.Error(err)
+	}
+	// Only the keys that the new shard is responsible for must be moved
+	if moved == 0 || moved != count(t, newShard.Store) {
+		t.Errorf("Expected the new shard to contain all %v moved key-value pairs, but it contained %v", moved, count(t, newShard.Store))
+	}
+	checkValues(t, store, 100)
+
+	// Remove a shard
+	err = store.RemoveShard(shards[0].Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = store.Rebalance(nil)
+	if err != nil {
+		t.Error(err)
+	}
+	if removedCount := count(t, shards[0].Store); removedCount != 0 {
+		t.Errorf("Expected the removed shard to be empty, but it contained %v key-value pairs", removedCount)
+	}
+	checkValues(t, store, 100)
+}
+
+// TestShardedReAdd tests if a removed shard can be added again without losing key-value pairs.
+func TestShardedReAdd(t *testing.T) {
+	store, shards := createSharded(t, 2)
+
+	for i := 0; i < 100; i++ {
+		err := store.Set(strconv.Itoa(i), test.Foo{Bar: strconv.Itoa(i)})
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	err := store.RemoveShard(shards[0].Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The removed shard wasn't drained yet, so its name must not be used yet
+	err = store.AddShard(shards[0])
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	_, err = store.Rebalance(nil)
+	if err != nil {
+		t.Error(err)
+	}
+	checkValues(t, store, 100)
+
+	err = store.AddShard(shards[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = store.Rebalance(func() interface{} { return new(test.Foo) })
+	if err != nil {
+		t.Error(err)
+	}
+	if total := count(t, shards[0].Store) + count(t, shards[1].Store); total != 100 {
+		t.Errorf("Expected 100 key-value pairs, but was %v", total)
+	}
+	checkValues(t, store, 100)
+}
+
+// TestShardedListError tests if the iteration of all shards is stopped when fn returns an error.
+func TestShardedListError(t *testing.T) {
+	store, _ := createSharded(t, 3)
+	for i := 0; i < 100; i++ {
+		err := store.Set(strconv.Itoa(i), i)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	calls := 0
+	expectedErr := errors.New("foo")
+	err := store.List(func(string) error {
+		calls++
+		return expectedErr
+	})
+	if err != expectedErr {
+		t.Errorf("Expected error %v, but was %v", expectedErr, err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected fn to be called once, but was called %v times", calls)
+	}
+}
+
+// TestShardedErrors tests some error cases.
+func TestShardedErrors(t *testing.T) {
+	_, err := gokv.NewSharded(nil, gokv.DefaultShardedOptions)
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	_, err = gokv.NewSharded([]gokv.Shard{{Name: "a"}}, gokv.DefaultShardedOptions)
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	store := gomap.NewStore(gomap.DefaultOptions)
+	_, err = gokv.NewSharded([]gokv.Shard{{Name: "a", Store: store}, {Name: "a", Store: store}}, gokv.DefaultShardedOptions)
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	_, err = gokv.NewSharded([]gokv.Shard{{Name: "a", Store: store}}, gokv.ShardedOptions{VirtualNodes: -1})
+	if err == nil {
+		t.Error("Expected an error")
+	}
+
+	sharded, shards := createSharded(t, 1)
+	err = sharded.AddShard(shards[0])
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	err = sharded.RemoveShard("foo")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	err = sharded.RemoveShard(shards[0].Name)
+	if err == nil {
+		t.Error("Expected an error")
+	}
+
+	// Test empty key
+	err = sharded.Set("", "bar")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	_, err = sharded.Get("", new(string))
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	err = sharded.Delete("")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func createSharded(t *testing.T, shardCount int) (gokv.Sharded, []gokv.Shard) {
+	shards := make([]gokv.Shard, 0, shardCount)
+	for i := 0; i < shardCount; i++ {
+		shards = append(shards, gokv.Shard{
+			Name:  "shard" + strconv.Itoa(i),
+			Store: gomap.NewStore(gomap.DefaultOptions),
+		})
+	}
+	store, err := gokv.NewSharded(shards, gokv.DefaultShardedOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store, shards
+}
+
+// count returns the number of key-value pairs in the given store, which must implement gokv.Lister.
+func count(t *testing.T, store gokv.Store) int {
+	result := 0
+	err := store.(gokv.Lister).List(func(string) error {
+		result++
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	return result
+}
+
+// checkValues checks if the values that TestShardedRebalance stored can be retrieved.
+func checkValues(t *testing.T, store gokv.Store, n int) {
+	for i := 0; i < n; i++ {
+		actualPtr := new(test.Foo)
+		found, err := store.Get(strconv.Itoa(i), actualPtr)
+		if err != nil {
+			t.Error(err)
+		}
+		if !found {
+			t.Errorf("No value was found for key %v, but should have been", i)
+		} else if actualPtr.Bar != strconv.Itoa(i) {
+			t.Errorf("Expected: %v, but was: %v", i, actualPtr.Bar)
+		}
+	}
+}
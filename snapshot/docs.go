@@ -0,0 +1,18 @@
+/*
+Package snapshot contains functions for exporting the key-value pairs of a `gokv.Store` to a portable snapshot
+and for importing a snapshot into any other `gokv.Store`.
+
+A snapshot starts with a header (format version, codec of the values, creation time and custom metadata),
+followed by one record per key-value pair and a footer with the number of records,
+which allows detecting truncated snapshots.
+Two formats are available: JSON Lines (one JSON object per line, easy to inspect and edit, for example for test fixtures)
+and a compact binary format. Import() detects the format automatically.
+
+Snapshots are written and read as streams, so they can be larger than the available memory.
+Exporting requires the store to implement `gokv.Lister`.
+
+By default the values are exported and imported exactly as they're stored, which requires the stores to use JSON.
+For stores that use gob or for converting the values between marshal formats,
+set Options.NewValue to a function that returns a pointer to the type of the values.
+*/
+package snapshot
@@ -0,0 +1,462 @@
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/philippgille/gokv"
+	"github.com/philippgille/gokv/util"
+)
+
+// Version is the version of the snapshot format that this package writes.
+// Snapshots with a higher version can't be imported.
+const Version = 1
+
+// magic is the prefix of snapshots in the binary format.
+var magic = []byte("GOKVSNAP")
+
+// maxLength is the maximum length of the header, a key or a value in the binary format.
+// Larger lengths can only occur in corrupt snapshots.
+const maxLength = 1 << 30
+
+// Header contains the information about a snapshot that's written before the key-value pairs.
+type Header struct {
+	// Version of the snapshot format.
+	Version int `json:"version"`
+	// Codec of the values ("json" or "gob").
+	Codec string `json:"codec"`
+	// Time when the export started.
+	Created time.Time `json:"created"`
+	// Custom metadata, for example the source of the snapshot.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// jsonRecord is a line of a snapshot in the JSON Lines format.
+// The first line is the header, which is encoded directly.
+type jsonRecord struct {
+	Key string `json:"k,omitempty"`
+	// Value if the codec is JSON. Embedded as is for easier inspection.
+	JSON json.RawMessage `json:"json,omitempty"`
+	// Value for all other codecs (base64 encoded).
+	Bytes []byte `json:"bytes,omitempty"`
+	// The footer has End set to true and contains the number of records.
+	End   bool `json:"end,omitempty"`
+	Count int  `json:"count,omitempty"`
+}
+
+// Export writes all key-value pairs of the store to w, in the JSON Lines format with JSON as codec.
+// The store must implement gokv.Lister.
+// See ExportWithOptions() for details.
+func Export(store gokv.Store, w io.Writer) error {
+	return ExportWithOptions(store, w, DefaultOptions)
+}
+
+// ExportWithOptions writes all key-value pairs of the store to w.
+// The store must implement gokv.Lister.
+//
+// If options.NewValue is nil, the values are exported as they're stored, without unmarshalling and marshalling them again.
+// This requires the store and options.MarshalFormat to use JSON.
+// Otherwise the values are retrieved into a value created by options.NewValue and then marshalled with options.MarshalFormat,
+// so the snapshot doesn't depend on the marshal format of the store.
+// Key-value pairs that are deleted during the export are skipped.
+func ExportWithOptions(store gokv.Store, w io.Writer, options Options) error {
+	lister, ok := store.(gokv.Lister)
+	if !ok {
+		return errors.New("The store doesn't support listing keys, which is required for exporting it")
+	}
+	if options.NewValue == nil && options.MarshalFormat != JSON {
+		return errors.New("Values can only be exported as they're stored with the JSON MarshalFormat. Set NewValue to a function that returns a pointer to the type of the values to export them with another MarshalFormat")
+	}
+
+	header := Header{
+		Version:  Version,
+		Created:  time.Now().UTC(),
+		Metadata: options.Metadata,
+	}
+	switch options.MarshalFormat {
+	case JSON:
+		header.Codec = "json"
+	case Gob:
+		header.Codec = "gob"
+	default:
+		return errors.New("The snapshot seems to be configured with a marshal format that's not implemented yet")
+	}
+
+	bufWriter := bufio.NewWriter(w)
+	var writer recordWriter
+	switch options.Format {
+	case JSONLines:
+		writer = &jsonLinesWriter{enc: json.NewEncoder(bufWriter), codec: header.Codec}
+	case Binary:
+		writer = &binaryWriter{w: bufWriter}
+	default:
+		return errors.New("The snapshot seems to be configured with a format that's not implemented yet")
+	}
+
+	if err := writer.writeHeader(header); err != nil {
+		return err
+	}
+	count := 0
+	err := lister.List(func(k string) error {
+		data, found, err := exportValue(store, k, header.Codec, options.NewValue)
+		if err != nil {
+			return err
+		}
+		// Deleted since the iteration started
+		if !found {
+			return nil
+		}
+		count++
+		return writer.writeRecord(k, data)
+	})
+	if err != nil {
+		return err
+	}
+	if err := writer.writeFooter(count); err != nil {
+		return err
+	}
+	return bufWriter.Flush()
+}
+
+// exportValue retrieves the value for the given key and returns it marshalled with the given codec.
+// If newValue is nil, the value is retrieved as json.RawMessage, which contains the data as it's stored
+// (as long as the store uses JSON), and returned as is.
+func exportValue(store gokv.Store, k string, codec string, newValue func() interface{}) ([]byte, bool, error) {
+	if newValue == nil {
+		raw := json.RawMessage{}
+		found, err := store.Get(k, &raw)
+		return raw, found, err
+	}
+	vPtr := newValue()
+	found, err := store.Get(k, vPtr)
+	if err != nil || !found {
+		return nil, found, err
+	}
+	data, err := marshal(codec, reflect.ValueOf(vPtr).Elem().Interface())
+	return data, true, err
+}
+
+// Import reads a snapshot from r and stores all its key-value pairs in the store.
+// Existing values with the same keys are overwritten.
+// See ImportWithOptions() for details.
+func Import(store gokv.Store, r io.Reader) (Header, error) {
+	return ImportWithOptions(store, r, DefaultOptions)
+}
+
+// ImportWithOptions reads a snapshot from r and stores all its key-value pairs in the store.
+// Existing values with the same keys are overwritten.
+//
+// The format and codec are read from the snapshot, so options.Format, options.MarshalFormat and options.Metadata are ignored.
+// If options.NewValue is nil, the values of snapshots with JSON as codec are stored as they are (as json.RawMessage),
+// without unmarshalling and marshalling them again. This requires the store to use JSON.
+// Otherwise the values are unmarshalled into a value created by options.NewValue before they're stored.
+// For snapshots with gob as codec NewValue is required, and the pointer must point to the actual type of the values.
+//
+// If the snapshot is truncated, the key-value pairs until the truncation are stored and an error is returned.
+func ImportWithOptions(store gokv.Store, r io.Reader, options Options) (Header, error) {
+	bufReader := bufio.NewReader(r)
+	var reader recordReader
+	prefix, err := bufReader.Peek(len(magic))
+	if err == nil && bytes.Equal(prefix, magic) {
+		reader = &binaryReader{r: bufReader}
+	} else {
+		reader = &jsonLinesReader{dec: json.NewDecoder(bufReader)}
+	}
+
+	header, err := reader.readHeader()
+	if err != nil {
+		return header, err
+	}
+	if header.Version > Version {
+		return header, errors.New("The snapshot has version " + strconv.Itoa(header.Version) + ", but only versions up to " + strconv.Itoa(Version) + " are supported")
+	}
+	if options.NewValue == nil && header.Codec != "json" {
+		return header, errors.New("The snapshot has the codec " + header.Codec + ", so NewValue must be set to a function that returns a pointer to the type of the values")
+	}
+
+	count := 0
+	for {
+		k, data, end, expectedCount, err := reader.readRecord()
+		if err == io.EOF {
+			return header, errors.New("The snapshot is truncated, only " + strconv.Itoa(count) + " key-value pairs were imported")
+		} else if err != nil {
+			return header, err
+		}
+		if end {
+			if expectedCount != count {
+				return header, errors.New("The snapshot should contain " + strconv.Itoa(expectedCount) + " key-value pairs, but contained " + strconv.Itoa(count))
+			}
+			return header, nil
+		}
+
+		var v interface{} = json.RawMessage(data)
+		if options.NewValue != nil {
+			vPtr := options.NewValue()
+			if err := unmarshal(header.Codec, data, vPtr); err != nil {
+				return header, err
+			}
+			v = reflect.ValueOf(vPtr).Elem().Interface()
+		}
+		if err := store.Set(k, v); err != nil {
+			return header, err
+		}
+		count++
+	}
+}
+
+type recordWriter interface {
+	writeHeader(header Header) error
+	writeRecord(k string, data []byte) error
+	writeFooter(count int) error
+}
+
+type recordReader interface {
+	readHeader() (Header, error)
+	// readRecord returns io.EOF if the input ends before the footer.
+	readRecord() (k string, data []byte, end bool, count int, err error)
+}
+
+type jsonLinesWriter struct {
+	enc   *json.Encoder
+	codec string
+}
+
+func (w *jsonLinesWriter) writeHeader(header Header) error {
+	return w.enc.Encode(header)
+}
+
+func (w *jsonLinesWriter) writeRecord(k string, data []byte) error {
+	record := jsonRecord{Key: k}
+	if w.codec == "json" {
+		record.JSON = data
+	} else {
+		record.Bytes = data
+	}
+	return w.enc.Encode(record)
+}
+
+func (w *jsonLinesWriter) writeFooter(count int) error {
+	return w.enc.Encode(jsonRecord{End: true, Count: count})
+}
+
+type jsonLinesReader struct {
+	dec *json.Decoder
+}
+
+func (r *jsonLinesReader) readHeader() (Header, error) {
+	header := Header{}
+	err := r.dec.Decode(&header)
+	if err == io.EOF {
+		return header, errors.New("The snapshot is empty")
+	}
+	return header, err
+}
+
+func (r *jsonLinesReader) readRecord() (string, []byte, bool, int, error) {
+	record := jsonRecord{}
+	if err := r.dec.Decode(&record); err != nil {
+		return "", nil, false, 0, err
+	}
+	if record.End {
+		return "", nil, true, record.Count, nil
+	}
+	if record.JSON != nil {
+		return record.Key, record.JSON, false, 0, nil
+	}
+	return record.Key, record.Bytes, false, 0, nil
+}
+
+// binaryWriter writes the binary format:
+// The magic bytes, the version (1 byte) and the length-prefixed JSON encoded header,
+// followed by records of length-prefixed keys and values.
+// The footer is an empty key followed by the number of records.
+// All lengths and the number of records are encoded as uvarint.
+type binaryWriter struct {
+	w *bufio.Writer
+}
+
+func (w *binaryWriter) writeHeader(header Header) error {
+	headerData, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	if _, err := w.w.Write(magic); err != nil {
+		return err
+	}
+	if err := w.w.WriteByte(byte(header.Version)); err != nil {
+		return err
+	}
+	return w.writeBytes(headerData)
+}
+
+func (w *binaryWriter) writeRecord(k string, data []byte) error {
+	if err := w.writeBytes([]byte(k)); err != nil {
+		return err
+	}
+	return w.writeBytes(data)
+}
+
+func (w *binaryWriter) writeFooter(count int) error {
+	if err := w.writeUvarint(0); err != nil {
+		return err
+	}
+	return w.writeUvarint(uint64(count))
+}
+
+func (w *binaryWriter) writeBytes(data []byte) error {
+	if len(data) > maxLength {
+		return errors.New("The binary format only supports keys and values of up to " + strconv.Itoa(maxLength) + " bytes")
+	}
+	if err := w.writeUvarint(uint64(len(data))); err != nil {
+		return err
+	}
+	_, err := w.w.Write(data)
+	return err
+}
+
+func (w *binaryWriter) writeUvarint(x uint64) error {
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, x)
+	_, err := w.w.Write(buf[:n])
+	return err
+}
+
+type binaryReader struct {
+	r *bufio.Reader
+}
+
+func (r *binaryReader) readHeader() (Header, error) {
+	header := Header{}
+	if _, err := r.r.Discard(len(magic)); err != nil {
+		return header, err
+	}
+	version, err := r.r.ReadByte()
+	if err != nil {
+		return header, err
+	}
+	headerData, err := r.readBytes()
+	if err != nil {
+		return header, err
+	}
+	if err := json.Unmarshal(headerData, &header); err != nil {
+		return header, err
+	}
+	if header.Version != int(version) {
+		return header, errors.New("The version in the header doesn't match the version after the magic bytes")
+	}
+	return header, nil
+}
+
+func (r *binaryReader) readRecord() (string, []byte, bool, int, error) {
+	k, err := r.readBytes()
+	if err != nil {
+		return "", nil, false, 0, err
+	}
+	// An empty key marks the footer
+	if len(k) == 0 {
+		count, err := binary.ReadUvarint(r.r)
+		if err != nil {
+			return "", nil, false, 0, err
+		}
+		return "", nil, true, int(count), nil
+	}
+	data, err := r.readBytes()
+	if err != nil {
+		return "", nil, false, 0, err
+	}
+	return string(k), data, false, 0, nil
+}
+
+// readBytes reads length-prefixed data.
+// The memory for the data is allocated while reading, not up front according to the length prefix,
+// so a corrupt length prefix can't lead to a huge allocation.
+func (r *binaryReader) readBytes() ([]byte, error) {
+	length, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return nil, err
+	}
+	if length > maxLength {
+		return nil, errors.New("The snapshot contains a length of " + strconv.FormatUint(length, 10) + " bytes, which exceeds the maximum of " + strconv.Itoa(maxLength) + " bytes, so it's probably corrupt")
+	}
+	buf := new(bytes.Buffer)
+	// CopyN returns io.EOF if the snapshot is truncated
+	_, err = io.CopyN(buf, r.r, int64(length))
+	return buf.Bytes(), err
+}
+
+func marshal(codec string, v interface{}) ([]byte, error) {
+	switch codec {
+	case "json":
+		return util.ToJSON(v)
+	case "gob":
+		return util.ToGob(v)
+	default:
+		return nil, errors.New("The codec " + codec + " is not supported")
+	}
+}
+
+func unmarshal(codec string, data []byte, v interface{}) error {
+	switch codec {
+	case "json":
+		return util.FromJSON(data, v)
+	case "gob":
+		return util.FromGob(data, v)
+	default:
+		return errors.New("The codec " + codec + " is not supported")
+	}
+}
+
+// Format is an enum for the available snapshot formats.
+type Format int
+
+const (
+	// JSONLines is the Format with one JSON object per line
+	JSONLines Format = iota
+	// Binary is the compact binary Format
+	Binary
+)
+
+// MarshalFormat is an enum for the available (un-)marshal formats of the values in a snapshot.
+type MarshalFormat int
+
+const (
+	// JSON is the MarshalFormat for (un-)marshalling to/from JSON
+	JSON MarshalFormat = iota
+	// Gob is the MarshalFormat for (un-)marshalling to/from gob
+	Gob
+)
+
+// Options are the options for exporting and importing snapshots.
+type Options struct {
+	// Format of the snapshot.
+	// Only relevant for exporting, when importing the format is detected automatically.
+	// Optional (JSONLines by default).
+	Format Format
+	// (Un-)marshal format of the values in the snapshot.
+	// Only relevant for exporting, when importing it's read from the header.
+	// Optional (JSON by default).
+	MarshalFormat MarshalFormat
+	// Function that returns a pointer to a new value into which the values are retrieved from the store
+	// (when exporting) or unmarshalled (when importing).
+	// If it's nil, the values are exported and imported as they're stored, which requires the store and the snapshot to use JSON.
+	// Otherwise the pointer must point to the actual type of the values.
+	// Optional (nil by default).
+	NewValue func() interface{}
+	// Custom metadata that's written to the header.
+	// Only relevant for exporting.
+	// Optional (nil by default).
+	Metadata map[string]string
+}
+
+// DefaultOptions is an Options object with default values.
+// Format: JSONLines, MarshalFormat: JSON, NewValue: nil, Metadata: nil
+var DefaultOptions = Options{
+	// No need to set any fields because their zero values are fine.
+}
@@ -0,0 +1,241 @@
+package snapshot_test
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/philippgille/gokv"
+	"github.com/philippgille/gokv/gomap"
+	"github.com/philippgille/gokv/snapshot"
+	"github.com/philippgille/gokv/syncmap"
+	"github.com/philippgille/gokv/test"
+)
+
+// TestRoundTrip tests if all key-value pairs of a store can be exported and imported into another store.
+func TestRoundTrip(t *testing.T) {
+	createTest := func(options snapshot.Options) func(t *testing.T) {
+		return func(t *testing.T) {
+			source := createSource(t, 100)
+			buf := new(bytes.Buffer)
+			err := snapshot.ExportWithOptions(source, buf, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			target := syncmap.NewStore(syncmap.DefaultOptions)
+			_, err = snapshot.ImportWithOptions(target, buf, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkValues(t, target, 100)
+		}
+	}
+	newValue := func() interface{} { return new(test.Foo) }
+	t.Run("JSON Lines with JSON", createTest(snapshot.DefaultOptions))
+	t.Run("JSON Lines with gob", createTest(snapshot.Options{MarshalFormat: snapshot.Gob, NewValue: newValue}))
+	t.Run("binary with JSON", createTest(snapshot.Options{Format: snapshot.Binary}))
+	t.Run("binary with gob", createTest(snapshot.Options{Format: snapshot.Binary, MarshalFormat: snapshot.Gob, NewValue: newValue}))
+}
+
+// TestHeader tests if the header is written and returned on import.
+func TestHeader(t *testing.T) {
+	source := createSource(t, 1)
+	buf := new(bytes.Buffer)
+	options := snapshot.Options{
+		Format:   snapshot.Binary,
+		Metadata: map[string]string{"source": "test"},
+	}
+	err := snapshot.ExportWithOptions(source, buf, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header, err := snapshot.Import(gomap.NewStore(gomap.DefaultOptions), buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Version != snapshot.Version {
+		t.Errorf("Expected: %v, but was: %v", snapshot.Version, header.Version)
+	}
+	if header.Codec != "json" {
+		t.Errorf("Expected: %v, but was: %v", "json", header.Codec)
+	}
+	if header.Metadata["source"] != "test" {
+		t.Errorf("Expected: %v, but was: %v", "test", header.Metadata["source"])
+	}
+	if header.Created.IsZero() {
+		t.Error("The creation time wasn't set, but should have been")
+	}
+}
+
+// TestJSONLines tests if the JSON Lines format contains one line per record and embeds JSON values as is.
+func TestJSONLines(t *testing.T) {
+	source := createSource(t, 3)
+	buf := new(bytes.Buffer)
+	err := snapshot.Export(source, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	// Header, 3 records and footer
+	if len(lines) != 5 {
+		t.Errorf("Expected 5 lines, but was %v", len(lines))
+	}
+	if !strings.Contains(buf.String(), `"json":{"Bar":"1"}`) {
+		t.Errorf("Expected the JSON value to be embedded, but the snapshot was: %v", buf.String())
+	}
+}
+
+// TestTruncated tests if importing a truncated snapshot leads to an error.
+func TestTruncated(t *testing.T) {
+	for _, format := range []snapshot.Format{snapshot.JSONLines, snapshot.Binary} {
+		source := createSource(t, 10)
+		buf := new(bytes.Buffer)
+		err := snapshot.ExportWithOptions(source, buf, snapshot.Options{Format: format})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data := buf.Bytes()
+		truncated := bytes.NewReader(data[:len(data)-10])
+		_, err = snapshot.Import(gomap.NewStore(gomap.DefaultOptions), truncated)
+		if err == nil {
+			t.Error("Expected an error")
+		}
+	}
+}
+
+// TestRawValues tests if values are exported and imported as they're stored when no NewValue function is set.
+func TestRawValues(t *testing.T) {
+	// The fields aren't in alphabetical order and the integer can't be represented exactly as float64,
+	// so unmarshalling into an interface{} and marshalling again would change the data.
+	type record struct {
+		Name string
+		ID   int64
+	}
+	source := gomap.NewStore(gomap.DefaultOptions)
+	expected := record{Name: "foo", ID: 1<<60 + 1}
+	err := source.Set("foo", expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []snapshot.Format{snapshot.JSONLines, snapshot.Binary} {
+		buf := new(bytes.Buffer)
+		err = snapshot.ExportWithOptions(source, buf, snapshot.Options{Format: format})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if format == snapshot.JSONLines && !strings.Contains(buf.String(), `"json":{"Name":"foo","ID":1152921504606846977}`) {
+			t.Errorf("Expected the value to be exported as it's stored, but the snapshot was: %v", buf.String())
+		}
+
+		target := gomap.NewStore(gomap.DefaultOptions)
+		_, err = snapshot.Import(target, buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := record{}
+		found, err := target.Get("foo", &actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !found || actual != expected {
+			t.Errorf("Expected: %+v, but was: %+v", expected, actual)
+		}
+	}
+}
+
+// TestCorrupt tests if importing a binary snapshot with a corrupt length prefix leads to an error.
+func TestCorrupt(t *testing.T) {
+	buf := new(bytes.Buffer)
+	err := snapshot.ExportWithOptions(createSource(t, 1), buf, snapshot.Options{Format: snapshot.Binary})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Magic bytes and version, followed by the maximum uvarint as length of the header
+	data := append(buf.Bytes()[:9], 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01)
+	_, err = snapshot.Import(gomap.NewStore(gomap.DefaultOptions), bytes.NewReader(data))
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+// TestErrors tests some error cases.
+func TestErrors(t *testing.T) {
+	// Test exporting a store that doesn't implement gokv.Lister
+	err := snapshot.Export(notLister{}, new(bytes.Buffer))
+	if err == nil {
+		t.Error("Expected an error")
+	}
+
+	// Test with a bad MarshalFormat enum value
+	err = snapshot.ExportWithOptions(createSource(t, 1), new(bytes.Buffer), snapshot.Options{MarshalFormat: snapshot.MarshalFormat(19)})
+	if err == nil {
+		t.Error("Expected an error")
+	}
+
+	// Test exporting with gob without NewValue
+	err = snapshot.ExportWithOptions(createSource(t, 1), new(bytes.Buffer), snapshot.Options{MarshalFormat: snapshot.Gob})
+	if err == nil {
+		t.Error("Expected an error")
+	}
+
+	// Test importing a snapshot with gob without NewValue
+	buf := new(bytes.Buffer)
+	err = snapshot.ExportWithOptions(createSource(t, 1), buf, snapshot.Options{MarshalFormat: snapshot.Gob, NewValue: func() interface{} { return new(test.Foo) }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = snapshot.Import(gomap.NewStore(gomap.DefaultOptions), buf)
+	if err == nil {
+		t.Error("Expected an error")
+	}
+
+	// Test importing an empty snapshot
+	_, err = snapshot.Import(gomap.NewStore(gomap.DefaultOptions), new(bytes.Buffer))
+	if err == nil {
+		t.Error("Expected an error")
+	}
+
+	// Test importing a snapshot with a newer version
+	newer := strings.NewReader(`{"version":` + strconv.Itoa(snapshot.Version+1) + `,"codec":"json"}` + "\n")
+	_, err = snapshot.Import(gomap.NewStore(gomap.DefaultOptions), newer)
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+// notLister is a gokv.Store that doesn't implement gokv.Lister.
+type notLister struct {
+	gokv.Store
+}
+
+func createSource(t *testing.T, n int) gokv.Store {
+	store := gomap.NewStore(gomap.DefaultOptions)
+	for i := 0; i < n; i++ {
+		err := store.Set(strconv.Itoa(i), test.Foo{Bar: strconv.Itoa(i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return store
+}
+
+func checkValues(t *testing.T, store gokv.Store, n int) {
+	for i := 0; i < n; i++ {
+		actualPtr := new(test.Foo)
+		found, err := store.Get(strconv.Itoa(i), actualPtr)
+		if err != nil {
+			t.Error(err)
+		}
+		if !found {
+			t.Errorf("No value was found for key %v, but should have been", i)
+		} else if actualPtr.Bar != strconv.Itoa(i) {
+			t.Errorf("Expected: %v, but was: %v", i, actualPtr.Bar)
+		}
+	}
+}
@@ -0,0 +1,6 @@
+/*
+Package sqlite contains an implementation of the `gokv.Store` interface for SQLite.
+
+It uses the github.com/mattn/go-sqlite3 driver, which requires cgo.
+*/
+package sqlite
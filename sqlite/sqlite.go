@@ -0,0 +1,218 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	// Registers the "sqlite3" driver
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/philippgille/gokv/util"
+)
+
+var defaultBusyTimeout = 5 * time.Second
+
+// Store is a gokv.Store implementation for SQLite.
+type Store struct {
+	db            *sql.DB
+	upsertStmt    *sql.Stmt
+	getStmt       *sql.Stmt
+	deleteStmt    *sql.Stmt
+	marshalFormat MarshalFormat
+}
+
+// Set stores the given value for the given key.
+// Values are automatically marshalled to JSON or gob (depending on the configuration).
+// The key must not be "" and the value must not be nil.
+func (s Store) Set(k string, v interface{}) error {
+	if err := util.CheckKeyAndValue(k, v); err != nil {
+		return err
+	}
+
+	// First turn the passed object into something that SQLite can handle
+	var data []byte
+	var err error
+	switch s.marshalFormat {
+	case JSON:
+		data, err = util.ToJSON(v)
+	case Gob:
+		data, err = util.ToGob(v)
+	default:
+		err = errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = s.upsertStmt.Exec(k, data)
+	return err
+}
+
+// Get retrieves the stored value for the given key.
+// You need to pass a pointer to the value, so in case of a struct
+// the automatic unmarshalling can populate the fields of the object
+// that v points to with the values of the retrieved object's values.
+// If no value is found it returns (false, nil).
+// The key must not be "" and the pointer must not be nil.
+func (s Store) Get(k string, v interface{}) (found bool, err error) {
+	if err := util.CheckKeyAndValue(k, v); err != nil {
+		return false, err
+	}
+
+	var data []byte
+	err = s.getStmt.QueryRow(k).Scan(&data)
+	// If no value was found return false
+	if err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	switch s.marshalFormat {
+	case JSON:
+		return true, util.FromJSON(data, v)
+	case Gob:
+		return true, util.FromGob(data, v)
+	default:
+		return true, errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	}
+}
+
+// Delete deletes the stored value for the given key.
+// Deleting a non-existing key-value pair does NOT lead to an error.
+// The key must not be "".
+func (s Store) Delete(k string) error {
+	if err := util.CheckKey(k); err != nil {
+		return err
+	}
+
+	_, err := s.deleteStmt.Exec(k)
+	return err
+}
+
+// Close closes the store.
+// It must be called to close the prepared statements and the database file.
+func (s Store) Close() error {
+	// Close the statements first, because they use the DB's connections.
+	s.upsertStmt.Close()
+	s.getStmt.Close()
+	s.deleteStmt.Close()
+	return s.db.Close()
+}
+
+// MarshalFormat is an enum for the available (un-)marshal formats of this gokv.Store implementation.
+type MarshalFormat int
+
+const (
+	// JSON is the MarshalFormat for (un-)marshalling to/from JSON
+	JSON MarshalFormat = iota
+	// Gob is the MarshalFormat for (un-)marshalling to/from gob
+	Gob
+)
+
+// Options are the options for the SQLite store.
+type Options struct {
+	// Path of the DB file.
+	// It can contain additional parameters for the driver, for example "gokv.db?_synchronous=NORMAL".
+	// See https://github.com/mattn/go-sqlite3#connection-string for the available parameters.
+	// Optional ("gokv.db" by default).
+	Path string
+	// Name of the table in which the key-value pairs are stored.
+	// Optional ("Item" by default).
+	TableName string
+	// Time that an operation waits for a lock held by another connection or process
+	// before it fails with "database is locked".
+	// Optional (5 * time.Second by default).
+	BusyTimeout *time.Duration
+	// (Un-)marshal format.
+	// Optional (JSON by default).
+	MarshalFormat MarshalFormat
+}
+
+// DefaultOptions is an Options object with default values.
+// Path: "gokv.db", TableName: "Item", BusyTimeout: 5 * time.Second, MarshalFormat: JSON
+var DefaultOptions = Options{
+	Path:        "gokv.db",
+	TableName:   "Item",
+	BusyTimeout: &defaultBusyTimeout,
+	// No need to set MarshalFormat to JSON because its zero value is fine.
+}
+
+// NewStore creates a new SQLite store.
+// The DB file and the table are created if they don't exist yet.
+//
+// The DB is opened in WAL mode, which allows reads while another connection writes
+// and leads to two additional files next to the DB file ("-wal" and "-shm").
+// Multiple stores and processes can use the same DB file,
+// as long as it's not located on a network file system.
+func NewStore(options Options) (Store, error) {
+	result := Store{}
+
+	// Set default values
+	if options.Path == "" {
+		options.Path = DefaultOptions.Path
+	}
+	if options.TableName == "" {
+		options.TableName = DefaultOptions.TableName
+	}
+	if options.BusyTimeout == nil {
+		options.BusyTimeout = DefaultOptions.BusyTimeout
+	}
+
+	// The busy timeout is a setting per connection, so it must be part of the connection string
+	// to apply to all connections in the pool.
+	dsn := options.Path
+	if strings.Contains(dsn, "?") {
+		dsn += "&"
+	} else {
+		dsn += "?"
+	}
+	busyTimeoutMillis := int64(*options.BusyTimeout / time.Millisecond)
+	dsn += "_busy_timeout=" + strconv.FormatInt(busyTimeoutMillis, 10) + "&_journal_mode=WAL"
+
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return result, err
+	}
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+		return result, err
+	}
+
+	// Create table if it doesn't exist yet.
+	_, err = db.Exec("CREATE TABLE IF NOT EXISTS " + options.TableName + " (k TEXT PRIMARY KEY, v BLOB NOT NULL)")
+	if err != nil {
+		db.Close()
+		return result, err
+	}
+
+	// Create prepared statements that will be reused for every Set()/Get()/Delete() operation.
+	// The upsert syntax requires SQLite 3.24.0 or newer, which the driver bundles.
+	upsertStmt, err := db.Prepare("INSERT INTO " + options.TableName + " (k, v) VALUES (?, ?) ON CONFLICT (k) DO UPDATE SET v = excluded.v")
+	if err != nil {
+		db.Close()
+		return result, err
+	}
+	getStmt, err := db.Prepare("SELECT v FROM " + options.TableName + " WHERE k = ?")
+	if err != nil {
+		db.Close()
+		return result, err
+	}
+	deleteStmt, err := db.Prepare("DELETE FROM " + options.TableName + " WHERE k = ?")
+	if err != nil {
+		db.Close()
+		return result, err
+	}
+
+	result.db = db
+	result.upsertStmt = upsertStmt
+	result.getStmt = getStmt
+	result.deleteStmt = deleteStmt
+	result.marshalFormat = options.MarshalFormat
+
+	return result, nil
+}
@@ -0,0 +1,193 @@
+package sqlite_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/philippgille/gokv/sqlite"
+	"github.com/philippgille/gokv/test"
+)
+
+// TestStore tests if reading from, writing to and deleting from the store works properly.
+// A struct is used as value. See TestTypes() for a test that is simpler but tests all types.
+func TestStore(t *testing.T) {
+	// Test with JSON
+	t.Run("JSON", func(t *testing.T) {
+		store := createStore(t, sqlite.JSON)
+		test.TestStore(store, t)
+	})
+
+	// Test with gob
+	t.Run("gob", func(t *testing.T) {
+		store := createStore(t, sqlite.Gob)
+		test.TestStore(store, t)
+	})
+}
+
+// TestTypes tests if setting and getting values works with all Go types.
+func TestTypes(t *testing.T) {
+	// Test with JSON
+	t.Run("JSON", func(t *testing.T) {
+		store := createStore(t, sqlite.JSON)
+		test.TestTypes(store, t)
+	})
+
+	// Test with gob
+	t.Run("gob", func(t *testing.T) {
+		store := createStore(t, sqlite.Gob)
+		test.TestTypes(store, t)
+	})
+}
+
+// TestStoreConcurrent launches a bunch of goroutines that concurrently work with one store.
+// SQLite only allows one writer at a time, so this tests if the busy timeout prevents "database is locked" errors.
+func TestStoreConcurrent(t *testing.T) {
+	store := createStore(t, sqlite.JSON)
+
+	goroutineCount := 1000
+
+	test.TestConcurrentInteractions(t, goroutineCount, store)
+}
+
+// TestSharedFile tests if multiple stores can use the same DB file.
+func TestSharedFile(t *testing.T) {
+	options := sqlite.Options{
+		Path: generateRandomTempDbPath(t),
+	}
+	store1, err := sqlite.NewStore(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store1.Close()
+	store2, err := sqlite.NewStore(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store2.Close()
+
+	err = store1.Set("foo", test.Foo{Bar: "baz"})
+	if err != nil {
+		t.Error(err)
+	}
+	actualPtr := new(test.Foo)
+	found, err := store2.Get("foo", actualPtr)
+	if err != nil {
+		t.Error(err)
+	}
+	if !found {
+		t.Error("No value was found, but should have been")
+	}
+	if actualPtr.Bar != "baz" {
+		t.Errorf("Expected: %v, but was: %v", "baz", actualPtr.Bar)
+	}
+}
+
+// TestErrors tests some error cases.
+func TestErrors(t *testing.T) {
+	// Test with a bad MarshalFormat enum value
+
+	store := createStore(t, sqlite.MarshalFormat(19))
+	err := store.Set("foo", "bar")
+	if err == nil {
+		t.Error("An error should have occurred, but didn't")
+	}
+
+	// Test empty key
+	err = store.Set("", "bar")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	_, err = store.Get("", new(string))
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	err = store.Delete("")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+// TestNil tests the behaviour when passing nil or pointers to nil values to some methods.
+func TestNil(t *testing.T) {
+	// Test setting nil
+
+	t.Run("set nil with JSON marshalling", func(t *testing.T) {
+		store := createStore(t, sqlite.JSON)
+		err := store.Set("foo", nil)
+		if err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	t.Run("set nil with Gob marshalling", func(t *testing.T) {
+		store := createStore(t, sqlite.Gob)
+		err := store.Set("foo", nil)
+		if err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	// Test passing nil or pointer to nil value for retrieval
+
+	createTest := func(mf sqlite.MarshalFormat) func(t *testing.T) {
+		return func(t *testing.T) {
+			store := createStore(t, mf)
+
+			// Prep
+			err := store.Set("foo", test.Foo{Bar: "baz"})
+			if err != nil {
+				t.Error(err)
+			}
+
+			_, err = store.Get("foo", nil) // actually nil
+			if err == nil {
+				t.Error("An error was expected")
+			}
+
+			var i interface{} // actually nil
+			_, err = store.Get("foo", i)
+			if err == nil {
+				t.Error("An error was expected")
+			}
+
+			var valPtr *test.Foo // nil value
+			_, err = store.Get("foo", valPtr)
+			if err == nil {
+				t.Error("An error was expected")
+			}
+		}
+	}
+	t.Run("get with nil / nil value parameter", createTest(sqlite.JSON))
+	t.Run("get with nil / nil value parameter", createTest(sqlite.Gob))
+}
+
+// TestClose tests if the close method returns any errors.
+func TestClose(t *testing.T) {
+	store := createStore(t, sqlite.JSON)
+	err := store.Close()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func createStore(t *testing.T, mf sqlite.MarshalFormat) sqlite.Store {
+	options := sqlite.Options{
+		Path:          generateRandomTempDbPath(t),
+		MarshalFormat: mf,
+	}
+	store, err := sqlite.NewStore(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func generateRandomTempDbPath(t *testing.T) string {
+	path, err := ioutil.TempDir(os.TempDir(), "sqlite")
+	if err != nil {
+		t.Fatalf("Generating random DB path failed: %v", err)
+	}
+	path += "/sqlite.db"
+	return path
+}
@@ -1,5 +1,9 @@
 package gokv
 
+import (
+	"context"
+)
+
 // Store is an abstraction for different key-value store implementations.
 // A store must be able to store, retrieve and delete key-value pairs,
 // with the key being a string and the value being any Go interface{}.
@@ -38,3 +42,82 @@ type Store interface {
 	// is passed to your method, so you should always call it.
 	Close() error
 }
+
+// Lister is an optional interface for gokv.Store implementations that can enumerate the keys they contain.
+// Functions that take a gokv.Store as parameter can check with a type assertion if the store implements it.
+type Lister interface {
+	// List calls fn for every key in the store, in no particular order.
+	// If fn returns an error, the iteration is stopped and the error is returned.
+	// Whether key-value pairs that are stored or deleted during the iteration are included
+	// depends on the implementation.
+	// fn may call Get, but calling methods that modify the store can lead to a deadlock
+	// in some implementations.
+	List(fn func(k string) error) error
+}
+
+// Batcher is an optional interface for gokv.Store implementations that can store and delete
+// multiple key-value pairs in one operation.
+type Batcher interface {
+	// SetMany stores the given values for the given keys.
+	// The same rules as for Set apply to every key and value.
+	// Whether the operation is atomic depends on the implementation.
+	SetMany(kvs map[string]interface{}) error
+	// DeleteMany deletes the stored values for the given keys.
+	// The same rules as for Delete apply to every key.
+	// Whether the operation is atomic depends on the implementation.
+	DeleteMany(ks []string) error
+}
+
+// CompareAndSwapper is an optional interface for gokv.Store implementations that can atomically
+// replace a value only if it hasn't changed since it was read.
+type CompareAndSwapper interface {
+	// CompareAndSwap stores newV for the given key, but only if the currently stored value equals oldV.
+	// Values are compared in their marshalled form, so oldV should be the value
+	// that was previously retrieved with Get, unmarshalled into the same type.
+	// If no value is stored for the key or the stored value differs, it returns (false, nil).
+	// The key must not be "" and the values must not be nil.
+	CompareAndSwap(k string, oldV, newV interface{}) (swapped bool, err error)
+}
+
+// WatchEvent is a notification about a changed key-value pair.
+type WatchEvent struct {
+	// Key of the changed key-value pair.
+	Key string
+	// Deleted is true if the key-value pair was deleted and false if a value was stored.
+	Deleted bool
+}
+
+// Watcher is an optional interface for gokv.Store implementations that can notify about changes.
+type Watcher interface {
+	// Watch returns a channel that receives an event for every key-value pair
+	// with a key that starts with the given prefix that is stored or deleted after the call.
+	// An empty prefix matches all keys.
+	// The value isn't part of the event, it can be retrieved with Get.
+	// The channel is closed when the context is done.
+	// Implementations can close it earlier, for example when the connection breaks
+	// or when the receiver doesn't keep up with the events. Events can be missed in that case,
+	// so the receiver should call Watch again and re-read the values it's interested in.
+	Watch(ctx context.Context, prefix string) (<-chan WatchEvent, error)
+}
+
+// Locker is an optional interface for gokv.Store implementations that provide distributed locks,
+// which can be used for mutual exclusion and leader election.
+type Locker interface {
+	// Lock acquires the lock with the given name.
+	// It blocks until the lock is acquired or the context is done, in which case it returns the context's error.
+	// The lock is renewed automatically in the background until it's unlocked.
+	// Lock names are independent of the keys of the key-value pairs in the store.
+	Lock(ctx context.Context, name string) (Lock, error)
+}
+
+// Lock is a distributed lock that was acquired with a Locker.
+type Lock interface {
+	// Unlock releases the lock and stops its renewal.
+	// Calling it more than once has no effect.
+	Unlock() error
+	// Lost returns a channel that is closed when the lock was lost while it was held,
+	// for example because it couldn't be renewed in time due to network problems.
+	// After that another process might hold the lock, so the work that requires the lock should be stopped.
+	// The channel isn't closed by Unlock.
+	Lost() <-chan struct{}
+}
@@ -77,6 +77,19 @@ func (m Store) Delete(k string) error {
 	return nil
 }
 
+// List calls fn for every key in the store, in no particular order.
+// If fn returns an error, the iteration is stopped and the error is returned.
+// Key-value pairs that are stored or deleted during the iteration
+// may or may not be included (see sync.Map.Range()).
+func (m Store) List(fn func(k string) error) error {
+	var err error
+	m.m.Range(func(key, _ interface{}) bool {
+		err = fn(key.(string))
+		return err == nil
+	})
+	return err
+}
+
 // Close closes the store.
 // When called, the store's pointer to the internal Go map is set to nil,
 // leading to the map being free for garbage collection.
@@ -47,6 +47,12 @@ func TestStoreConcurrent(t *testing.T) {
 	test.TestConcurrentInteractions(t, goroutineCount, store)
 }
 
+// TestList tests if all stored keys are enumerated.
+func TestList(t *testing.T) {
+	store := createStore(t, syncmap.JSON)
+	test.TestList(store, t)
+}
+
 // TestErrors tests some error cases.
 func TestErrors(t *testing.T) {
 	// Test with a bad MarshalFormat enum value
@@ -1,4 +1,26 @@
 /*
 Package tablestorage contains an implementation of the `gokv.Store` interface for Azure Table Storage.
+
+By default every value is marshalled and stored in the binary property "v" of an entity,
+with the key as RowKey.
+With the NativeProperties option, structs are stored as native entity properties instead,
+which makes their fields visible in the Azure portal and Storage Explorer and allows querying them.
+Every exported struct field is stored in a property with the name of the struct field,
+which can be changed with a `tablestorage:"name"` tag. Fields with a `tablestorage:"-"` tag are skipped.
+The names "PartitionKey", "RowKey", "Timestamp" and "v" are reserved,
+so storing a struct with a field that uses one of them leads to an error.
+
+Fields are stored with the following property types:
+
+	string: String
+	bool: Boolean
+	int8, int16, int32, uint8, uint16: Int32
+	int, int64, uint, uint32, uint64: Int64 (uint and uint64 values must not exceed the maximum int64)
+	float32, float64: Double
+	[]byte: Binary
+	time.Time: DateTime
+
+All other fields, like slices, maps and structs, are marshalled with the configured marshal format
+and stored as String property with JSON or as Binary property with gob.
 */
 package tablestorage
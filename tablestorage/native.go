@@ -0,0 +1,214 @@
+package tablestorage
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/philippgille/gokv/util"
+)
+
+// Key of the struct tag for renaming the property of a struct field.
+const propertyTagKey = "tablestorage"
+
+// Property names that are used by Table Storage or by this package, so struct fields must not use them.
+var reservedPropertyNames = []string{"PartitionKey", "RowKey", "Timestamp", valAttrName}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// toProperties converts the exported fields of the given struct (or pointer to a struct) to entity properties.
+func (c Client) toProperties(v interface{}) (map[string]interface{}, error) {
+	structVal := reflect.Indirect(reflect.ValueOf(v))
+	if !structVal.IsValid() {
+		return nil, errors.New("The value must not be a nil pointer")
+	}
+	properties := make(map[string]interface{})
+	for _, field := range util.StructFields(structVal.Type(), propertyTagKey) {
+		for _, reserved := range reservedPropertyNames {
+			if field.Name == reserved {
+				return nil, util.ReservedFieldNameError("property", reserved, propertyTagKey)
+			}
+		}
+		property, err := c.toProperty(structVal.Field(field.Index))
+		if err != nil {
+			return nil, err
+		}
+		properties[field.Name] = property
+	}
+	return properties, nil
+}
+
+// fromProperties populates the fields of the struct that v points to with the given entity properties.
+// Properties without a corresponding struct field are ignored.
+func (c Client) fromProperties(properties map[string]interface{}, v interface{}) error {
+	structVal := reflect.ValueOf(v).Elem()
+	if structVal.Kind() != reflect.Struct {
+		return errors.New("The value was stored as entity properties, so it can only be retrieved as struct")
+	}
+	for _, field := range util.StructFields(structVal.Type(), propertyTagKey) {
+		property, ok := properties[field.Name]
+		if !ok || property == nil {
+			continue
+		}
+		if err := c.fromProperty(property, structVal.Field(field.Index)); err != nil {
+			return fmt.Errorf("The property %v can't be converted: %v", field.Name, err)
+		}
+	}
+	return nil
+}
+
+// toProperty converts the given field to a value with a type that Table Storage supports natively.
+// Smaller integers are stored as Int32 and larger ones as Int64.
+// Values that don't have a corresponding Table Storage type, like slices, maps and structs,
+// are marshalled with the configured marshal format.
+// With JSON they're stored as string property, which keeps them readable, with gob as binary property.
+func (c Client) toProperty(v reflect.Value) (interface{}, error) {
+	if v.Type() == timeType {
+		return v.Interface(), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return int32(v.Int()), nil
+	case reflect.Int, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint8, reflect.Uint16:
+		return int32(v.Uint()), nil
+	case reflect.Uint, reflect.Uint32, reflect.Uint64:
+		if v.Uint() > math.MaxInt64 {
+			return nil, errors.New("Unsigned integers greater than the maximum int64 can't be stored as entity property")
+		}
+		return int64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Bytes(), nil
+		}
+	}
+
+	data, err := c.marshal(v.Interface())
+	if err != nil {
+		return nil, err
+	}
+	if c.marshalFormat == JSON {
+		return string(data), nil
+	}
+	return data, nil
+}
+
+// fromProperty is the reverse of toProperty.
+// Table Storage returns Int32 and Double properties as float64 and Int64 properties as int64,
+// so numbers are converted to the type of the field, as long as they fit.
+func (c Client) fromProperty(property interface{}, v reflect.Value) error {
+	if v.Type() == timeType {
+		t, ok := property.(time.Time)
+		if !ok {
+			return fmt.Errorf("Expected a time.Time, but was %T", property)
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		s, ok := property.(string)
+		if !ok {
+			return fmt.Errorf("Expected a string, but was %T", property)
+		}
+		v.SetString(s)
+		return nil
+	case reflect.Bool:
+		b, ok := property.(bool)
+		if !ok {
+			return fmt.Errorf("Expected a bool, but was %T", property)
+		}
+		v.SetBool(b)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := toInt64(property)
+		if !ok || v.OverflowInt(i) {
+			return fmt.Errorf("Expected an integer that fits into %v, but was %v", v.Type(), property)
+		}
+		v.SetInt(i)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, ok := toInt64(property)
+		if !ok || i < 0 || v.OverflowUint(uint64(i)) {
+			return fmt.Errorf("Expected an integer that fits into %v, but was %v", v.Type(), property)
+		}
+		v.SetUint(uint64(i))
+		return nil
+	case reflect.Float32, reflect.Float64:
+		n, ok := toFloat64(property)
+		if !ok {
+			return fmt.Errorf("Expected a number, but was %T", property)
+		}
+		v.SetFloat(n)
+		return nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b, ok := property.([]byte)
+			if !ok {
+				return fmt.Errorf("Expected a slice of bytes, but was %T", property)
+			}
+			v.SetBytes(b)
+			return nil
+		}
+	}
+
+	switch data := property.(type) {
+	case string:
+		return c.unmarshal([]byte(data), v.Addr().Interface())
+	case []byte:
+		return c.unmarshal(data, v.Addr().Interface())
+	default:
+		return fmt.Errorf("Expected a marshalled value, but was %T", property)
+	}
+}
+
+// toInt64 converts the numeric property values that Table Storage returns to int64,
+// as long as they're integers that fit into an int64.
+func toInt64(property interface{}) (int64, bool) {
+	switch n := property.(type) {
+	case int64:
+		return n, true
+	case int32:
+		return int64(n), true
+	case int:
+		return int64(n), true
+	case float64:
+		// float64(math.MaxInt64) is rounded up to 2^63, which doesn't fit into an int64 anymore
+		if n != math.Trunc(n) || n < math.MinInt64 || n >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(n), true
+	default:
+		return 0, false
+	}
+}
+
+// toFloat64 converts the numeric property values that Table Storage returns to float64.
+func toFloat64(property interface{}) (float64, bool) {
+	switch n := property.(type) {
+	case float64:
+		return n, true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
+
+// isNativeStorable returns true if values of the given type are stored as entity properties in the NativeProperties mode.
+func isNativeStorable(t reflect.Type) bool {
+	return len(util.StructFields(t, propertyTagKey)) > 0
+}
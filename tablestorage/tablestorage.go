@@ -4,10 +4,12 @@ import (
 	"crypto/md5"
 	"errors"
 	"fmt"
+	"reflect"
 	"strconv"
 
 	"github.com/Azure/azure-sdk-for-go/storage"
 
+	"github.com/philippgille/gokv"
 	"github.com/philippgille/gokv/util"
 )
 
@@ -26,36 +28,38 @@ var setupTimeout = uint(2)
 type Client struct {
 	c                    *storage.Table
 	partitionKeySupplier func(k string) string
+	nativeProperties     bool
 	marshalFormat        MarshalFormat
 }
 
 // Set stores the given value for the given key.
 // Values are automatically marshalled to JSON or gob (depending on the configuration).
+// With the NativeProperties option, structs are stored as native entity properties instead (see the package documentation).
 // The key must not be "" and the value must not be nil.
 func (c Client) Set(k string, v interface{}) error {
 	if err := util.CheckKeyAndValue(k, v); err != nil {
 		return err
 	}
 
-	// First turn the passed object into something that Table Storage can handle.
-	var data []byte
+	var valMap map[string]interface{}
 	var err error
-	switch c.marshalFormat {
-	case JSON:
-		data, err = util.ToJSON(v)
-	case Gob:
-		data, err = util.ToGob(v)
-	default:
-		err = errors.New("The store seems to be configured with a marshal format that's not implemented yet")
-	}
-	if err != nil {
-		return err
+	if c.nativeProperties && isNativeStorable(reflect.TypeOf(v)) {
+		valMap, err = c.toProperties(v)
+		if err != nil {
+			return err
+		}
+	} else {
+		// First turn the passed object into something that Table Storage can handle.
+		data, err := c.marshal(v)
+		if err != nil {
+			return err
+		}
+		valMap = make(map[string]interface{})
+		valMap[valAttrName] = data
 	}
 
 	partitionKey := c.partitionKeySupplier(k)
 	entity := c.c.GetEntityReference(partitionKey, k)
-	valMap := make(map[string]interface{})
-	valMap[valAttrName] = data
 	entity.Properties = valMap
 	entityOptions := storage.EntityOptions{
 		Timeout: opTimeout,
@@ -71,6 +75,7 @@ func (c Client) Set(k string, v interface{}) error {
 // You need to pass a pointer to the value, so in case of a struct
 // the automatic unmarshalling can populate the fields of the object
 // that v points to with the values of the retrieved object's values.
+// With the NativeProperties option, values that are stored as native properties can be retrieved as well.
 // If no value is found it returns (false, nil).
 // The key must not be "" and the pointer must not be nil.
 func (c Client) Get(k string, v interface{}) (found bool, err error) {
@@ -80,8 +85,10 @@ func (c Client) Get(k string, v interface{}) (found bool, err error) {
 
 	partitionKey := c.partitionKeySupplier(k)
 	entity := c.c.GetEntityReference(partitionKey, k)
-	getEntityOptions := storage.GetEntityOptions{
-		Select: []string{valAttrName},
+	getEntityOptions := storage.GetEntityOptions{}
+	// Native properties can have any name, so all properties are required in that case.
+	if !c.nativeProperties {
+		getEntityOptions.Select = []string{valAttrName}
 	}
 	timeout := uint(opTimeout)
 	err = entity.Get(timeout, storage.FullMetadata, &getEntityOptions)
@@ -97,20 +104,17 @@ func (c Client) Get(k string, v interface{}) (found bool, err error) {
 		}
 		return false, err
 	}
-	retrievedVal := entity.Properties[valAttrName]
+	retrievedVal, ok := entity.Properties[valAttrName]
+	if !ok && c.nativeProperties {
+		// The value was stored as native properties.
+		return true, c.fromProperties(entity.Properties, v)
+	}
 	data, ok := retrievedVal.([]byte)
 	if !ok {
 		return true, fmt.Errorf("The value belonging to the key was expected to be a slice of bytes, but wasn't. Key: %v", k)
 	}
 
-	switch c.marshalFormat {
-	case JSON:
-		return true, util.FromJSON([]byte(data), v)
-	case Gob:
-		return true, util.FromGob([]byte(data), v)
-	default:
-		return true, errors.New("The store seems to be configured with a marshal format that's not implemented yet")
-	}
+	return true, c.unmarshal(data, v)
 }
 
 // Delete deletes the stored value for the given key.
@@ -142,6 +146,30 @@ func (c Client) Delete(k string) error {
 	return err
 }
 
+// marshal marshals the given value according to the configured marshal format.
+func (c Client) marshal(v interface{}) ([]byte, error) {
+	switch c.marshalFormat {
+	case JSON:
+		return util.ToJSON(v)
+	case Gob:
+		return util.ToGob(v)
+	default:
+		return nil, errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	}
+}
+
+// unmarshal unmarshals the given data according to the configured marshal format.
+func (c Client) unmarshal(data []byte, v interface{}) error {
+	switch c.marshalFormat {
+	case JSON:
+		return util.FromJSON(data, v)
+	case Gob:
+		return util.FromGob(data, v)
+	default:
+		return errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	}
+}
+
 // Close closes the client.
 // In the Table Storage implementation this doesn't have any effect.
 func (c Client) Close() error {
@@ -167,7 +195,7 @@ type Options struct {
 	// Example: "TableEndpoint=https://foo.table.core.windows.net/;SharedAccessSignature=sv=2017-11-09&ss=t&srt=sco&sp=rwdlacu&se=2018-01-01T00:00:00Z&st=2018-01-02T00:00:00Z&spr=https&sig=abc123"
 	ConnectionString string
 	// Name of the table.
-	// If the table doesn't exist yet, it's created automatically.
+	// If the table doesn't exist yet, it's created automatically, unless DisableAutoProvisioning is set.
 	// Optional ("gokv" by default).
 	TableName string
 	// PartitionKeySupplier is a function for supplying a "partition key" for a given key.
@@ -203,6 +231,19 @@ type Options struct {
 	// (Un-)marshal format.
 	// Optional (JSON by default).
 	MarshalFormat MarshalFormat
+	// Prevents NewClient() from creating the table.
+	// Instead, it returns a *gokv.ResourceNotFoundError if the table doesn't exist.
+	// This is useful when the connection string (for example a shared access signature)
+	// doesn't allow creating tables.
+	// The table can then be created separately with Provision().
+	// Optional (false by default).
+	DisableAutoProvisioning bool
+	// If true, structs (and pointers to structs) are stored as native entity properties,
+	// with one property per exported struct field, so that the Azure portal, Storage Explorer and queries can access them.
+	// See the package documentation for details.
+	// Other values are still marshalled and stored in a binary property.
+	// Optional (false by default).
+	NativeProperties bool
 }
 
 // DefaultOptions is an Options object with default values.
@@ -214,6 +255,8 @@ var DefaultOptions = Options{
 }
 
 // NewClient creates a new Table Storage client.
+//
+// Unless DisableAutoProvisioning is set, the table is created if it doesn't exist yet.
 func NewClient(options Options) (Client, error) {
 	result := Client{}
 
@@ -230,39 +273,83 @@ func NewClient(options Options) (Client, error) {
 		options.PartitionKeySupplier = DefaultOptions.PartitionKeySupplier
 	}
 
-	storageClient, err := storage.NewClientFromConnectionString(options.ConnectionString)
+	table, err := getTable(options)
 	if err != nil {
-		return result, nil
+		return result, err
 	}
-
-	tableService := storageClient.GetTableService()
-	tableServicePtr := &tableService
-	table := tableServicePtr.GetTableReference(options.TableName)
 	err = table.Get(setupTimeout, storage.NoMetadata)
 	if err != nil {
-		storageErr, ok := err.(storage.AzureStorageServiceError)
-		if !ok {
+		if !isResourceNotFound(err) {
 			return result, err
 		}
-		// Handle AzureStorageServiceError.
-		// If the table wasn't found, create it.
-		if storageErr.Code == "ResourceNotFound" {
-			err = table.Create(setupTimeout, storage.EmptyPayload, nil)
-			if err != nil {
-				return result, err
-			}
-		} else {
+		// If the table wasn't found, create it, unless automatic provisioning is disabled.
+		if options.DisableAutoProvisioning {
+			return result, &gokv.ResourceNotFoundError{ResourceType: "table", Name: options.TableName}
+		}
+		err = table.Create(setupTimeout, storage.EmptyPayload, nil)
+		if err != nil {
 			return result, err
 		}
 	}
 
 	result.c = table
 	result.partitionKeySupplier = options.PartitionKeySupplier
+	result.nativeProperties = options.NativeProperties
 	result.marshalFormat = options.MarshalFormat
 
 	return result, nil
 }
 
+// Provision creates the table for the given options if it doesn't exist yet, the same way NewClient() does.
+// It's meant to be called once in a separate setup step,
+// for example with a connection string that has more permissions than the one that's used by the application.
+// DisableAutoProvisioning is ignored.
+func Provision(options Options) error {
+	options.DisableAutoProvisioning = false
+	_, err := NewClient(options)
+	return err
+}
+
+// Drop deletes the table for the given options, including all stored key-value pairs.
+// Dropping a non-existing table does NOT lead to an error.
+// Note that Table Storage deletes tables asynchronously,
+// so a table with the same name can't be created again for at least 40 seconds.
+func Drop(options Options) error {
+	if options.ConnectionString == "" {
+		return errors.New("The ConnectionString of the passed options is empty")
+	}
+	if options.TableName == "" {
+		options.TableName = DefaultOptions.TableName
+	}
+
+	table, err := getTable(options)
+	if err != nil {
+		return err
+	}
+	err = table.Delete(setupTimeout, nil)
+	if isResourceNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// getTable returns a reference to the table for the given options.
+func getTable(options Options) (*storage.Table, error) {
+	storageClient, err := storage.NewClientFromConnectionString(options.ConnectionString)
+	if err != nil {
+		return nil, err
+	}
+	tableService := storageClient.GetTableService()
+	tableServicePtr := &tableService
+	return tableServicePtr.GetTableReference(options.TableName), nil
+}
+
+// isResourceNotFound returns true if the error is Table Storage's error for a non-existing resource.
+func isResourceNotFound(err error) bool {
+	storageErr, ok := err.(storage.AzureStorageServiceError)
+	return ok && storageErr.Code == "ResourceNotFound"
+}
+
 // EmptyPartitionKeySupplier returns an empty string as partition key for any given value.
 func EmptyPartitionKeySupplier(_ string) string {
 	return ""
@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"reflect"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/storage"
 
+	"github.com/philippgille/gokv"
 	"github.com/philippgille/gokv/tablestorage"
 	"github.com/philippgille/gokv/test"
 )
@@ -180,6 +183,132 @@ func TestNil(t *testing.T) {
 	t.Run("get with nil / nil value parameter", createTest(tablestorage.Gob))
 }
 
+// TestProvisioning tests if NewClient() returns a *gokv.ResourceNotFoundError
+// when automatic provisioning is disabled and the table doesn't exist,
+// and if Provision() creates the table.
+//
+// Note: This test is only executed if the initial connection to Table Storage works.
+func TestProvisioning(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Table Storage could be established. Probably not running in a proper test environment.")
+	}
+
+	connString, found := os.LookupEnv(connectionStringEnvVar)
+	if !found {
+		t.Fatal(errors.New("No connection string found in the environment variable"))
+	}
+	// Table Storage doesn't allow re-creating a deleted table right away,
+	// so a new table name is used for each test run.
+	options := tablestorage.Options{
+		ConnectionString:        connString,
+		TableName:               "gokvProvisioning" + strconv.FormatInt(time.Now().Unix(), 10),
+		DisableAutoProvisioning: true,
+	}
+	_, err := tablestorage.NewClient(options)
+	if notFoundErr, ok := err.(*gokv.ResourceNotFoundError); !ok {
+		t.Errorf("Expected a *gokv.ResourceNotFoundError, but was: %v", err)
+	} else if notFoundErr.Name != options.TableName {
+		t.Errorf("Expected %v, but was %v", options.TableName, notFoundErr.Name)
+	}
+
+	err = tablestorage.Provision(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := tablestorage.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.TestStore(client, t)
+
+	err = tablestorage.Drop(options)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+type nativeFoo struct {
+	Name    string
+	Count   int `tablestorage:"count"`
+	Big     int64
+	Tags    []string
+	Ignored string `tablestorage:"-"`
+}
+
+// TestNativeProperties tests if struct fields are stored as native entity properties
+// and if they're converted back when retrieving the value.
+//
+// Note: This test is only executed if the initial connection to Table Storage works.
+func TestNativeProperties(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Table Storage could be established. Probably not running in a proper test environment.")
+	}
+
+	connString, found := os.LookupEnv(connectionStringEnvVar)
+	if !found {
+		t.Fatal(errors.New("No connection string found in the environment variable"))
+	}
+	options := tablestorage.Options{
+		ConnectionString: connString,
+		NativeProperties: true,
+	}
+	client, err := tablestorage.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := nativeFoo{Name: "bar", Count: 1, Big: 1 << 60, Tags: []string{"a"}}
+	err = client.Set("foo", nativeFoo{Name: "bar", Count: 1, Big: 1 << 60, Tags: []string{"a"}, Ignored: "baz"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Other tools can read the properties
+	storageClient, err := storage.NewClientFromConnectionString(connString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tableService := storageClient.GetTableService()
+	tableServicePtr := &tableService
+	entity := tableServicePtr.GetTableReference(tablestorage.DefaultOptions.TableName).GetEntityReference("", "foo")
+	err = entity.Get(30, storage.FullMetadata, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entity.Properties["Name"] != "bar" {
+		t.Errorf("Expected %v, but was %v", "bar", entity.Properties["Name"])
+	}
+	if entity.Properties["Tags"] != `["a"]` {
+		t.Errorf("Expected %v, but was %v", `["a"]`, entity.Properties["Tags"])
+	}
+	if _, ok := entity.Properties["Ignored"]; ok {
+		t.Error("The ignored field was stored, but shouldn't have been")
+	}
+
+	actual := nativeFoo{}
+	found, err = client.Get("foo", &actual)
+	if err != nil {
+		t.Error(err)
+	}
+	if !found {
+		t.Error("No value was found, but should have been")
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected: %+v, but was: %+v", expected, actual)
+	}
+
+	// Reserved property names
+	reserved := struct {
+		RowKey string
+	}{RowKey: "bar"}
+	err = client.Set("foo", reserved)
+	if err == nil {
+		t.Error("An error should have occurred, but didn't")
+	}
+
+	test.TestStore(client, t)
+}
+
 // TestClose tests if the close method returns any errors.
 //
 // Note: This test is only executed if the initial connection to Table Storage works.
@@ -1,10 +1,12 @@
 package test
 
 import (
+	"context"
 	"math/rand"
 	"strconv"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/go-test/deep"
 
@@ -313,3 +315,99 @@ func InteractWithStore(store gokv.Store, key string, t *testing.T, waitGroup *sy
 		t.Error(err)
 	}
 }
+
+// TestList tests if the store's List method enumerates all stored keys.
+// The store must implement gokv.Lister and should be empty when the test starts.
+func TestList(store gokv.Store, t *testing.T) {
+	lister, ok := store.(gokv.Lister)
+	if !ok {
+		t.Fatal("The store doesn't implement gokv.Lister")
+	}
+
+	expected := make(map[string]bool)
+	for i := 0; i < 10; i++ {
+		key := strconv.FormatInt(rand.Int63(), 10)
+		err := store.Set(key, Foo{Bar: key})
+		if err != nil {
+			t.Error(err)
+		}
+		expected[key] = true
+	}
+
+	actual := make(map[string]bool)
+	err := lister.List(func(k string) error {
+		actual[k] = true
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	if diff := deep.Equal(actual, expected); diff != nil {
+		t.Error(diff)
+	}
+
+	// Deleted keys shouldn't be listed anymore
+	for k := range expected {
+		err = store.Delete(k)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	err = lister.List(func(k string) error {
+		t.Errorf("No key was expected, but got: %v", k)
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// TestLocker tests if the locks of the store provide mutual exclusion.
+// The store must implement gokv.Locker.
+func TestLocker(store gokv.Store, t *testing.T) {
+	locker, ok := store.(gokv.Locker)
+	if !ok {
+		t.Fatal("The store doesn't implement gokv.Locker")
+	}
+	name := "lock_" + strconv.FormatInt(rand.Int63(), 10)
+
+	lock, err := locker.Lock(context.Background(), name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The lock is held, so acquiring it again must block until the context is done
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	_, err = locker.Lock(ctxWithTimeout, name)
+	if err == nil {
+		t.Error("Expected an error because the lock is held")
+	}
+
+	select {
+	case <-lock.Lost():
+		t.Error("The lock was lost")
+	default:
+	}
+
+	// After unlocking, the lock can be acquired again
+	err = lock.Unlock()
+	if err != nil {
+		t.Error(err)
+	}
+	// Unlocking again must not lead to an error
+	err = lock.Unlock()
+	if err != nil {
+		t.Error(err)
+	}
+	ctxWithTimeout, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	lock, err = locker.Lock(ctxWithTimeout, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = lock.Unlock()
+	if err != nil {
+		t.Error(err)
+	}
+}
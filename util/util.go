@@ -5,6 +5,8 @@ import (
 	"encoding/gob"
 	"encoding/json"
 	"errors"
+	"reflect"
+	"strings"
 )
 
 // ToJSON marshals the given value into JSON.
@@ -58,3 +60,51 @@ func CheckVal(v interface{}) error {
 	}
 	return nil
 }
+
+// StructField is an exported struct field, as returned by StructFields.
+type StructField struct {
+	// Index of the field in the struct, for reflect.Value.Field().
+	Index int
+	// Name under which the field is stored.
+	Name string
+}
+
+// StructFields returns the exported fields of the given struct type, or of the struct type the given pointer type points to,
+// for stores that store the fields of structs individually instead of marshalling the whole struct.
+// The name of a field is the name of the struct field, unless it's changed with a tag with the given key,
+// for example `redis:"name"`. Options after a comma in the tag are ignored, fields with a "-" tag are skipped.
+// For other types, and for structs without exported fields (like time.Time), nil is returned,
+// so such values must be marshalled as a whole.
+func StructFields(t reflect.Type, tagKey string) []StructField {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var result []StructField
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		// Skip unexported fields
+		if structField.PkgPath != "" {
+			continue
+		}
+		tag := structField.Tag.Get(tagKey)
+		if tag == "-" {
+			continue
+		}
+		name := structField.Name
+		if tagName := strings.SplitN(tag, ",", 2)[0]; tagName != "" {
+			name = tagName
+		}
+		result = append(result, StructField{Index: i, Name: name})
+	}
+	return result
+}
+
+// ReservedFieldNameError returns the error for a struct field that uses a name which is reserved by the store,
+// for example for the key. kind is the store's term for the field, like "property", and tagKey the key of the tag
+// that can be used to rename the field.
+func ReservedFieldNameError(kind, name, tagKey string) error {
+	return errors.New("The " + kind + " name \"" + name + "\" is reserved, so a struct field must not use it. Use a `" + tagKey + "` tag to rename the field")
+}
@@ -0,0 +1,11 @@
+/*
+Package vault contains an implementation of the `gokv.Store` interface for the KV secrets engine (version 2) of HashiCorp Vault.
+
+Each key-value pair is stored as a secret with a single field "value".
+Slashes in keys lead to nested paths, for example the key "foo/bar" with the default mount path is stored at "secret/data/foo/bar".
+Empty segments, "." and ".." are escaped with percent-encoding, as well as "%" itself,
+so keys like "foo/", "foo//bar" or "../bar" don't refer to other secrets.
+Every call of Set creates a new version of the secret.
+The metadata of the versions can be retrieved with Metadata and older versions with GetVersion.
+*/
+package vault
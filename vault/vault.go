@@ -0,0 +1,444 @@
+package vault
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+
+	"github.com/philippgille/gokv/util"
+)
+
+// Client is a gokv.Store implementation for the Vault KV secrets engine (version 2).
+// It also implements gokv.Lister.
+type Client struct {
+	c             *api.Client
+	mountPath     string
+	destroy       bool
+	marshalFormat MarshalFormat
+}
+
+// Metadata contains information about the versions of a key-value pair.
+type Metadata struct {
+	// Version of the latest value.
+	CurrentVersion int
+	// Oldest version that's still kept.
+	OldestVersion int
+	// Time when the first version was stored.
+	CreatedTime time.Time
+	// Time when the latest version was stored.
+	UpdatedTime time.Time
+	// Metadata of every version that's still kept, by version number.
+	Versions map[int]VersionMetadata
+}
+
+// VersionMetadata contains information about a single version of a key-value pair.
+type VersionMetadata struct {
+	// Time when the version was stored.
+	CreatedTime time.Time
+	// Time when the version was soft-deleted.
+	// Zero if the version isn't deleted.
+	DeletionTime time.Time
+	// Destroyed is true if the value of the version was permanently removed.
+	Destroyed bool
+}
+
+// Set stores the given value for the given key.
+// Every call creates a new version of the secret.
+// Values are automatically marshalled to JSON or gob (depending on the configuration).
+// The key must not be "" and the value must not be nil.
+func (c Client) Set(k string, v interface{}) error {
+	if err := util.CheckKeyAndValue(k, v); err != nil {
+		return err
+	}
+
+	// First turn the passed object into something that Vault can handle.
+	// Secrets are JSON objects with string values, so gob is encoded as base64.
+	var value string
+	switch c.marshalFormat {
+	case JSON:
+		data, err := util.ToJSON(v)
+		if err != nil {
+			return err
+		}
+		value = string(data)
+	case Gob:
+		data, err := util.ToGob(v)
+		if err != nil {
+			return err
+		}
+		value = base64.StdEncoding.EncodeToString(data)
+	default:
+		return errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	}
+
+	_, err := c.c.Logical().Write(c.mountPath+"/data/"+escapeKey(k), map[string]interface{}{
+		"data": map[string]interface{}{
+			"value": value,
+		},
+	})
+	return err
+}
+
+// Get retrieves the latest version of the stored value for the given key.
+// You need to pass a pointer to the value, so in case of a struct
+// the automatic unmarshalling can populate the fields of the object
+// that v points to with the values of the retrieved object's values.
+// If no value is found or the latest version is deleted, it returns (false, nil).
+// The key must not be "" and the pointer must not be nil.
+func (c Client) Get(k string, v interface{}) (found bool, err error) {
+	if err := util.CheckKeyAndValue(k, v); err != nil {
+		return false, err
+	}
+
+	secret, err := c.c.Logical().Read(c.mountPath + "/data/" + escapeKey(k))
+	if err != nil {
+		return false, err
+	}
+	return c.unmarshalSecret(secret, v)
+}
+
+// GetVersion retrieves the given version of the stored value for the given key.
+// If the version doesn't exist, is deleted or destroyed, it returns (false, nil).
+// Otherwise it behaves like Get.
+func (c Client) GetVersion(k string, version int, v interface{}) (found bool, err error) {
+	if err := util.CheckKeyAndValue(k, v); err != nil {
+		return false, err
+	}
+	if version < 1 {
+		return false, errors.New("The version must be greater than 0")
+	}
+
+	secret, err := c.c.Logical().ReadWithData(c.mountPath+"/data/"+escapeKey(k), map[string][]string{
+		"version": {strconv.Itoa(version)},
+	})
+	if err != nil {
+		return false, err
+	}
+	return c.unmarshalSecret(secret, v)
+}
+
+// Metadata retrieves the metadata of all versions of the given key.
+// If the key doesn't exist, it returns (false, nil).
+// The key must not be "".
+func (c Client) Metadata(k string) (Metadata, bool, error) {
+	result := Metadata{}
+	if err := util.CheckKey(k); err != nil {
+		return result, false, err
+	}
+
+	secret, err := c.c.Logical().Read(c.mountPath + "/metadata/" + escapeKey(k))
+	if err != nil {
+		return result, false, err
+	}
+	if secret == nil || secret.Data == nil {
+		return result, false, nil
+	}
+
+	if result.CurrentVersion, err = toInt(secret.Data["current_version"]); err != nil {
+		return result, true, err
+	}
+	if result.OldestVersion, err = toInt(secret.Data["oldest_version"]); err != nil {
+		return result, true, err
+	}
+	if result.CreatedTime, err = toTime(secret.Data["created_time"]); err != nil {
+		return result, true, err
+	}
+	if result.UpdatedTime, err = toTime(secret.Data["updated_time"]); err != nil {
+		return result, true, err
+	}
+	versions, _ := secret.Data["versions"].(map[string]interface{})
+	result.Versions = make(map[int]VersionMetadata, len(versions))
+	for versionString, versionData := range versions {
+		version, err := strconv.Atoi(versionString)
+		if err != nil {
+			return result, true, err
+		}
+		versionMap, _ := versionData.(map[string]interface{})
+		versionMetadata := VersionMetadata{}
+		if versionMetadata.CreatedTime, err = toTime(versionMap["created_time"]); err != nil {
+			return result, true, err
+		}
+		if versionMetadata.DeletionTime, err = toTime(versionMap["deletion_time"]); err != nil {
+			return result, true, err
+		}
+		versionMetadata.Destroyed, _ = versionMap["destroyed"].(bool)
+		result.Versions[version] = versionMetadata
+	}
+	return result, true, nil
+}
+
+// Delete deletes the stored value for the given key.
+// Depending on the configuration, it either soft-deletes the latest version,
+// which can be restored with Vault's "undelete" operation,
+// or it permanently removes all versions and the metadata of the key.
+// Deleting a non-existing key-value pair does NOT lead to an error.
+// The key must not be "".
+func (c Client) Delete(k string) error {
+	if err := util.CheckKey(k); err != nil {
+		return err
+	}
+
+	if c.destroy {
+		_, err := c.c.Logical().Delete(c.mountPath + "/metadata/" + escapeKey(k))
+		return err
+	}
+	_, err := c.c.Logical().Delete(c.mountPath + "/data/" + escapeKey(k))
+	return err
+}
+
+// List calls fn for every key in the KV secrets engine.
+// Keys with slashes are nested paths in Vault, which are listed recursively.
+// Note: Keys whose latest version is soft-deleted are included, because Vault still keeps their metadata.
+// If fn returns an error, the iteration is stopped and the error is returned.
+func (c Client) List(fn func(k string) error) error {
+	return c.list("", fn)
+}
+
+// list calls fn for every key under the given folder, which must be "" or end with a slash.
+// The folder is escaped, the keys that are passed to fn are unescaped.
+func (c Client) list(folder string, fn func(k string) error) error {
+	secret, err := c.c.Logical().List(c.mountPath + "/metadata/" + folder)
+	if err != nil {
+		return err
+	}
+	// Vault returns no secret for empty folders
+	if secret == nil || secret.Data == nil {
+		return nil
+	}
+	keys, _ := secret.Data["keys"].([]interface{})
+	for _, key := range keys {
+		keyString, ok := key.(string)
+		if !ok {
+			continue
+		}
+		if strings.HasSuffix(keyString, "/") {
+			err = c.list(folder+keyString, fn)
+		} else {
+			err = fn(unescapeKey(folder + keyString))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the client.
+// In the Vault implementation this doesn't have any effect.
+func (c Client) Close() error {
+	return nil
+}
+
+// escapeKey returns the path of the secret for the given key, relative to the "data" or "metadata" path.
+// Each slash-separated segment of the key is escaped with escapeSegment.
+func escapeKey(k string) string {
+	segments := strings.Split(k, "/")
+	for i, segment := range segments {
+		segments[i] = escapeSegment(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// unescapeKey reverses escapeKey.
+func unescapeKey(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = unescapeSegment(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// escapeSegment turns a segment of a key into a path segment that Vault and its client don't remove.
+// Both clean the request path, which would drop empty segments and resolve "." and "..",
+// so that for example the key "foo/../bar" would refer to the secret "bar".
+// Those segments are escaped with percent-encoding, an empty segment becomes "%".
+// "%" itself is escaped as well, so different keys always lead to different paths.
+func escapeSegment(segment string) string {
+	switch segment {
+	case "":
+		return "%"
+	case ".":
+		return "%2E"
+	case "..":
+		return "%2E%2E"
+	}
+	return strings.Replace(segment, "%", "%25", -1)
+}
+
+// unescapeSegment reverses escapeSegment.
+func unescapeSegment(segment string) string {
+	switch segment {
+	case "%":
+		return ""
+	case "%2E":
+		return "."
+	case "%2E%2E":
+		return ".."
+	}
+	return strings.Replace(segment, "%25", "%", -1)
+}
+
+// unmarshalSecret unmarshals the value of the given secret into v.
+// It returns false if the secret doesn't contain a value.
+func (c Client) unmarshalSecret(secret *api.Secret, v interface{}) (bool, error) {
+	// Vault returns no secret or a secret without data for non-existing, deleted or destroyed versions
+	if secret == nil || secret.Data == nil {
+		return false, nil
+	}
+	data, ok := secret.Data["data"].(map[string]interface{})
+	if !ok {
+		return false, nil
+	}
+	value, ok := data["value"].(string)
+	if !ok {
+		return false, errors.New("The secret doesn't contain a \"value\" field of type string, so it probably wasn't stored by gokv")
+	}
+
+	switch c.marshalFormat {
+	case JSON:
+		return true, util.FromJSON([]byte(value), v)
+	case Gob:
+		data, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return true, err
+		}
+		return true, util.FromGob(data, v)
+	default:
+		return true, errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	}
+}
+
+// toInt converts a number from a Vault response to an int.
+// The Vault client decodes numbers as json.Number.
+func toInt(v interface{}) (int, error) {
+	switch n := v.(type) {
+	case json.Number:
+		i, err := n.Int64()
+		return int(i), err
+	case float64:
+		return int(n), nil
+	case nil:
+		return 0, nil
+	default:
+		return 0, errors.New("Unexpected type of number in the response from Vault")
+	}
+}
+
+// toTime converts a timestamp from a Vault response to a time.Time.
+// Empty timestamps lead to the zero time.
+func toTime(v interface{}) (time.Time, error) {
+	s, _ := v.(string)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+// MarshalFormat is an enum for the available (un-)marshal formats of this gokv.Store implementation.
+type MarshalFormat int
+
+const (
+	// JSON is the MarshalFormat for (un-)marshalling to/from JSON
+	JSON MarshalFormat = iota
+	// Gob is the MarshalFormat for (un-)marshalling to/from gob
+	Gob
+)
+
+// Options are the options for the Vault client.
+type Options struct {
+	// Address of the Vault server, including scheme and port.
+	// Optional (the value of the environment variable "VAULT_ADDR" or "https://127.0.0.1:8200" by default).
+	Address string
+	// Path under which the KV secrets engine (version 2) is mounted.
+	// Optional ("secret" by default).
+	MountPath string
+	// Token for authentication.
+	// Ignored if RoleID is set.
+	// Optional (the value of the environment variable "VAULT_TOKEN" by default).
+	Token string
+	// Role ID for the AppRole authentication method.
+	// If set, the client logs in with the RoleID and SecretID and uses the resulting token.
+	// Optional ("" by default).
+	RoleID string
+	// Secret ID for the AppRole authentication method.
+	// Optional ("" by default).
+	SecretID string
+	// Path under which the AppRole authentication method is mounted.
+	// Optional ("approle" by default).
+	AppRoleMountPath string
+	// If true, Delete permanently removes all versions and the metadata of a key.
+	// Otherwise Delete only soft-deletes the latest version,
+	// which can then be restored and is still listed in the metadata.
+	// Optional (false by default).
+	Destroy bool
+	// (Un-)marshal format.
+	// Optional (JSON by default).
+	MarshalFormat MarshalFormat
+}
+
+// DefaultOptions is an Options object with default values.
+// Address: "" (VAULT_ADDR or "https://127.0.0.1:8200"), MountPath: "secret", Token: "" (VAULT_TOKEN),
+// RoleID: "", SecretID: "", AppRoleMountPath: "approle", Destroy: false, MarshalFormat: JSON
+var DefaultOptions = Options{
+	MountPath:        "secret",
+	AppRoleMountPath: "approle",
+	// No need to set Address, Token, RoleID, SecretID, Destroy or MarshalFormat because their zero values are fine.
+}
+
+// NewClient creates a new Vault client.
+// If a RoleID is configured, it logs in with the AppRole authentication method.
+//
+// Note: The token that's received via AppRole isn't renewed, so its TTL should be long enough
+// for the lifetime of the client.
+func NewClient(options Options) (Client, error) {
+	result := Client{}
+
+	// Set default values
+	if options.MountPath == "" {
+		options.MountPath = DefaultOptions.MountPath
+	}
+	if options.AppRoleMountPath == "" {
+		options.AppRoleMountPath = DefaultOptions.AppRoleMountPath
+	}
+	mountPath := strings.Trim(options.MountPath, "/")
+
+	config := api.DefaultConfig()
+	if config.Error != nil {
+		return result, config.Error
+	}
+	if options.Address != "" {
+		config.Address = options.Address
+	}
+	client, err := api.NewClient(config)
+	if err != nil {
+		return result, err
+	}
+
+	if options.RoleID != "" {
+		secret, err := client.Logical().Write("auth/"+strings.Trim(options.AppRoleMountPath, "/")+"/login", map[string]interface{}{
+			"role_id":   options.RoleID,
+			"secret_id": options.SecretID,
+		})
+		if err != nil {
+			return result, err
+		}
+		if secret == nil || secret.Auth == nil {
+			return result, errors.New("The AppRole login didn't return a token")
+		}
+		client.SetToken(secret.Auth.ClientToken)
+	} else if options.Token != "" {
+		client.SetToken(options.Token)
+	}
+
+	result.c = client
+	result.mountPath = mountPath
+	result.destroy = options.Destroy
+	result.marshalFormat = options.MarshalFormat
+
+	return result, nil
+}
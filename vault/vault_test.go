@@ -0,0 +1,449 @@
+package vault_test
+
+import (
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/philippgille/gokv/test"
+	"github.com/philippgille/gokv/vault"
+)
+
+// Address and root token of the Vault dev-mode server, which mounts the KV secrets engine (version 2) at "secret".
+const (
+	address = "http://127.0.0.1:8200"
+	token   = "gokv"
+)
+
+// TestClient tests if reading from, writing to and deleting from the store works properly.
+// A struct is used as value. See TestTypes() for a test that is simpler but tests all types.
+//
+// Note: This test is only executed if the initial connection to Vault works.
+func TestClient(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Vault could be established. Probably not running in a proper test environment.")
+	}
+
+	// Test with JSON
+	t.Run("JSON", func(t *testing.T) {
+		client := createClient(t, vault.JSON, false)
+		test.TestStore(client, t)
+	})
+
+	// Test with gob
+	t.Run("gob", func(t *testing.T) {
+		client := createClient(t, vault.Gob, false)
+		test.TestStore(client, t)
+	})
+}
+
+// TestTypes tests if setting and getting values works with all Go types.
+//
+// Note: This test is only executed if the initial connection to Vault works.
+func TestTypes(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Vault could be established. Probably not running in a proper test environment.")
+	}
+
+	// Test with JSON
+	t.Run("JSON", func(t *testing.T) {
+		client := createClient(t, vault.JSON, false)
+		test.TestTypes(client, t)
+	})
+
+	// Test with gob
+	t.Run("gob", func(t *testing.T) {
+		client := createClient(t, vault.Gob, false)
+		test.TestTypes(client, t)
+	})
+}
+
+// TestClientConcurrent launches a bunch of goroutines that concurrently work with the Vault client.
+//
+// Note: This test is only executed if the initial connection to Vault works.
+func TestClientConcurrent(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Vault could be established. Probably not running in a proper test environment.")
+	}
+
+	client := createClient(t, vault.JSON, false)
+
+	goroutineCount := 1000
+
+	test.TestConcurrentInteractions(t, goroutineCount, client)
+}
+
+// TestErrors tests some error cases.
+//
+// Note: This test is only executed if the initial connection to Vault works.
+func TestErrors(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Vault could be established. Probably not running in a proper test environment.")
+	}
+
+	// Test with a bad MarshalFormat enum value
+
+	client := createClient(t, vault.MarshalFormat(19), false)
+	err := client.Set("foo", "bar")
+	if err == nil {
+		t.Error("An error should have occurred, but didn't")
+	}
+	_, err = client.GetVersion("foo", 0, new(string))
+	if err == nil {
+		t.Error("An error should have occurred, but didn't")
+	}
+
+	// Test empty key
+	err = client.Set("", "bar")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	_, err = client.Get("", new(string))
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	err = client.Delete("")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	_, _, err = client.Metadata("")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+
+	// Test client creation with bad AppRole credentials
+	_, err = vault.NewClient(vault.Options{
+		Address:  address,
+		RoleID:   "foo",
+		SecretID: "bar",
+	})
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+// TestVersions tests if every Set creates a new version and if the versions and their metadata can be retrieved.
+//
+// Note: This test is only executed if the initial connection to Vault works.
+func TestVersions(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Vault could be established. Probably not running in a proper test environment.")
+	}
+
+	client := createClient(t, vault.JSON, false)
+	key := "test_versions_" + strconv.FormatInt(time.Now().UnixNano(), 10)
+
+	for _, v := range []string{"foo", "bar"} {
+		err := client.Set(key, v)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	metadata, found, err := client.Metadata(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found {
+		t.Fatal("No metadata was found, but should have been")
+	}
+	if metadata.CurrentVersion != 2 {
+		t.Errorf("Expected: %v, but was: %v", 2, metadata.CurrentVersion)
+	}
+	if len(metadata.Versions) != 2 {
+		t.Errorf("Expected: %v, but was: %v", 2, len(metadata.Versions))
+	}
+	if metadata.CreatedTime.IsZero() || metadata.UpdatedTime.Before(metadata.CreatedTime) {
+		t.Errorf("Unexpected times in metadata: %+v", metadata)
+	}
+
+	actual := ""
+	found, err = client.GetVersion(key, 1, &actual)
+	if err != nil {
+		t.Error(err)
+	}
+	if !found {
+		t.Error("No value was found, but should have been")
+	}
+	if actual != "foo" {
+		t.Errorf("Expected: %v, but was: %v", "foo", actual)
+	}
+	found, err = client.GetVersion(key, 3, &actual)
+	if err != nil {
+		t.Error(err)
+	}
+	if found {
+		t.Error("A value was found, but no value was expected")
+	}
+
+	_, found, err = client.Metadata(key + "_non-existing")
+	if err != nil {
+		t.Error(err)
+	}
+	if found {
+		t.Error("Metadata was found, but none was expected")
+	}
+}
+
+// TestDelete tests the soft-delete and destroy semantics of Delete.
+//
+// Note: This test is only executed if the initial connection to Vault works.
+func TestDelete(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Vault could be established. Probably not running in a proper test environment.")
+	}
+
+	key := "test_delete_" + strconv.FormatInt(time.Now().UnixNano(), 10)
+
+	// Soft-delete
+	client := createClient(t, vault.JSON, false)
+	err := client.Set(key, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Delete(key)
+	if err != nil {
+		t.Error(err)
+	}
+	found, err := client.Get(key, new(string))
+	if err != nil {
+		t.Error(err)
+	}
+	if found {
+		t.Error("A value was found, but no value was expected")
+	}
+	metadata, found, err := client.Metadata(key)
+	if err != nil {
+		t.Error(err)
+	}
+	if !found {
+		t.Error("No metadata was found, but should have been")
+	}
+	if metadata.Versions[1].DeletionTime.IsZero() {
+		t.Error("The deletion time of the soft-deleted version wasn't set")
+	}
+
+	// Destroy
+	client = createClient(t, vault.JSON, true)
+	err = client.Delete(key)
+	if err != nil {
+		t.Error(err)
+	}
+	_, found, err = client.Metadata(key)
+	if err != nil {
+		t.Error(err)
+	}
+	if found {
+		t.Error("Metadata was found, but none was expected")
+	}
+}
+
+// TestList tests if all keys, including nested ones, are listed.
+//
+// Note: This test is only executed if the initial connection to Vault works.
+func TestList(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Vault could be established. Probably not running in a proper test environment.")
+	}
+
+	client := createClient(t, vault.JSON, true)
+	prefix := "test_list_" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	expected := []string{prefix + "/a", prefix + "/b/c"}
+	for _, k := range expected {
+		err := client.Set(k, "foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var actual []string
+	err := client.List(func(k string) error {
+		if len(k) > len(prefix) && k[:len(prefix)] == prefix {
+			actual = append(actual, k)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	sort.Strings(actual)
+	if len(actual) != len(expected) || actual[0] != expected[0] || actual[1] != expected[1] {
+		t.Errorf("Expected: %v, but was: %v", expected, actual)
+	}
+
+	for _, k := range expected {
+		err = client.Delete(k)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// TestSpecialKeys tests if keys with segments that Vault would remove from the path can be used and listed.
+//
+// Note: This test is only executed if the initial connection to Vault works.
+func TestSpecialKeys(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Vault could be established. Probably not running in a proper test environment.")
+	}
+
+	client := createClient(t, vault.JSON, true)
+	prefix := "test_special_keys_" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	keys := []string{prefix + "/foo", prefix + "/foo/", prefix + "//foo", prefix + "/../foo", prefix + "/./foo", prefix + "/%", prefix + "/%25", prefix + "/%2E"}
+	for i, k := range keys {
+		err := client.Set(k, strconv.Itoa(i))
+		if err != nil {
+			t.Errorf("Setting the value for key %q failed: %v", k, err)
+		}
+	}
+	// Different keys must not overwrite each other
+	for i, k := range keys {
+		actual := ""
+		found, err := client.Get(k, &actual)
+		if err != nil {
+			t.Errorf("Getting the value for key %q failed: %v", k, err)
+		}
+		if !found {
+			t.Errorf("No value was found for key %q, but should have been", k)
+		}
+		if actual != strconv.Itoa(i) {
+			t.Errorf("Expected: %v, but was: %v", i, actual)
+		}
+	}
+
+	var actual []string
+	err := client.List(func(k string) error {
+		if strings.HasPrefix(k, prefix) {
+			actual = append(actual, k)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	expected := append([]string{}, keys...)
+	sort.Strings(expected)
+	sort.Strings(actual)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected: %q, but was: %q", expected, actual)
+	}
+
+	for _, k := range keys {
+		err := client.Delete(k)
+		if err != nil {
+			t.Errorf("Deleting the value for key %q failed: %v", k, err)
+		}
+	}
+}
+
+// TestNil tests the behaviour when passing nil or pointers to nil values to some methods.
+//
+// Note: This test is only executed if the initial connection to Vault works.
+func TestNil(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Vault could be established. Probably not running in a proper test environment.")
+	}
+
+	// Test setting nil
+
+	t.Run("set nil with JSON marshalling", func(t *testing.T) {
+		client := createClient(t, vault.JSON, false)
+		err := client.Set("foo", nil)
+		if err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	t.Run("set nil with Gob marshalling", func(t *testing.T) {
+		client := createClient(t, vault.Gob, false)
+		err := client.Set("foo", nil)
+		if err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	// Test passing nil or pointer to nil value for retrieval
+
+	createTest := func(mf vault.MarshalFormat) func(t *testing.T) {
+		return func(t *testing.T) {
+			client := createClient(t, mf, false)
+
+			// Prep
+			err := client.Set("foo", test.Foo{Bar: "baz"})
+			if err != nil {
+				t.Error(err)
+			}
+
+			_, err = client.Get("foo", nil) // actually nil
+			if err == nil {
+				t.Error("An error was expected")
+			}
+
+			var i interface{} // actually nil
+			_, err = client.Get("foo", i)
+			if err == nil {
+				t.Error("An error was expected")
+			}
+
+			var valPtr *test.Foo // nil value
+			_, err = client.Get("foo", valPtr)
+			if err == nil {
+				t.Error("An error was expected")
+			}
+		}
+	}
+	t.Run("get with nil / nil value parameter", createTest(vault.JSON))
+	t.Run("get with nil / nil value parameter", createTest(vault.Gob))
+}
+
+// TestClose tests if the close method returns any errors.
+//
+// Note: This test is only executed if the initial connection to Vault works.
+func TestClose(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to Vault could be established. Probably not running in a proper test environment.")
+	}
+
+	client := createClient(t, vault.JSON, false)
+	err := client.Close()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// checkConnection returns true if a connection could be made, false otherwise.
+func checkConnection() bool {
+	httpClient := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	res, err := httpClient.Get(address + "/v1/sys/health")
+	if err != nil {
+		log.Printf("An error occurred during testing the connection to the server: %v\n", err)
+		return false
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		log.Printf("The server isn't healthy, the status code is: %v\n", res.StatusCode)
+		return false
+	}
+	return true
+}
+
+func createClient(t *testing.T, mf vault.MarshalFormat, destroy bool) vault.Client {
+	options := vault.Options{
+		Address:       address,
+		Token:         token,
+		Destroy:       destroy,
+		MarshalFormat: mf,
+	}
+	client, err := vault.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
@@ -0,0 +1,10 @@
+/*
+Package zookeeper contains an implementation of the `gokv.Store` interface for Apache ZooKeeper.
+
+Each key-value pair is stored as a znode under a configurable root path.
+Slashes in keys lead to nested znodes, for example the key "foo/bar" with the default root path is stored in the znode "/gokv/foo/bar".
+Parent znodes are created on demand and have no data, so they're not treated as stored values.
+Segments that aren't valid znode names, like empty segments, "." and "..", are escaped with percent-encoding,
+so any key can be used.
+*/
+package zookeeper
@@ -0,0 +1,319 @@
+package zookeeper
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/samuel/go-zookeeper/zk"
+
+	"github.com/philippgille/gokv/util"
+)
+
+var defaultSessionTimeout = 10 * time.Second
+
+// ZooKeeper's default limit for the size of requests (jute.maxbuffer) is 1 MB, which includes some overhead besides the value.
+const defaultMaxValueSize = 1000 * 1000
+
+// Client is a gokv.Store implementation for ZooKeeper.
+type Client struct {
+	c             *zk.Conn
+	rootPath      string
+	acl           []zk.ACL
+	maxValueSize  int
+	marshalFormat MarshalFormat
+}
+
+// Set stores the given value for the given key.
+// Values are automatically marshalled to JSON or gob (depending on the configuration).
+// The marshalled value must not be larger than the configured MaxValueSize.
+// The key must not be "" and the value must not be nil.
+func (c Client) Set(k string, v interface{}) error {
+	if err := util.CheckKeyAndValue(k, v); err != nil {
+		return err
+	}
+	path := c.path(k)
+
+	// First turn the passed object into something that ZooKeeper can handle
+	var data []byte
+	var err error
+	switch c.marshalFormat {
+	case JSON:
+		data, err = util.ToJSON(v)
+	case Gob:
+		data, err = util.ToGob(v)
+	default:
+		err = errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	}
+	if err != nil {
+		return err
+	}
+	if len(data) > c.maxValueSize {
+		return errors.New("The marshalled value is " + strconv.Itoa(len(data)) + " bytes long, but the maximum size of a znode's data is configured to be " + strconv.Itoa(c.maxValueSize) + " bytes")
+	}
+
+	// Most of the time the znode already exists, so try updating it first.
+	// Version -1 matches any version.
+	_, err = c.c.Set(path, data, -1)
+	if err != zk.ErrNoNode {
+		return err
+	}
+	if err = c.createParents(path); err != nil {
+		return err
+	}
+	_, err = c.c.Create(path, data, 0, c.acl)
+	// Another client could have created the znode in the meantime
+	if err == zk.ErrNodeExists {
+		_, err = c.c.Set(path, data, -1)
+	}
+	return err
+}
+
+// Get retrieves the stored value for the given key.
+// You need to pass a pointer to the value, so in case of a struct
+// the automatic unmarshalling can populate the fields of the object
+// that v points to with the values of the retrieved object's values.
+// If no value is found it returns (false, nil).
+// The key must not be "" and the pointer must not be nil.
+func (c Client) Get(k string, v interface{}) (found bool, err error) {
+	if err := util.CheckKeyAndValue(k, v); err != nil {
+		return false, err
+	}
+	data, _, err := c.c.Get(c.path(k))
+	// If no value was found return false.
+	// Parent znodes that were created on demand don't have data.
+	if err == zk.ErrNoNode {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	} else if len(data) == 0 {
+		return false, nil
+	}
+
+	switch c.marshalFormat {
+	case JSON:
+		return true, util.FromJSON(data, v)
+	case Gob:
+		return true, util.FromGob(data, v)
+	default:
+		return true, errors.New("The store seems to be configured with a marshal format that's not implemented yet")
+	}
+}
+
+// Delete deletes the stored value for the given key.
+// If the znode has children (because other keys start with the key followed by a slash),
+// only its data is removed.
+// Deleting a non-existing key-value pair does NOT lead to an error.
+// The key must not be "".
+func (c Client) Delete(k string) error {
+	if err := util.CheckKey(k); err != nil {
+		return err
+	}
+	path := c.path(k)
+
+	err := c.c.Delete(path, -1)
+	if err == zk.ErrNotEmpty {
+		_, err = c.c.Set(path, nil, -1)
+	}
+	if err == zk.ErrNoNode {
+		return nil
+	}
+	return err
+}
+
+// Close closes the client.
+// It must be called to end the ZooKeeper session.
+func (c Client) Close() error {
+	c.c.Close()
+	return nil
+}
+
+// path returns the znode path for the given key.
+// Each slash-separated segment of the key is escaped with escapeSegment.
+func (c Client) path(k string) string {
+	segments := strings.Split(k, "/")
+	for i, segment := range segments {
+		segments[i] = escapeSegment(segment)
+	}
+	// The znode "/zookeeper" is reserved for ZooKeeper itself
+	if c.rootPath == "" && segments[0] == "zookeeper" {
+		segments[0] = "%7Aookeeper"
+	}
+	return c.rootPath + "/" + strings.Join(segments, "/")
+}
+
+// escapeSegment turns a segment of a key into a valid znode name.
+// ZooKeeper doesn't allow empty names, "." and "..", as well as some control characters and Unicode ranges.
+// Those are escaped with percent-encoding of their UTF-8 bytes, an empty segment becomes "%".
+// "%" itself is escaped as well, so different keys always lead to different paths.
+func escapeSegment(segment string) string {
+	switch segment {
+	case "":
+		return "%"
+	case ".":
+		return "%2E"
+	case "..":
+		return "%2E%2E"
+	}
+	var b strings.Builder
+	for i := 0; i < len(segment); {
+		// Invalid UTF-8 is decoded as utf8.RuneError, which is escaped as well
+		r, size := utf8.DecodeRuneInString(segment[i:])
+		if r == '%' || r < 0x20 || (r >= 0x7f && r <= 0x9f) || (r >= 0xd800 && r <= 0xf8ff) || (r >= 0xfff0 && r <= 0xffff) {
+			for _, c := range []byte(segment[i : i+size]) {
+				fmt.Fprintf(&b, "%%%02X", c)
+			}
+		} else {
+			b.WriteString(segment[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// createParents creates all parent znodes of the given path that don't exist yet.
+func (c Client) createParents(path string) error {
+	for i := 1; i < len(path); i++ {
+		if path[i] != '/' {
+			continue
+		}
+		_, err := c.c.Create(path[:i], nil, 0, c.acl)
+		if err != nil && err != zk.ErrNodeExists {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarshalFormat is an enum for the available (un-)marshal formats of this gokv.Store implementation.
+type MarshalFormat int
+
+const (
+	// JSON is the MarshalFormat for (un-)marshalling to/from JSON
+	JSON MarshalFormat = iota
+	// Gob is the MarshalFormat for (un-)marshalling to/from gob
+	Gob
+)
+
+// Options are the options for the ZooKeeper client.
+type Options struct {
+	// Addresses of the ZooKeeper servers in the ensemble, including port.
+	// Optional ([]string{"127.0.0.1:2181"} by default).
+	Servers []string
+	// Path of the znode under which the key-value pairs are stored.
+	// Must start with a slash. It's created if it doesn't exist yet.
+	// Optional ("/gokv" by default).
+	RootPath string
+	// Timeout of the session.
+	// The client reconnects automatically, but if it can't reach the ensemble for longer than the timeout,
+	// the session expires and a new one is established.
+	// Optional (10 * time.Second by default).
+	SessionTimeout *time.Duration
+	// Maximum size of a (marshalled) value, in bytes.
+	// Only increase it when the servers are configured with a higher "jute.maxbuffer".
+	// Optional (1000 * 1000 by default).
+	MaxValueSize int
+	// Username and password for the "digest" authentication scheme.
+	// When set, znodes that are created by gokv can only be accessed with the same credentials.
+	// Optional ("" by default, which leads to znodes that can be accessed by anyone).
+	Username string
+	// Password for the "digest" authentication scheme.
+	// Optional ("" by default).
+	Password string
+	// (Un-)marshal format.
+	// Optional (JSON by default).
+	MarshalFormat MarshalFormat
+}
+
+// DefaultOptions is an Options object with default values.
+// Servers: []string{"127.0.0.1:2181"}, RootPath: "/gokv", SessionTimeout: 10 * time.Second, MaxValueSize: 1000 * 1000,
+// Username: "", Password: "", MarshalFormat: JSON
+var DefaultOptions = Options{
+	Servers:        []string{"127.0.0.1:2181"},
+	RootPath:       "/gokv",
+	SessionTimeout: &defaultSessionTimeout,
+	MaxValueSize:   defaultMaxValueSize,
+	// No need to set Username, Password or MarshalFormat because their zero values are fine.
+}
+
+// NewClient creates a new ZooKeeper client.
+// It blocks until a session is established or the session timeout is reached.
+// The session is reused for all operations.
+func NewClient(options Options) (Client, error) {
+	result := Client{}
+
+	// Set default values
+	if len(options.Servers) == 0 {
+		options.Servers = DefaultOptions.Servers
+	}
+	if options.RootPath == "" {
+		options.RootPath = DefaultOptions.RootPath
+	}
+	if options.SessionTimeout == nil {
+		options.SessionTimeout = DefaultOptions.SessionTimeout
+	}
+	if options.MaxValueSize == 0 {
+		options.MaxValueSize = DefaultOptions.MaxValueSize
+	}
+	if !strings.HasPrefix(options.RootPath, "/") {
+		return result, errors.New("The RootPath must start with a slash")
+	}
+	// This also turns "/" into "", so that the keys are stored directly under the root znode
+	rootPath := strings.TrimSuffix(options.RootPath, "/")
+
+	conn, events, err := zk.Connect(options.Servers, *options.SessionTimeout)
+	if err != nil {
+		return result, err
+	}
+	timeout := time.After(*options.SessionTimeout)
+	for connected := false; !connected; {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				conn.Close()
+				return result, errors.New("The connection to ZooKeeper was closed while establishing a session")
+			}
+			connected = event.State == zk.StateHasSession
+		case <-timeout:
+			conn.Close()
+			return result, errors.New("No session could be established with ZooKeeper within the session timeout")
+		}
+	}
+	// The events must be consumed, but the client handles reconnecting by itself.
+	// The channel is closed when the connection is closed.
+	go func() {
+		for range events {
+		}
+	}()
+
+	acl := zk.WorldACL(zk.PermAll)
+	if options.Username != "" {
+		err = conn.AddAuth("digest", []byte(options.Username+":"+options.Password))
+		if err != nil {
+			conn.Close()
+			return result, err
+		}
+		acl = zk.DigestACL(zk.PermAll, options.Username, options.Password)
+	}
+
+	result = Client{
+		c:             conn,
+		rootPath:      rootPath,
+		acl:           acl,
+		maxValueSize:  options.MaxValueSize,
+		marshalFormat: options.MarshalFormat,
+	}
+
+	// Create the root znode if it doesn't exist yet
+	if rootPath != "" {
+		if err = result.createParents(rootPath + "/"); err != nil {
+			conn.Close()
+			return Client{}, err
+		}
+	}
+
+	return result, nil
+}
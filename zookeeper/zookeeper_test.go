@@ -0,0 +1,315 @@
+package zookeeper_test
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samuel/go-zookeeper/zk"
+
+	"github.com/philippgille/gokv/test"
+	"github.com/philippgille/gokv/zookeeper"
+)
+
+// TestClient tests if reading from, writing to and deleting from the store works properly.
+// A struct is used as value. See TestTypes() for a test that is simpler but tests all types.
+//
+// Note: This test is only executed if the initial connection to ZooKeeper works.
+func TestClient(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to ZooKeeper could be established. Probably not running in a proper test environment.")
+	}
+
+	// Test with JSON
+	t.Run("JSON", func(t *testing.T) {
+		client := createClient(t, zookeeper.JSON)
+		test.TestStore(client, t)
+	})
+
+	// Test with gob
+	t.Run("gob", func(t *testing.T) {
+		client := createClient(t, zookeeper.Gob)
+		test.TestStore(client, t)
+	})
+}
+
+// TestTypes tests if setting and getting values works with all Go types.
+//
+// Note: This test is only executed if the initial connection to ZooKeeper works.
+func TestTypes(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to ZooKeeper could be established. Probably not running in a proper test environment.")
+	}
+
+	// Test with JSON
+	t.Run("JSON", func(t *testing.T) {
+		client := createClient(t, zookeeper.JSON)
+		test.TestTypes(client, t)
+	})
+
+	// Test with gob
+	t.Run("gob", func(t *testing.T) {
+		client := createClient(t, zookeeper.Gob)
+		test.TestTypes(client, t)
+	})
+}
+
+// TestClientConcurrent launches a bunch of goroutines that concurrently work with the ZooKeeper client.
+//
+// Note: This test is only executed if the initial connection to ZooKeeper works.
+func TestClientConcurrent(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to ZooKeeper could be established. Probably not running in a proper test environment.")
+	}
+
+	client := createClient(t, zookeeper.JSON)
+
+	goroutineCount := 1000
+
+	test.TestConcurrentInteractions(t, goroutineCount, client)
+}
+
+// TestNestedKeys tests if keys with slashes lead to nested znodes
+// and if a key can be both a value and the parent of other values.
+//
+// Note: This test is only executed if the initial connection to ZooKeeper works.
+func TestNestedKeys(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to ZooKeeper could be established. Probably not running in a proper test environment.")
+	}
+
+	client := createClient(t, zookeeper.JSON)
+
+	err := client.Set("nested/foo/bar", "baz")
+	if err != nil {
+		t.Error(err)
+	}
+	// The parent was created on demand, but doesn't contain a value
+	found, err := client.Get("nested/foo", new(string))
+	if err != nil {
+		t.Error(err)
+	}
+	if found {
+		t.Error("A value was found, but no value was expected")
+	}
+
+	err = client.Set("nested/foo", "qux")
+	if err != nil {
+		t.Error(err)
+	}
+	err = client.Delete("nested/foo")
+	if err != nil {
+		t.Error(err)
+	}
+	found, err = client.Get("nested/foo", new(string))
+	if err != nil {
+		t.Error(err)
+	}
+	if found {
+		t.Error("A value was found, but no value was expected")
+	}
+	// The child must still exist
+	actual := ""
+	found, err = client.Get("nested/foo/bar", &actual)
+	if err != nil {
+		t.Error(err)
+	}
+	if !found {
+		t.Error("No value was found, but should have been")
+	}
+	if actual != "baz" {
+		t.Errorf("Expected: %v, but was: %v", "baz", actual)
+	}
+}
+
+// TestSpecialKeys tests if keys that aren't valid znode paths as they are can be used.
+//
+// Note: This test is only executed if the initial connection to ZooKeeper works.
+func TestSpecialKeys(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to ZooKeeper could be established. Probably not running in a proper test environment.")
+	}
+
+	client := createClient(t, zookeeper.JSON)
+
+	keys := []string{"/foo", "foo/", "foo//bar", "foo/../bar", ".", "..", "%", "%25", "foo\x00bar"}
+	for i, k := range keys {
+		err := client.Set(k, strconv.Itoa(i))
+		if err != nil {
+			t.Errorf("Setting the value for key %q failed: %v", k, err)
+		}
+	}
+	// Different keys must not overwrite each other
+	for i, k := range keys {
+		actual := ""
+		found, err := client.Get(k, &actual)
+		if err != nil {
+			t.Errorf("Getting the value for key %q failed: %v", k, err)
+		}
+		if !found {
+			t.Errorf("No value was found for key %q, but should have been", k)
+		}
+		if actual != strconv.Itoa(i) {
+			t.Errorf("Expected: %v, but was: %v", i, actual)
+		}
+	}
+	for _, k := range keys {
+		err := client.Delete(k)
+		if err != nil {
+			t.Errorf("Deleting the value for key %q failed: %v", k, err)
+		}
+	}
+}
+
+// TestErrors tests some error cases.
+//
+// Note: This test is only executed if the initial connection to ZooKeeper works.
+func TestErrors(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to ZooKeeper could be established. Probably not running in a proper test environment.")
+	}
+
+	// Test with a bad MarshalFormat enum value
+
+	client := createClient(t, zookeeper.MarshalFormat(19))
+	err := client.Set("foo", "bar")
+	if err == nil {
+		t.Error("An error should have occurred, but didn't")
+	}
+
+	// Test empty key
+	err = client.Set("", "bar")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	_, err = client.Get("", new(string))
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	err = client.Delete("")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+
+	// Test value that's too large
+	err = client.Set("foo", strings.Repeat("a", 1000*1000))
+	if err == nil {
+		t.Error("Expected an error")
+	}
+
+	// Test client creation with bad options
+	_, err = zookeeper.NewClient(zookeeper.Options{RootPath: "gokv"})
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+// TestNil tests the behaviour when passing nil or pointers to nil values to some methods.
+//
+// Note: This test is only executed if the initial connection to ZooKeeper works.
+func TestNil(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to ZooKeeper could be established. Probably not running in a proper test environment.")
+	}
+
+	// Test setting nil
+
+	t.Run("set nil with JSON marshalling", func(t *testing.T) {
+		client := createClient(t, zookeeper.JSON)
+		err := client.Set("foo", nil)
+		if err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	t.Run("set nil with Gob marshalling", func(t *testing.T) {
+		client := createClient(t, zookeeper.Gob)
+		err := client.Set("foo", nil)
+		if err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	// Test passing nil or pointer to nil value for retrieval
+
+	createTest := func(mf zookeeper.MarshalFormat) func(t *testing.T) {
+		return func(t *testing.T) {
+			client := createClient(t, mf)
+
+			// Prep
+			err := client.Set("foo", test.Foo{Bar: "baz"})
+			if err != nil {
+				t.Error(err)
+			}
+
+			_, err = client.Get("foo", nil) // actually nil
+			if err == nil {
+				t.Error("An error was expected")
+			}
+
+			var i interface{} // actually nil
+			_, err = client.Get("foo", i)
+			if err == nil {
+				t.Error("An error was expected")
+			}
+
+			var valPtr *test.Foo // nil value
+			_, err = client.Get("foo", valPtr)
+			if err == nil {
+				t.Error("An error was expected")
+			}
+		}
+	}
+	t.Run("get with nil / nil value parameter", createTest(zookeeper.JSON))
+	t.Run("get with nil / nil value parameter", createTest(zookeeper.Gob))
+}
+
+// TestClose tests if the close method returns any errors.
+//
+// Note: This test is only executed if the initial connection to ZooKeeper works.
+func TestClose(t *testing.T) {
+	if !checkConnection() {
+		t.Skip("No connection to ZooKeeper could be established. Probably not running in a proper test environment.")
+	}
+
+	client := createClient(t, zookeeper.JSON)
+	err := client.Close()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// checkConnection returns true if a connection could be made, false otherwise.
+func checkConnection() bool {
+	conn, events, err := zk.Connect([]string{"127.0.0.1:2181"}, 2*time.Second)
+	if err != nil {
+		log.Printf("An error occurred during testing the connection to the server: %v\n", err)
+		return false
+	}
+	defer conn.Close()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case event := <-events:
+			if event.State == zk.StateHasSession {
+				return true
+			}
+		case <-timeout:
+			log.Println("An error occurred during testing the connection to the server: No session could be established")
+			return false
+		}
+	}
+}
+
+func createClient(t *testing.T, mf zookeeper.MarshalFormat) zookeeper.Client {
+	options := zookeeper.Options{
+		MarshalFormat: mf,
+	}
+	client, err := zookeeper.NewClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}